@@ -0,0 +1,242 @@
+package autodiff
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// Add another variable of the same shape
+func (v *Variable) Add(other *Variable) *Variable {
+	return record(v.Value.Add(other.Value), func(grad matrix.NDArray) {
+		v.accumulate(grad)
+		other.accumulate(grad)
+	}, v, other)
+}
+
+// Subtract another variable of the same shape
+func (v *Variable) Sub(other *Variable) *Variable {
+	return record(v.Value.Sub(other.Value), func(grad matrix.NDArray) {
+		v.accumulate(grad)
+		other.accumulate(grad.ItemProd(-1))
+	}, v, other)
+}
+
+// Multiply elementwise by another variable of the same shape
+func (v *Variable) Prod(other *Variable) *Variable {
+	return record(v.Value.Prod(other.Value), func(grad matrix.NDArray) {
+		v.accumulate(grad.Prod(other.Value))
+		other.accumulate(grad.Prod(v.Value))
+	}, v, other)
+}
+
+// Divide elementwise by another variable of the same shape
+func (v *Variable) Div(other *Variable) *Variable {
+	return record(v.Value.Div(other.Value), func(grad matrix.NDArray) {
+		v.accumulate(grad.Div(other.Value))
+		if other.needsGrad {
+			other.accumulate(grad.Prod(v.Value).Div(other.Value.Prod(other.Value)).ItemProd(-1))
+		}
+	}, v, other)
+}
+
+// Multiply by a constant
+func (v *Variable) Scale(factor float64) *Variable {
+	return record(v.Value.ItemProd(factor), func(grad matrix.NDArray) {
+		v.accumulate(grad.ItemProd(factor))
+	}, v)
+}
+
+// Add a constant to every element
+func (v *Variable) AddScalar(value float64) *Variable {
+	return record(v.Value.Dense().ItemAdd(value), func(grad matrix.NDArray) {
+		v.accumulate(grad)
+	}, v)
+}
+
+// Get the matrix product with another variable. Both must be 2D.
+func (v *Variable) MProd(other *Variable) *Variable {
+	a, b := v.Value.M(), other.Value.M()
+	return record(a.MProd(b), func(grad matrix.NDArray) {
+		g := grad.M()
+		if v.sparse {
+			// Only compute the entries of G B' at the parameter's nonzeros
+			v.accumulate(v.mask(func(pos []int) float64 {
+				var sum float64
+				for j := 0; j < g.Cols(); j++ {
+					sum += g.Item(pos[0], j) * b.Item(pos[1], j)
+				}
+				return sum
+			}))
+		} else if v.needsGrad {
+			v.accumulate(g.MProd(b.T()))
+		}
+		if other.sparse {
+			other.accumulate(other.mask(func(pos []int) float64 {
+				var sum float64
+				for i := 0; i < g.Rows(); i++ {
+					sum += a.Item(i, pos[0]) * g.Item(i, pos[1])
+				}
+				return sum
+			}))
+		} else if other.needsGrad {
+			other.accumulate(a.T().MProd(g))
+		}
+	}, v, other)
+}
+
+// Transpose a 2D variable
+func (v *Variable) T() *Variable {
+	return record(v.Value.M().T(), func(grad matrix.NDArray) {
+		v.accumulate(grad.M().T())
+	}, v)
+}
+
+// Apply a differentiable function to each element, given the function and
+// its derivative
+func (v *Variable) Apply(f, deriv func(float64) float64) *Variable {
+	return record(v.Value.Dense().Apply(f), func(grad matrix.NDArray) {
+		v.accumulate(grad.Prod(v.Value.Dense().Apply(deriv)))
+	}, v)
+}
+
+// Apply a ufunc whose derivative is best written in terms of its output
+func (v *Variable) applyOut(value matrix.NDArray, deriv func(in, out float64) float64) *Variable {
+	var out *Variable
+	out = record(value, func(grad matrix.NDArray) {
+		d := v.Value.Dense()
+		for i := 0; i < d.Size(); i++ {
+			d.FlatItemSet(deriv(d.FlatItem(i), out.Value.FlatItem(i)), i)
+		}
+		v.accumulate(grad.Prod(d))
+	}, v)
+	return out
+}
+
+// Get the absolute value of each element. The derivative at zero is taken
+// to be zero.
+func (v *Variable) Abs() *Variable {
+	return v.applyOut(matrix.Abs(v.Value), func(in, out float64) float64 {
+		switch {
+		case in > 0:
+			return 1
+		case in < 0:
+			return -1
+		}
+		return 0
+	})
+}
+
+// Get the cosine of each element
+func (v *Variable) Cos() *Variable {
+	return v.applyOut(matrix.Cos(v.Value), func(in, out float64) float64 {
+		return -math.Sin(in)
+	})
+}
+
+// Get the exponential of each element
+func (v *Variable) Exp() *Variable {
+	return v.applyOut(matrix.Exp(v.Value), func(in, out float64) float64 {
+		return out
+	})
+}
+
+// Get the natural logarithm of each element
+func (v *Variable) Log() *Variable {
+	return v.applyOut(matrix.Log(v.Value), func(in, out float64) float64 {
+		return 1 / in
+	})
+}
+
+// Raise each element to a power
+func (v *Variable) Pow(power float64) *Variable {
+	return v.applyOut(matrix.Pow(v.Value, power), func(in, out float64) float64 {
+		return power * math.Pow(in, power-1)
+	})
+}
+
+// Get the larger of each element and zero
+func (v *Variable) Relu() *Variable {
+	return v.applyOut(matrix.Maximum(v.Value, matrix.Zeros(v.Value.Shape()...)), func(in, out float64) float64 {
+		if in > 0 {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Get the logistic sigmoid of each element
+func (v *Variable) Sigmoid() *Variable {
+	return v.applyOut(matrix.Sigmoid(v.Value), func(in, out float64) float64 {
+		return out * (1 - out)
+	})
+}
+
+// Get the sine of each element
+func (v *Variable) Sin() *Variable {
+	return v.applyOut(matrix.Sin(v.Value), func(in, out float64) float64 {
+		return math.Cos(in)
+	})
+}
+
+// Get the square root of each element
+func (v *Variable) Sqrt() *Variable {
+	return v.applyOut(matrix.Sqrt(v.Value), func(in, out float64) float64 {
+		return 0.5 / out
+	})
+}
+
+// Get the hyperbolic tangent of each element
+func (v *Variable) Tanh() *Variable {
+	return v.applyOut(matrix.Tanh(v.Value), func(in, out float64) float64 {
+		return 1 - out*out
+	})
+}
+
+// Get the sum of all elements, as a variable of shape [1]
+func (v *Variable) Sum() *Variable {
+	return record(matrix.A1(v.Value.Sum()), func(grad matrix.NDArray) {
+		v.accumulate(matrix.WithValue(grad.FlatItem(0), v.Value.Shape()...))
+	}, v)
+}
+
+// Get the mean of all elements, as a variable of shape [1]
+func (v *Variable) Mean() *Variable {
+	n := float64(v.Value.Size())
+	return record(matrix.A1(matrix.Mean(v.Value)), func(grad matrix.NDArray) {
+		v.accumulate(matrix.WithValue(grad.FlatItem(0)/n, v.Value.Shape()...))
+	}, v)
+}
+
+// Spread the gradient of a reduction along an axis back over the input,
+// scaled by a factor
+func (v *Variable) unreduce(grad matrix.NDArray, axis int, factor float64) matrix.NDArray {
+	return matrix.FromFunction(v.Value.Shape(), func(index []int) float64 {
+		if len(index) == 1 {
+			return grad.FlatItem(0) * factor
+		}
+		reduced := make([]int, 0, len(index)-1)
+		reduced = append(reduced, index[:axis]...)
+		reduced = append(reduced, index[axis+1:]...)
+		return grad.Item(reduced...) * factor
+	})
+}
+
+// Get the sum along an axis. See matrix.SumAxis for the result's shape.
+func (v *Variable) SumAxis(axis int) *Variable {
+	return record(matrix.SumAxis(v.Value, axis), func(grad matrix.NDArray) {
+		v.accumulate(v.unreduce(grad, axis, 1))
+	}, v)
+}
+
+// Get the mean along an axis. See matrix.MeanAxis for the result's shape.
+func (v *Variable) MeanAxis(axis int) *Variable {
+	shape := v.Value.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	n := float64(shape[axis])
+	return record(matrix.MeanAxis(v.Value, axis), func(grad matrix.NDArray) {
+		v.accumulate(v.unreduce(grad, axis, 1/n))
+	}, v)
+}
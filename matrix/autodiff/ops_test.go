@@ -0,0 +1,94 @@
+package autodiff
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"testing"
+)
+
+// Check the gradient of a scalar function of one parameter against finite
+// differences
+func checkGrad(x matrix.NDArray, f func(v *Variable) *Variable) {
+	tape := NewTape()
+	v := tape.Var(x)
+	f(v).Backward()
+	numeric := numericGrad(func(y matrix.NDArray) float64 {
+		return f(NewTape().Var(y)).Scalar()
+	}, x)
+	So(matrix.AllClose(v.Grad, numeric, 0, 1e-6, false), ShouldBeTrue)
+}
+
+func TestOps(t *testing.T) {
+	Convey("Given a positive matrix", t, func() {
+		x := matrix.A2([]float64{0.5, 1.5, 2}, []float64{1, 0.25, 3})
+		other := matrix.A2([]float64{2, -1, 0.5}, []float64{1, 3, -2})
+
+		Convey("Arithmetic gradients match finite differences", func() {
+			checkGrad(x, func(v *Variable) *Variable {
+				c := v.tape.Const(other)
+				return v.Add(c).Prod(v.Sub(c)).Sum()
+			})
+			checkGrad(x, func(v *Variable) *Variable {
+				c := v.tape.Const(other)
+				return c.Div(v).Add(v.Div(c.AddScalar(5))).Sum()
+			})
+			checkGrad(x, func(v *Variable) *Variable {
+				return v.Scale(3).AddScalar(1).Prod(v).Mean()
+			})
+		})
+
+		Convey("Matrix product gradients match finite differences", func() {
+			checkGrad(x, func(v *Variable) *Variable {
+				c := v.tape.Const(other.M().T())
+				out := v.MProd(c)
+				return out.Prod(out).Sum()
+			})
+			checkGrad(x, func(v *Variable) *Variable {
+				out := v.T().MProd(v)
+				return out.Tanh().Sum()
+			})
+		})
+
+		Convey("Ufunc gradients match finite differences", func() {
+			ufuncs := []func(*Variable) *Variable{
+				(*Variable).Abs, (*Variable).Cos, (*Variable).Exp, (*Variable).Log,
+				(*Variable).Relu, (*Variable).Sigmoid, (*Variable).Sin,
+				(*Variable).Sqrt, (*Variable).Tanh,
+				func(v *Variable) *Variable { return v.Pow(2.5) },
+				func(v *Variable) *Variable {
+					return v.Apply(func(a float64) float64 { return a * a * a },
+						func(a float64) float64 { return 3 * a * a })
+				},
+			}
+			for _, u := range ufuncs {
+				checkGrad(x, func(v *Variable) *Variable { return u(v).Sum() })
+			}
+		})
+
+		Convey("Reduction gradients match finite differences", func() {
+			checkGrad(x, func(v *Variable) *Variable {
+				return v.SumAxis(0).Exp().Sum()
+			})
+			checkGrad(x, func(v *Variable) *Variable {
+				return v.MeanAxis(1).Pow(2).Sum()
+			})
+			checkGrad(matrix.A1(1, 2, 3), func(v *Variable) *Variable {
+				return v.MeanAxis(0).Pow(2).Sum()
+			})
+			So(func() { NewTape().Var(x).MeanAxis(2) }, ShouldPanic)
+		})
+
+		Convey("Gradients flow through a small network", func() {
+			inputs := matrix.A2([]float64{1, -1}, []float64{0.5, 2}, []float64{-1, 0})
+			targets := matrix.A2([]float64{1}, []float64{0}, []float64{1})
+			hidden := matrix.A2([]float64{0.1, -0.2, 0.3}, []float64{0.4, 0.5, -0.6})
+			checkGrad(hidden, func(w *Variable) *Variable {
+				in := w.tape.Const(inputs)
+				out := w.tape.Const(matrix.A2([]float64{1}, []float64{-1}, []float64{0.5}))
+				pred := in.MProd(w).Tanh().MProd(out).Sigmoid()
+				diff := pred.Sub(w.tape.Const(targets))
+				return diff.Prod(diff).Mean()
+			})
+		})
+	})
+}
@@ -0,0 +1,176 @@
+// The autodiff package computes gradients by reverse-mode automatic
+// differentiation. Operations on Variables are recorded on a Tape, and
+// Backward replays the tape in reverse to find the gradient of a scalar
+// output with respect to every Variable.
+//
+// To fit a linear model by gradient descent:
+//     tape := autodiff.NewTape()
+//     w := tape.Var(matrix.Zeros(3, 1).M())
+//     inputs, targets := tape.Const(x), tape.Const(y)
+//     for step := 0; step < 100; step++ {
+//             residual := inputs.MProd(w).Sub(targets)
+//             loss := residual.Prod(residual).Mean()
+//             loss.Backward()
+//             w.Value = w.Value.Sub(w.Grad.ItemProd(0.1))
+//             tape.Reset()
+//     }
+package autodiff
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+)
+
+// A record of the operations on a set of Variables
+type Tape struct {
+	nodes []*Variable
+}
+
+// Create an empty tape
+func NewTape() *Tape {
+	return &Tape{}
+}
+
+// A value computed on a tape. After Backward, Grad holds the gradient of
+// the output with respect to this value, with the same shape as Value.
+type Variable struct {
+	Value matrix.NDArray
+	Grad  matrix.NDArray
+
+	tape      *Tape
+	needsGrad bool
+	leaf      bool
+
+	// Whether this is a sparse coo parameter, whose gradient is restricted
+	// to the nonzero positions in pattern
+	sparse  bool
+	pattern [][]int
+
+	// Pass the gradient of this variable on to its inputs
+	backward func(grad matrix.NDArray)
+}
+
+// Create a parameter: a variable whose gradient Backward computes. If the
+// value is a sparse coo matrix, the gradient is also a sparse coo matrix,
+// holding only the entries at the value's nonzero positions; zero entries
+// are treated as structural and not trained.
+func (t *Tape) Var(value matrix.NDArray) *Variable {
+	v := &Variable{Value: value, tape: t, needsGrad: true, leaf: true}
+	if value.Sparsity() == matrix.SparseCooMatrix {
+		v.sparse = true
+		value.VisitNonzero(func(pos []int, val float64) bool {
+			v.pattern = append(v.pattern, append([]int(nil), pos...))
+			return true
+		})
+	}
+	t.nodes = append(t.nodes, v)
+	return v
+}
+
+// Create a constant: a variable with no gradient, such as training data
+func (t *Tape) Const(value matrix.NDArray) *Variable {
+	v := &Variable{Value: value, tape: t, leaf: true}
+	t.nodes = append(t.nodes, v)
+	return v
+}
+
+// Forget the recorded operations, keeping the parameters and constants so
+// that they can be used to record new ones
+func (t *Tape) Reset() {
+	var leaves []*Variable
+	for _, v := range t.nodes {
+		if v.leaf {
+			leaves = append(leaves, v)
+		}
+	}
+	t.nodes = leaves
+}
+
+// Record the result of an operation on some inputs. backward receives the
+// gradient of the result, and should accumulate the gradients of the inputs.
+func record(value matrix.NDArray, backward func(grad matrix.NDArray), inputs ...*Variable) *Variable {
+	t := inputs[0].tape
+	v := &Variable{Value: value, tape: t, backward: backward}
+	for _, in := range inputs {
+		if in.tape != t {
+			panic("Can't combine variables from different tapes")
+		}
+		v.needsGrad = v.needsGrad || in.needsGrad
+	}
+	t.nodes = append(t.nodes, v)
+	return v
+}
+
+// Add to the gradient of a variable
+func (v *Variable) accumulate(grad matrix.NDArray) {
+	if !v.needsGrad {
+		return
+	}
+	if v.sparse {
+		grad = v.mask(func(pos []int) float64 {
+			return grad.Item(append([]int(nil), pos...)...)
+		})
+	}
+	if v.Grad == nil {
+		v.Grad = grad.Copy()
+	} else {
+		v.Grad = v.Grad.Add(grad)
+	}
+}
+
+// Get a sparse coo gradient for a sparse parameter, with the entry at each
+// nonzero position of the value given by f
+func (v *Variable) mask(f func(pos []int) float64) matrix.NDArray {
+	shape := v.Value.Shape()
+	grad := matrix.SparseCoo(shape[0], shape[1])
+	for _, pos := range v.pattern {
+		grad.ItemSet(f(pos), pos[0], pos[1])
+	}
+	return grad
+}
+
+// Compute the gradient of this variable, which must have a single element,
+// with respect to every variable on its tape which it depends on. Gradients
+// from a previous call are replaced. Parameters the output doesn't depend on
+// get zero gradients, and constants get nil.
+func (v *Variable) Backward() {
+	if v.Value.Size() != 1 {
+		panic(fmt.Sprintf("Backward needs a scalar output, but got shape %v", v.Value.Shape()))
+	}
+	last := -1
+	for i, node := range v.tape.nodes {
+		node.Grad = nil
+		if node == v {
+			last = i
+		}
+	}
+	if last < 0 {
+		panic("Backward was called on a variable that is no longer on its tape")
+	}
+
+	v.Grad = matrix.Ones(v.Value.Shape()...)
+	for i := last; i >= 0; i-- {
+		node := v.tape.nodes[i]
+		if node.Grad != nil && node.backward != nil && node.needsGrad {
+			node.backward(node.Grad)
+		}
+	}
+
+	for _, node := range v.tape.nodes {
+		if node.leaf && node.needsGrad && node.Grad == nil {
+			if node.sparse {
+				node.Grad = node.mask(func(pos []int) float64 { return 0 })
+			} else {
+				node.Grad = matrix.Zeros(node.Value.Shape()...)
+			}
+		}
+	}
+}
+
+// Get the single value of a scalar variable
+func (v *Variable) Scalar() float64 {
+	if v.Value.Size() != 1 {
+		panic(fmt.Sprintf("Can't get a scalar from shape %v", v.Value.Shape()))
+	}
+	return v.Value.FlatItem(0)
+}
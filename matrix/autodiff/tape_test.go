@@ -0,0 +1,106 @@
+package autodiff
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"testing"
+)
+
+// Estimate the gradient of f at x by central differences
+func numericGrad(f func(x matrix.NDArray) float64, x matrix.NDArray) matrix.NDArray {
+	const h = 1e-6
+	grad := matrix.Dense(x.Shape()...)
+	for i := 0; i < x.Size(); i++ {
+		shifted := x.Dense()
+		shifted.FlatItemSet(x.FlatItem(i)+h, i)
+		fwd := f(shifted)
+		shifted.FlatItemSet(x.FlatItem(i)-h, i)
+		grad.FlatItemSet((fwd-f(shifted))/(2*h), i)
+	}
+	return grad
+}
+
+func TestTape(t *testing.T) {
+	Convey("Given a tape with parameters and constants", t, func() {
+		tape := NewTape()
+		x := tape.Var(matrix.A1(1, 2, 3))
+		y := tape.Var(matrix.A1(4, 5, 6))
+		unused := tape.Var(matrix.A2([]float64{1, 2}))
+		c := tape.Const(matrix.A1(2, 2, 2))
+
+		Convey("Backward fills in the gradients", func() {
+			loss := x.Prod(y).Add(x.Prod(c)).Sum()
+			So(loss.Scalar(), ShouldEqual, 44)
+			loss.Backward()
+			So(x.Grad.Equal(matrix.A1(6, 7, 8)), ShouldBeTrue)
+			So(y.Grad.Equal(matrix.A1(1, 2, 3)), ShouldBeTrue)
+			So(unused.Grad.Equal(matrix.Zeros(1, 2)), ShouldBeTrue)
+			So(c.Grad, ShouldBeNil)
+			So(loss.Grad.Equal(matrix.A1(1)), ShouldBeTrue)
+		})
+
+		Convey("Gradients accumulate over repeated uses", func() {
+			loss := x.Prod(x).Prod(x).Sum()
+			loss.Backward()
+			So(x.Grad.Equal(matrix.A1(3, 12, 27)), ShouldBeTrue)
+		})
+
+		Convey("A second Backward replaces the gradients", func() {
+			loss := x.Sum()
+			loss.Backward()
+			loss.Backward()
+			So(x.Grad.Equal(matrix.A1(1, 1, 1)), ShouldBeTrue)
+		})
+
+		Convey("Reset keeps the leaves and drops the operations", func() {
+			loss := x.Scale(2).Sum()
+			tape.Reset()
+			So(len(tape.nodes), ShouldEqual, 4)
+			So(func() { loss.Backward() }, ShouldPanic)
+			x.Add(y).Sum().Backward()
+			So(y.Grad.Equal(matrix.A1(1, 1, 1)), ShouldBeTrue)
+		})
+
+		Convey("Bad uses panic", func() {
+			So(func() { x.Add(y).Backward() }, ShouldPanic)
+			So(func() { x.Scalar() }, ShouldPanic)
+			So(func() { x.Add(NewTape().Var(matrix.A1(1, 1, 1))) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a sparse coo parameter", t, func() {
+		tape := NewTape()
+		w := tape.Var(matrix.SparseCoo(2, 3, 1, 0, 0, 0, 2, 3))
+		x := tape.Const(matrix.A2([]float64{1, 2}, []float64{3, 4}, []float64{5, 6}))
+
+		Convey("Its gradient is sparse with the same pattern", func() {
+			out := w.MProd(x)
+			out.Prod(out).Sum().Backward()
+			So(w.Grad.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(w.Grad.CountNonzero(), ShouldBeLessThanOrEqualTo, 3)
+			So(w.Grad.Item(0, 1), ShouldEqual, 0)
+			sparseGrad := w.Grad
+
+			// Compare with the dense gradient at the nonzero positions
+			dense := tape.Var(w.Value.Dense())
+			out = dense.MProd(x)
+			out.Prod(out).Sum().Backward()
+			for _, pos := range [][]int{{0, 0}, {1, 1}, {1, 2}} {
+				So(sparseGrad.Item(pos...), ShouldAlmostEqual, dense.Grad.Item(pos...), 1e-9)
+			}
+			So(dense.Grad.Item(0, 1), ShouldNotEqual, 0)
+		})
+
+		Convey("Elementwise operations also give sparse gradients", func() {
+			w.Prod(w).Sum().Backward()
+			So(w.Grad.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(w.Grad.Item(1, 2), ShouldEqual, 6)
+		})
+
+		Convey("Unused sparse parameters get empty sparse gradients", func() {
+			x.Sum().AddScalar(0).Backward()
+			So(w.Grad.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(w.Grad.CountNonzero(), ShouldEqual, 0)
+		})
+	})
+}
@@ -187,14 +187,20 @@ func AnyF2(array NDArray, f func(v1, v2 float64) bool, other NDArray) bool {
 	return false
 }
 
-// Return the result of applying a function to all elements
+// Return the result of applying a function to all elements. Infinite results
+// from finite inputs are reported as Overflow, and NaN results from non-NaN
+// inputs as Invalid; see SetErr.
 func Apply(array NDArray, f func(float64) float64) NDArray {
 	result := array.Dense()
 	size := result.Size()
+	errs := floatErrors{op: "Apply"}
 	for i := 0; i < size; i++ {
-		value := f(result.FlatItem(i))
+		in := result.FlatItem(i)
+		value := f(in)
+		errs.check(in, value)
 		result.FlatItemSet(value, i)
 	}
+	errs.report()
 	return result
 }
 
@@ -303,7 +309,8 @@ func Dist(m Matrix, t DistType) Matrix {
 }
 
 // Return the element-wise quotient of this array and one or more others.
-// This function defines 0 / 0 = 0, so it's useful for sparse arrays.
+// This function defines 0 / 0 = 0, so it's useful for sparse arrays. Other
+// floating-point errors are handled as configured by SetErr.
 func Div(array NDArray, others ...NDArray) NDArray {
 	sh := array.Shape()
 	for _, o := range others {
@@ -319,12 +326,17 @@ func Div(array NDArray, others ...NDArray) NDArray {
 	}
 
 	result := array.Copy()
+	errs := floatErrors{op: "Div"}
 	for _, o := range others {
 		result.VisitNonzero(func(pos []int, value float64) bool {
-			result.ItemSet(value/o.Item(pos...), pos...)
+			den := o.Item(pos...)
+			q := value / den
+			errs.checkDiv(value, den, q)
+			result.ItemSet(q, pos...)
 			return true
 		})
 	}
+	errs.report()
 	return result
 }
 
@@ -374,16 +386,22 @@ func ItemAdd(array NDArray, value float64) NDArray {
 	return result
 }
 
-// Divide each array element by a scalar value
+// Divide each array element by a scalar value. Zero elements are left as
+// zero, even when dividing by zero; other floating-point errors are handled
+// as configured by SetErr.
 func ItemDiv(array NDArray, value float64) NDArray {
 	if value == 1 {
 		return array.Copy()
 	}
 	result := array.Copy()
+	errs := floatErrors{op: "ItemDiv"}
 	result.VisitNonzero(func(pos []int, v float64) bool {
-		result.ItemSet(v/value, pos...)
+		q := v / value
+		errs.checkDiv(v, value, q)
+		result.ItemSet(q, pos...)
 		return true
 	})
+	errs.report()
 	return result
 }
 
@@ -499,6 +517,31 @@ func MProd(array Matrix, others ...Matrix) Matrix {
 				}
 			}
 
+		} else if leftSp == SparseCooMatrix {
+			// Add each nonzero's multiple of a row of the dense matrix
+			result = Dense(leftSh[0], rightSh[1]).M()
+			resArr := result.Array()
+			rArr := right.Array()
+			left.VisitNonzero(func(pos []int, value float64) bool {
+				row, src := pos[0]*rightSh[1], pos[1]*rightSh[1]
+				for j := 0; j < rightSh[1]; j++ {
+					resArr[row+j] += value * rArr[src+j]
+				}
+				return true
+			})
+
+		} else if rightSp == SparseCooMatrix {
+			// Add each nonzero's multiple of a column of the dense matrix
+			result = Dense(leftSh[0], rightSh[1]).M()
+			resArr := result.Array()
+			lArr := left.Array()
+			right.VisitNonzero(func(pos []int, value float64) bool {
+				for i := 0; i < leftSh[0]; i++ {
+					resArr[i*rightSh[1]+pos[1]] += lArr[i*leftSh[1]+pos[0]] * value
+				}
+				return true
+			})
+
 		} else {
 			result = Dense(leftSh[0], rightSh[1]).M()
 			resArr := result.Array()
@@ -556,12 +599,28 @@ func Min(array NDArray) float64 {
 	return min
 }
 
-// Return a copy of the array, normalized to sum to 1
+// Return a copy of the array, normalized to sum to 1. If the array sums to
+// zero, a copy is returned unchanged; that is reported as DivideByZero if
+// the array has nonzero elements. See SetErr.
 func Normalize(array NDArray) NDArray {
 	s := array.Sum()
 	if s != 0 && s != 1 {
-		return array.ItemDiv(s)
+		errs := floatErrors{op: "Normalize"}
+		result := array.Copy()
+		result.VisitNonzero(func(pos []int, v float64) bool {
+			q := v / s
+			errs.checkDiv(v, s, q)
+			result.ItemSet(q, pos...)
+			return true
+		})
+		errs.report()
+		return result
 	} else {
+		if s == 0 && array.Any() {
+			errs := floatErrors{op: "Normalize"}
+			errs.counts[DivideByZero] = 1
+			errs.report()
+		}
 		return array.Copy()
 	}
 }
@@ -976,6 +976,40 @@ func TestMProd(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given sparse coo 2x3 and dense 3x2 matrixes", t, func() {
+		m1 := SparseCoo(2, 3)
+		m1.ItemSet(2, 0, 1)
+		m1.ItemSet(3, 1, 0)
+		m1.ItemSet(4, 1, 2)
+		m2 := A([]int{3, 2},
+			1, 2,
+			3, 4,
+			5, 6).M()
+		Convey("MProd is correct and dense", func() {
+			p := MProd(m1, m2)
+			So(p.Sparsity(), ShouldEqual, DenseArray)
+			So(p.Array(), ShouldResemble, []float64{
+				6, 8,
+				23, 30,
+			})
+		})
+		Convey("MProd is correct for dense times sparse", func() {
+			p := MProd(m2, m1)
+			So(p.Sparsity(), ShouldEqual, DenseArray)
+			So(p.Array(), ShouldResemble, []float64{
+				6, 2, 8,
+				12, 6, 16,
+				18, 10, 24,
+			})
+		})
+		Convey("MProd is correct for a transposed sparse matrix", func() {
+			p := MProd(m1.T(), A([]int{2, 1}, 1, 2).M())
+			So(p.Array(), ShouldResemble, []float64{6, 2, 8})
+			p = MProd(A([]int{1, 3}, 1, 2, 3).M(), m1.T())
+			So(p.Array(), ShouldResemble, []float64{4, 15})
+		})
+	})
 }
 
 func TestProd(t *testing.T) {
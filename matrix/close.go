@@ -0,0 +1,90 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+)
+
+// Returns true if two values are equal within a tolerance, using NumPy's
+// rule |a - b| <= atol + rtol * |b|. Infinities are only close to themselves.
+// NaN is close to NaN only if equalNaN is true.
+func isClose(a, b, rtol, atol float64, equalNaN bool) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return equalNaN && math.IsNaN(a) && math.IsNaN(b)
+	}
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return a == b
+	}
+	return math.Abs(a-b) <= atol+rtol*math.Abs(b)
+}
+
+// Get an element without letting the array modify the position slice
+func itemAt(array NDArray, pos []int) float64 {
+	return array.Item(append([]int{}, pos...)...)
+}
+
+// Visit each position where either array has a nonzero element, with the
+// values of both arrays there. Positions where both arrays are zero are
+// skipped, and positions nonzero in both are visited once. Stops early if f
+// returns false, and returns false if it did.
+func visitUnion(a, b NDArray, f func(pos []int, v1, v2 float64) bool) bool {
+	if !a.VisitNonzero(func(pos []int, value float64) bool {
+		return f(pos, value, itemAt(b, pos))
+	}) {
+		return false
+	}
+	return b.VisitNonzero(func(pos []int, value float64) bool {
+		if v1 := itemAt(a, pos); v1 == 0 {
+			return f(pos, v1, value)
+		}
+		return true
+	})
+}
+
+// Returns true if two arrays have the same shape
+func ShapeEqual(a, b NDArray) bool {
+	return sameShape(a.Shape(), b.Shape())
+}
+
+// Get a dense mask array holding 1 where the elements of two arrays are close
+// and 0 elsewhere. See AllClose for the meaning of the tolerances.
+func IsClose(a, b NDArray, rtol, atol float64, equalNaN bool) NDArray {
+	if !ShapeEqual(a, b) {
+		panic(fmt.Sprintf("Can't compare arrays with shapes %v and %v", a.Shape(), b.Shape()))
+	}
+	result := Ones(a.Shape()...)
+	shape := a.Shape()
+	visitUnion(a, b, func(pos []int, v1, v2 float64) bool {
+		if !isClose(v1, v2, rtol, atol, equalNaN) {
+			result.FlatItemSet(0, ndToFlat(shape, pos))
+		}
+		return true
+	})
+	return result
+}
+
+// Returns true if two arrays have the same shape and every pair of elements
+// satisfies |a - b| <= atol + rtol * |b|. Note that this is not symmetric in
+// a and b. Infinities are only close to themselves, and NaN is close to NaN
+// only if equalNaN is true. Positions where both arrays are zero are never
+// visited, so comparing sparse arrays is fast.
+func AllClose(a, b NDArray, rtol, atol float64, equalNaN bool) bool {
+	if !ShapeEqual(a, b) {
+		return false
+	}
+	return visitUnion(a, b, func(pos []int, v1, v2 float64) bool {
+		return isClose(v1, v2, rtol, atol, equalNaN)
+	})
+}
+
+// Returns true if two arrays have the same shape and equal elements. Unlike
+// Equal, NaN values in the same position are considered equal if equalNaN is
+// true.
+func ArrayEqual(a, b NDArray, equalNaN bool) bool {
+	if !ShapeEqual(a, b) {
+		return false
+	}
+	return visitUnion(a, b, func(pos []int, v1, v2 float64) bool {
+		return v1 == v2 || (equalNaN && math.IsNaN(v1) && math.IsNaN(v2))
+	})
+}
@@ -0,0 +1,83 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestIsClose(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+
+	Convey("Given dense arrays", t, func() {
+		a := A1(1, 100, 0, nan, inf, inf)
+		b := A1(1.05, 101, 1e-9, nan, inf, -inf)
+
+		Convey("IsClose returns a mask", func() {
+			So(IsClose(a, b, 0.01, 1e-8, false).Equal(A1(0, 1, 1, 0, 1, 0)), ShouldBeTrue)
+			So(IsClose(a, b, 0.1, 0, true).Equal(A1(1, 1, 0, 1, 1, 0)), ShouldBeTrue)
+		})
+
+		Convey("AllClose requires every pair to be close", func() {
+			So(AllClose(A1(1, 2), A1(1+1e-10, 2), 1e-9, 0, false), ShouldBeTrue)
+			So(AllClose(a, b, 0.1, 1e-8, true), ShouldBeFalse)
+			So(AllClose(A1(nan, 1), A1(nan, 1), 0, 0, false), ShouldBeFalse)
+			So(AllClose(A1(nan, 1), A1(nan, 1), 0, 0, true), ShouldBeTrue)
+		})
+
+		Convey("Arrays with different shapes are not close", func() {
+			So(AllClose(Ones(2, 2), Ones(4), 1, 1, true), ShouldBeFalse)
+			So(func() { IsClose(Ones(2, 2), Ones(4), 1, 1, true) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given sparse and dense matrices", t, func() {
+		coo := SparseCoo(3, 3,
+			0, 1, 0,
+			0, 0, 2,
+			3, 0, 0)
+		dense := M(3, 3,
+			0, 1, 0,
+			0, 0, 2+1e-12,
+			3, 0, 0)
+
+		Convey("Sparse and dense arrays can be compared", func() {
+			So(AllClose(coo, dense, 1e-9, 0, false), ShouldBeTrue)
+			So(AllClose(dense, coo, 1e-9, 0, false), ShouldBeTrue)
+			So(AllClose(coo, dense, 0, 0, false), ShouldBeFalse)
+		})
+
+		Convey("Nonzeros in only one array are compared to zero", func() {
+			So(AllClose(coo, coo.T(), 1e-9, 0, false), ShouldBeFalse)
+			So(AllClose(Diag(1e-10, 0), SparseCoo(2, 2), 0, 1e-9, false), ShouldBeTrue)
+			So(AllClose(SparseCoo(2, 2), Diag(0, 1), 0, 1e-9, false), ShouldBeFalse)
+		})
+
+		Convey("IsClose masks sparse comparisons", func() {
+			m := IsClose(coo, Diag(0, 0, 0), 0, 0.5, false)
+			So(m.Equal(M(3, 3, 1, 0, 1, 1, 1, 0, 0, 1, 1)), ShouldBeTrue)
+		})
+	})
+}
+
+func TestArrayEqual(t *testing.T) {
+	nan := math.NaN()
+
+	Convey("ArrayEqual optionally treats NaN as equal", t, func() {
+		So(ArrayEqual(A1(1, nan), A1(1, nan), false), ShouldBeFalse)
+		So(ArrayEqual(A1(1, nan), A1(1, nan), true), ShouldBeTrue)
+		So(ArrayEqual(A1(1, nan), A1(2, nan), true), ShouldBeFalse)
+	})
+
+	Convey("ArrayEqual compares across representations", t, func() {
+		So(ArrayEqual(Eye(3), Identity(3, 3, 0).Dense(), false), ShouldBeTrue)
+		So(ArrayEqual(Eye(3), Identity(3, 3, 1), false), ShouldBeFalse)
+		So(ArrayEqual(Eye(3), Eye(2), false), ShouldBeFalse)
+	})
+
+	Convey("ShapeEqual compares only shapes", t, func() {
+		So(ShapeEqual(Ones(2, 3), SparseCoo(2, 3)), ShouldBeTrue)
+		So(ShapeEqual(Ones(2, 3), Ones(3, 2)), ShouldBeFalse)
+		So(ShapeEqual(Ones(6), Ones(2, 3)), ShouldBeFalse)
+	})
+}
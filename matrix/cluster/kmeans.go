@@ -0,0 +1,389 @@
+// The cluster package groups the rows of a matrix into clusters, loosely
+// following sklearn.cluster.
+//
+// To cluster a large sparse feature matrix into 20 groups:
+//     result := cluster.KMeans(features, 20, &cluster.Options{BatchSize: 1024})
+//     for row, label := range result.Labels {
+//             fmt.Println(row, label)
+//     }
+//
+// To cluster by a precomputed distance matrix:
+//     result := cluster.KMedoids(features.Dist(matrix.EuclideanDist), 20, nil)
+package cluster
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"math/rand"
+)
+
+// Options for KMeans and KMedoids. The zero value of each field selects a
+// default.
+type Options struct {
+	// The number of times KMeans is run from a new initialization, keeping
+	// the result with the lowest inertia; the default is 10
+	Restarts int
+
+	// The most iterations to run; the default is 300. In mini-batch mode,
+	// each batch counts as one iteration.
+	MaxIter int
+
+	// KMeans stops when the total squared movement of the centers is at most
+	// Tol times the mean variance of the features; the default is 1e-4
+	Tol float64
+
+	// If positive, KMeans updates the centers from random batches of this
+	// many rows instead of the full matrix. This is much faster for large
+	// inputs, and sparse coo inputs are never densified.
+	BatchSize int
+
+	// The source of randomness; by default, the global math/rand source
+	Rng *rand.Rand
+}
+
+// The outcome of a clustering
+type Result struct {
+	// The cluster of each row
+	Labels []int
+
+	// The center of each cluster, one per row. KMedoids leaves this nil
+	// when it is given only a distance matrix.
+	Centers matrix.Matrix
+
+	// The rows chosen as cluster centers by KMedoids, or nil for KMeans
+	Medoids []int
+
+	// The sum over rows of the squared distance to the row's center for
+	// KMeans, or of the distance to the row's medoid for KMedoids
+	Inertia float64
+
+	// The number of iterations run, and whether the centers converged
+	Iterations int
+	Converged  bool
+}
+
+// Fill in default options
+func withDefaults(opts *Options) Options {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Restarts == 0 {
+		o.Restarts = 10
+	}
+	if o.MaxIter == 0 {
+		o.MaxIter = 300
+	}
+	if o.Tol == 0 {
+		o.Tol = 1e-4
+	}
+	if o.Rng == nil {
+		o.Rng = rand.New(globalSource{})
+	}
+	return o
+}
+
+// A rand.Source which draws from the global math/rand source
+type globalSource struct{}
+
+func (globalSource) Int63() int64 { return rand.Int63() }
+func (globalSource) Seed(int64)   {}
+
+// The nonzero entries of each row of a matrix, which lets KMeans work on
+// sparse inputs without densifying them
+type points struct {
+	rows, cols int
+	index      [][]int
+	value      [][]float64
+	norm       []float64
+}
+
+// Collect the nonzero entries of the rows of m
+func newPoints(m matrix.Matrix) *points {
+	p := &points{
+		rows:  m.Rows(),
+		cols:  m.Cols(),
+		index: make([][]int, m.Rows()),
+		value: make([][]float64, m.Rows()),
+		norm:  make([]float64, m.Rows()),
+	}
+	m.VisitNonzero(func(pos []int, value float64) bool {
+		p.index[pos[0]] = append(p.index[pos[0]], pos[1])
+		p.value[pos[0]] = append(p.value[pos[0]], value)
+		p.norm[pos[0]] += value * value
+		return true
+	})
+	return p
+}
+
+// Find the squared distance from row i to a center with the given squared
+// norm
+func (p *points) dist(i int, center []float64, norm float64) float64 {
+	var dot float64
+	for pos, col := range p.index[i] {
+		dot += p.value[i][pos] * center[col]
+	}
+	d := p.norm[i] - 2*dot + norm
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Find the nearest center to row i, and the squared distance to it
+func (p *points) nearest(i int, centers [][]float64, norms []float64) (int, float64) {
+	best, bestDist := 0, math.Inf(1)
+	for c, center := range centers {
+		if d := p.dist(i, center, norms[c]); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, bestDist
+}
+
+// Add a multiple of row i to a center
+func (p *points) addTo(center []float64, i int, scale float64) {
+	for pos, col := range p.index[i] {
+		center[col] += scale * p.value[i][pos]
+	}
+}
+
+// Find the mean variance of the features, which scales the tolerance
+func (p *points) meanVariance() float64 {
+	sums := make([]float64, p.cols)
+	var squares float64
+	for i := 0; i < p.rows; i++ {
+		p.addTo(sums, i, 1)
+		squares += p.norm[i]
+	}
+	n := float64(p.rows)
+	variance := squares / n
+	for _, sum := range sums {
+		variance -= (sum / n) * (sum / n)
+	}
+	return variance / float64(p.cols)
+}
+
+// Find the squared norm of each center
+func centerNorms(centers [][]float64) []float64 {
+	norms := make([]float64, len(centers))
+	for c, center := range centers {
+		for _, value := range center {
+			norms[c] += value * value
+		}
+	}
+	return norms
+}
+
+// Partition the rows of m into k clusters, minimizing the sum of squared
+// Euclidean distances from each row to the mean of its cluster. Centers are
+// initialized by k-means++, and the best of several restarts is returned.
+// With opts.BatchSize set, the centers are refined by mini-batch updates
+// rather than full Lloyd iterations.
+func KMeans(m matrix.Matrix, k int, opts *Options) *Result {
+	if k < 1 || k > m.Rows() {
+		panic(fmt.Sprintf("KMeans can't find %d clusters among %d rows", k, m.Rows()))
+	}
+	o := withDefaults(opts)
+	if o.Restarts < 0 || o.MaxIter < 0 || o.BatchSize < 0 {
+		panic(fmt.Sprintf("KMeans got invalid options %+v", o))
+	}
+	p := newPoints(m)
+	tol := o.Tol * p.meanVariance()
+
+	var best *Result
+	for restart := 0; restart < o.Restarts; restart++ {
+		centers := kmeansPlusPlus(p, k, o.Rng)
+		var result *Result
+		if o.BatchSize > 0 {
+			result = miniBatch(p, centers, tol, o)
+		} else {
+			result = lloyd(p, centers, tol, o)
+		}
+		if best == nil || result.Inertia < best.Inertia {
+			best = result
+		}
+	}
+	return best
+}
+
+// Choose initial centers by k-means++: each new center is a row chosen with
+// probability proportional to its squared distance from the nearest center
+// so far
+func kmeansPlusPlus(p *points, k int, rng *rand.Rand) [][]float64 {
+	centers := make([][]float64, 0, k)
+	newCenter := func(i int) {
+		center := make([]float64, p.cols)
+		p.addTo(center, i, 1)
+		centers = append(centers, center)
+	}
+	newCenter(rng.Intn(p.rows))
+
+	closest := make([]float64, p.rows)
+	for i := range closest {
+		closest[i] = math.Inf(1)
+	}
+	for {
+		last := centers[len(centers)-1]
+		norm := centerNorms([][]float64{last})[0]
+		for i := range closest {
+			if d := p.dist(i, last, norm); d < closest[i] {
+				closest[i] = d
+			}
+		}
+		if len(centers) == k {
+			break
+		}
+
+		var total float64
+		for _, d := range closest {
+			total += d
+		}
+
+		// If every row coincides with a center, any row will do
+		chosen := rng.Intn(p.rows)
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range closest {
+				target -= d
+				if target < 0 && d > 0 {
+					chosen = i
+					break
+				}
+			}
+		}
+		newCenter(chosen)
+	}
+	return centers
+}
+
+// Refine the centers by Lloyd's algorithm, alternately assigning each row
+// to its nearest center and moving each center to the mean of its rows
+func lloyd(p *points, centers [][]float64, tol float64, o Options) *Result {
+	k := len(centers)
+	labels := make([]int, p.rows)
+	dists := make([]float64, p.rows)
+	result := &Result{}
+	for result.Iterations < o.MaxIter && !result.Converged {
+		result.Iterations++
+		norms := centerNorms(centers)
+		for i := range labels {
+			labels[i], dists[i] = p.nearest(i, centers, norms)
+		}
+
+		// Move each center to the mean of its rows
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, p.cols)
+		}
+		for i, c := range labels {
+			p.addTo(sums[c], i, 1)
+			counts[c]++
+		}
+		reseatEmpty(p, sums, counts, labels, dists)
+
+		var shift float64
+		for c := range centers {
+			for j := range centers[c] {
+				value := sums[c][j] / float64(counts[c])
+				shift += (value - centers[c][j]) * (value - centers[c][j])
+				centers[c][j] = value
+			}
+		}
+		result.Converged = shift <= tol
+	}
+	finish(p, centers, result)
+	return result
+}
+
+// Move the centers of empty clusters to the rows farthest from their own
+// centers, so that every cluster keeps at least one row
+func reseatEmpty(p *points, sums [][]float64, counts []int, labels []int, dists []float64) {
+	for c := range counts {
+		if counts[c] > 0 {
+			continue
+		}
+		far := -1
+		for i, d := range dists {
+			if counts[labels[i]] > 1 && (far < 0 || d > dists[far]) {
+				far = i
+			}
+		}
+		if far < 0 {
+			continue
+		}
+		old := labels[far]
+		p.addTo(sums[old], far, -1)
+		counts[old]--
+		for j := range sums[c] {
+			sums[c][j] = 0
+		}
+		p.addTo(sums[c], far, 1)
+		counts[c] = 1
+		labels[far], dists[far] = c, 0
+	}
+}
+
+// Refine the centers by mini-batch k-means: each batch of random rows pulls
+// its nearest centers toward it, with a step size that shrinks as a center
+// absorbs more rows
+func miniBatch(p *points, centers [][]float64, tol float64, o Options) *Result {
+	k := len(centers)
+	counts := make([]float64, k)
+	batch := o.BatchSize
+	if batch > p.rows {
+		batch = p.rows
+	}
+	result := &Result{}
+	for result.Iterations < o.MaxIter && !result.Converged {
+		result.Iterations++
+		norms := centerNorms(centers)
+		sums := make([][]float64, k)
+		added := make([]float64, k)
+		for b := 0; b < batch; b++ {
+			i := o.Rng.Intn(p.rows)
+			c, _ := p.nearest(i, centers, norms)
+			if sums[c] == nil {
+				sums[c] = make([]float64, p.cols)
+			}
+			p.addTo(sums[c], i, 1)
+			added[c]++
+		}
+
+		// Each center becomes the running mean of every row assigned to it
+		var shift float64
+		for c := range centers {
+			if added[c] == 0 {
+				continue
+			}
+			total := counts[c] + added[c]
+			for j := range centers[c] {
+				value := (centers[c][j]*counts[c] + sums[c][j]) / total
+				shift += (value - centers[c][j]) * (value - centers[c][j])
+				centers[c][j] = value
+			}
+			counts[c] = total
+		}
+		result.Converged = shift <= tol
+	}
+	finish(p, centers, result)
+	return result
+}
+
+// Assign every row to its nearest center, and fill in the result
+func finish(p *points, centers [][]float64, result *Result) {
+	norms := centerNorms(centers)
+	result.Labels = make([]int, p.rows)
+	result.Inertia = 0
+	for i := range result.Labels {
+		var d float64
+		result.Labels[i], d = p.nearest(i, centers, norms)
+		result.Inertia += d
+	}
+	result.Centers = matrix.Dense(len(centers), p.cols).M()
+	for c, center := range centers {
+		result.Centers.RowSet(c, center)
+	}
+}
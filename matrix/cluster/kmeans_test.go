@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math/rand"
+	"testing"
+)
+
+const Eps = 1e-9
+
+// Three tight groups of four points each
+func blobs() matrix.Matrix {
+	return matrix.M(12, 2,
+		0, 0, 1, 0, 0, 1, 1, 1,
+		10, 0, 11, 0, 10, 1, 11, 1,
+		0, 10, 1, 10, 0, 11, 1, 11)
+}
+
+// A sparse matrix with groups of rows using disjoint blocks of columns
+func sparseBlobs(groups, size, width int) matrix.Matrix {
+	m := matrix.SparseCoo(groups*size, groups*width)
+	for g := 0; g < groups; g++ {
+		for i := 0; i < size; i++ {
+			for j := 0; j < width; j += 2 {
+				m.ItemSet(1+0.1*float64((i+j)%3), g*size+i, g*width+j)
+			}
+		}
+	}
+	return m
+}
+
+// Check that rows are labeled in consecutive groups of the given size
+func sameGroups(labels []int, size int) bool {
+	seen := make(map[int]bool)
+	for start := 0; start < len(labels); start += size {
+		if seen[labels[start]] {
+			return false
+		}
+		seen[labels[start]] = true
+		for i := start; i < start+size; i++ {
+			if labels[i] != labels[start] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestKMeans(t *testing.T) {
+	Convey("Given three separated groups of points", t, func() {
+		m := blobs()
+		result := KMeans(m, 3, &Options{Rng: rand.New(rand.NewSource(1))})
+
+		Convey("KMeans labels each group as one cluster", func() {
+			So(result.Labels, ShouldHaveLength, 12)
+			So(sameGroups(result.Labels, 4), ShouldBeTrue)
+			So(result.Converged, ShouldBeTrue)
+			So(result.Medoids, ShouldBeNil)
+		})
+
+		Convey("The centers are the group means", func() {
+			So(result.Centers.Shape(), ShouldResemble, []int{3, 2})
+			for g, mean := range [][]float64{{0.5, 0.5}, {10.5, 0.5}, {0.5, 10.5}} {
+				c := result.Labels[4*g]
+				So(result.Centers.Item(c, 0), ShouldAlmostEqual, mean[0], Eps)
+				So(result.Centers.Item(c, 1), ShouldAlmostEqual, mean[1], Eps)
+			}
+		})
+
+		Convey("The inertia is the total squared distance to the centers", func() {
+			So(result.Inertia, ShouldAlmostEqual, 6, Eps)
+		})
+
+		Convey("One cluster per point has zero inertia", func() {
+			result := KMeans(m, 12, nil)
+			So(result.Inertia, ShouldAlmostEqual, 0, Eps)
+			labels := make(map[int]bool)
+			for _, label := range result.Labels {
+				labels[label] = true
+			}
+			So(labels, ShouldHaveLength, 12)
+		})
+
+		Convey("A single cluster has the mean as its center", func() {
+			result := KMeans(m, 1, &Options{Restarts: 1})
+			So(result.Centers.Array(), ShouldResemble, []float64{11.5 / 3, 11.5 / 3})
+		})
+	})
+
+	Convey("Given a sparse coo matrix of separated groups", t, func() {
+		m := sparseBlobs(4, 50, 10)
+
+		Convey("KMeans clusters it without densifying", func() {
+			result := KMeans(m, 4, &Options{Rng: rand.New(rand.NewSource(2))})
+			So(sameGroups(result.Labels, 50), ShouldBeTrue)
+			So(m.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+		})
+
+		Convey("Mini-batch KMeans finds the same clusters", func() {
+			exact := KMeans(m, 4, &Options{Rng: rand.New(rand.NewSource(3))})
+			result := KMeans(m, 4, &Options{BatchSize: 20, Restarts: 3, Rng: rand.New(rand.NewSource(3))})
+			So(sameGroups(result.Labels, 50), ShouldBeTrue)
+			So(result.Inertia, ShouldBeBetweenOrEqual, exact.Inertia, 1.01*exact.Inertia)
+			So(result.Iterations, ShouldBeGreaterThan, 0)
+		})
+	})
+
+	Convey("KMeans is reproducible with a seeded source", t, func() {
+		m := matrix.Rand(30, 3).M()
+		r1 := KMeans(m, 4, &Options{Restarts: 2, Rng: rand.New(rand.NewSource(4))})
+		r2 := KMeans(m, 4, &Options{Restarts: 2, Rng: rand.New(rand.NewSource(4))})
+		So(r1.Labels, ShouldResemble, r2.Labels)
+		So(r1.Inertia, ShouldEqual, r2.Inertia)
+	})
+
+	Convey("KMeans panics on invalid arguments", t, func() {
+		So(func() { KMeans(blobs(), 0, nil) }, ShouldPanic)
+		So(func() { KMeans(blobs(), 13, nil) }, ShouldPanic)
+		So(func() { KMeans(blobs(), 2, &Options{BatchSize: -1}) }, ShouldPanic)
+	})
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// Partition n points into k clusters given their n x n distance matrix,
+// minimizing the sum of distances from each point to the medoid of its
+// cluster. This is the PAM algorithm: a greedy BUILD phase picks the initial
+// medoids, then the SWAP phase repeatedly makes the medoid/non-medoid swap
+// which lowers the total distance most. Any dissimilarity works, not only a
+// metric, and the result is deterministic. opts.MaxIter limits the number of
+// swaps; the other options are ignored.
+func KMedoids(dist matrix.Matrix, k int, opts *Options) *Result {
+	n := dist.Rows()
+	if dist.Cols() != n {
+		panic(fmt.Sprintf("KMedoids needs a square distance matrix, but got shape %v", dist.Shape()))
+	} else if k < 1 || k > n {
+		panic(fmt.Sprintf("KMedoids can't find %d clusters among %d points", k, n))
+	}
+	o := withDefaults(opts)
+	d := make([][]float64, n)
+	for i := range d {
+		d[i] = make([]float64, n)
+		for j := range d[i] {
+			d[i][j] = dist.Item(j, i)
+		}
+	}
+
+	// BUILD: start from the most central point, then add the points which
+	// most reduce the total distance
+	nearest := make([]float64, n)
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+	isMedoid := make([]bool, n)
+	medoids := make([]int, 0, k)
+	for len(medoids) < k {
+		best, bestCost := -1, math.Inf(1)
+		for h := 0; h < n; h++ {
+			if isMedoid[h] {
+				continue
+			}
+			var cost float64
+			for j := 0; j < n; j++ {
+				cost += math.Min(nearest[j], d[h][j])
+			}
+			if cost < bestCost {
+				best, bestCost = h, cost
+			}
+		}
+		medoids = append(medoids, best)
+		isMedoid[best] = true
+		for j := range nearest {
+			nearest[j] = math.Min(nearest[j], d[best][j])
+		}
+	}
+
+	// SWAP: replace a medoid by a non-medoid while it lowers the total,
+	// ignoring improvements too small to be more than rounding error
+	result := &Result{Converged: true}
+	labels, first, second := assignMedoids(d, medoids)
+	for {
+		var total float64
+		for _, value := range first {
+			total += value
+		}
+		best, bestH, bestDelta := -1, -1, -1e-12*total
+		for m := range medoids {
+			for h := 0; h < n; h++ {
+				if isMedoid[h] {
+					continue
+				}
+				var delta float64
+				for j := 0; j < n; j++ {
+					if labels[j] == m {
+						delta += math.Min(d[h][j], second[j]) - first[j]
+					} else if d[h][j] < first[j] {
+						delta += d[h][j] - first[j]
+					}
+				}
+				if delta < bestDelta {
+					best, bestH, bestDelta = m, h, delta
+				}
+			}
+		}
+		if best < 0 {
+			break
+		} else if result.Iterations == o.MaxIter {
+			result.Converged = false
+			break
+		}
+		result.Iterations++
+		isMedoid[medoids[best]] = false
+		isMedoid[bestH] = true
+		medoids[best] = bestH
+		labels, first, second = assignMedoids(d, medoids)
+	}
+
+	result.Labels = labels
+	result.Medoids = medoids
+	for _, value := range first {
+		result.Inertia += value
+	}
+	return result
+}
+
+// Cluster the rows of m by KMedoids, using the distance matrix m.Dist(t).
+// The Centers of the result are the medoid rows of m.
+func KMedoidsDist(m matrix.Matrix, t matrix.DistType, k int, opts *Options) *Result {
+	result := KMedoids(m.Dist(t), k, opts)
+	result.Centers = matrix.Dense(k, m.Cols()).M()
+	for c, row := range result.Medoids {
+		center := make([]float64, m.Cols())
+		for col := range center {
+			center[col] = m.Item(row, col)
+		}
+		result.Centers.RowSet(c, center)
+	}
+	return result
+}
+
+// Assign each point to its nearest medoid, returning the label of each point
+// and its distances to the nearest and second-nearest medoids
+func assignMedoids(d [][]float64, medoids []int) (labels []int, first, second []float64) {
+	n := len(d)
+	labels = make([]int, n)
+	first = make([]float64, n)
+	second = make([]float64, n)
+	for j := 0; j < n; j++ {
+		first[j], second[j] = math.Inf(1), math.Inf(1)
+		for m, medoid := range medoids {
+			if dist := d[medoid][j]; dist < first[j] {
+				labels[j], first[j], second[j] = m, dist, first[j]
+			} else if dist < second[j] {
+				second[j] = dist
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"testing"
+)
+
+func TestKMedoids(t *testing.T) {
+	Convey("Given points in two groups on a line", t, func() {
+		m := matrix.M(6, 1, 0, 1, 2, 10, 11, 13)
+
+		Convey("KMedoids picks the central point of each group", func() {
+			result := KMedoids(m.Dist(matrix.EuclideanDist), 2, nil)
+			So(result.Medoids, ShouldContain, 1)
+			So(result.Medoids, ShouldContain, 4)
+			So(sameGroups(result.Labels, 3), ShouldBeTrue)
+			So(result.Inertia, ShouldAlmostEqual, 5, Eps)
+			So(result.Converged, ShouldBeTrue)
+			So(result.Centers, ShouldBeNil)
+		})
+
+		Convey("KMedoidsDist gives the medoid rows as centers", func() {
+			result := KMedoidsDist(m, matrix.EuclideanDist, 2, nil)
+			So(result.Centers.Shape(), ShouldResemble, []int{2, 1})
+			for c, medoid := range result.Medoids {
+				So(result.Centers.Item(c, 0), ShouldEqual, m.Item(medoid, 0))
+			}
+		})
+
+		Convey("One cluster per point has zero inertia", func() {
+			result := KMedoids(m.Dist(matrix.EuclideanDist), 6, nil)
+			So(result.Inertia, ShouldEqual, 0)
+		})
+	})
+
+	Convey("KMedoids accepts a dissimilarity which isn't a metric", t, func() {
+		// Squared distances between points 0, 1, 2, 10 and 12
+		d := matrix.M(5, 5,
+			0, 1, 4, 100, 144,
+			1, 0, 1, 81, 121,
+			4, 1, 0, 64, 100,
+			100, 81, 64, 0, 4,
+			144, 121, 100, 4, 0)
+		result := KMedoids(d, 2, nil)
+		So(result.Labels[0], ShouldEqual, result.Labels[2])
+		So(result.Labels[3], ShouldEqual, result.Labels[4])
+		So(result.Labels[0], ShouldNotEqual, result.Labels[3])
+		So(result.Medoids[result.Labels[0]], ShouldEqual, 1)
+		So(result.Inertia, ShouldAlmostEqual, 6, Eps)
+	})
+
+	Convey("KMedoids works on sparse inputs", t, func() {
+		m := sparseBlobs(3, 5, 4)
+		result := KMedoidsDist(m, matrix.EuclideanDist, 3, nil)
+		So(sameGroups(result.Labels, 5), ShouldBeTrue)
+		So(result.Centers.Shape(), ShouldResemble, []int{3, 12})
+	})
+
+	Convey("KMedoids panics on invalid arguments", t, func() {
+		So(func() { KMedoids(matrix.Rand(3, 4).M(), 2, nil) }, ShouldPanic)
+		So(func() { KMedoids(matrix.Rand(3, 3).M(), 0, nil) }, ShouldPanic)
+		So(func() { KMedoids(matrix.Rand(3, 3).M(), 4, nil) }, ShouldPanic)
+	})
+}
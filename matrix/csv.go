@@ -0,0 +1,274 @@
+package matrix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// How to decide whether the first record of a CSV file is a header
+type CSVHeader int
+
+const (
+	// Treat the first record as a header if any field isn't a number
+	CSVHeaderAuto CSVHeader = iota
+
+	// The first record is always a header
+	CSVHeaderPresent
+
+	// There is no header; all records are data
+	CSVHeaderAbsent
+)
+
+// What to do with empty cells when reading a CSV file
+type CSVMissing int
+
+const (
+	// Read empty cells as math.NaN()
+	CSVMissingNaN CSVMissing = iota
+
+	// Read empty cells as CSVOptions.FillValue
+	CSVMissingFill
+
+	// Return an error when an empty cell is found
+	CSVMissingError
+)
+
+// Options which control reading and writing CSV files. The zero value reads
+// and writes comma-separated files, detects headers automatically, and reads
+// empty cells as NaN.
+type CSVOptions struct {
+
+	// The field delimiter. Defaults to ','; use '\t' for TSV files.
+	Delimiter rune
+
+	// Whether the first record is a header
+	Header CSVHeader
+
+	// Lines beginning with this prefix are ignored. No lines are ignored if
+	// the prefix is empty.
+	Comment string
+
+	// The indices of columns in the file which should not be read
+	SkipColumns []int
+
+	// How empty cells are handled
+	Missing CSVMissing
+
+	// The value to use for empty cells when Missing is CSVMissingFill
+	FillValue float64
+
+	// When writing, the number of digits after the decimal point. If zero,
+	// values are written with the fewest digits which represent them exactly.
+	Precision int
+
+	// When writing, the column names to write as a header. No header is
+	// written if this is empty.
+	ColumnNames []string
+}
+
+// The field delimiter to use
+func (opts CSVOptions) delimiter() rune {
+	if opts.Delimiter == 0 {
+		return ','
+	}
+	return opts.Delimiter
+}
+
+// Format a value for writing
+func (opts CSVOptions) format(v float64) string {
+	if opts.Precision > 0 {
+		return strconv.FormatFloat(v, 'f', opts.Precision, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// A reader which drops lines starting with a comment prefix
+type csvCommentFilter struct {
+	r      *bufio.Reader
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (f *csvCommentFilter) Read(p []byte) (int, error) {
+	for f.buf.Len() == 0 {
+		line, err := f.r.ReadString('\n')
+		if len(line) > 0 && !strings.HasPrefix(strings.TrimLeft(line, " \t"), f.prefix) {
+			f.buf.WriteString(line)
+		}
+		if err != nil {
+			if f.buf.Len() == 0 {
+				return 0, err
+			}
+			break
+		}
+	}
+	return f.buf.Read(p)
+}
+
+// Read a CSV file, invoking f on each data row in order. The values slice
+// is reused between calls, so f should copy it if needed. If f returns an
+// error, reading stops and the error is returned. This is useful for files
+// too large to hold in memory. Returns the column names from the header, or
+// nil if the file has no header.
+func ReadCSVFunc(r io.Reader, opts CSVOptions, f func(row int, values []float64) error) ([]string, error) {
+	if opts.Comment != "" {
+		r = &csvCommentFilter{r: bufio.NewReader(r), prefix: opts.Comment}
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = opts.delimiter()
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	skip := make(map[int]bool)
+	for _, col := range opts.SkipColumns {
+		skip[col] = true
+	}
+	var (
+		names  []string
+		values []float64
+		width  = -1
+		row    = 0
+	)
+	for line := 0; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return names, err
+		}
+		if width < 0 {
+			width = len(record)
+		} else if len(record) != width {
+			return names, fmt.Errorf("CSV record %d has %d fields, but expected %d", line+1, len(record), width)
+		}
+
+		if line == 0 && opts.Header != CSVHeaderAbsent {
+			isHeader := opts.Header == CSVHeaderPresent
+			for col, field := range record {
+				if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); !skip[col] && field != "" && err != nil {
+					isHeader = true
+				}
+			}
+			if isHeader {
+				for col, field := range record {
+					if !skip[col] {
+						names = append(names, strings.TrimSpace(field))
+					}
+				}
+				continue
+			}
+		}
+
+		values = values[:0]
+		for col, field := range record {
+			if skip[col] {
+				continue
+			}
+			field = strings.TrimSpace(field)
+			if field == "" {
+				switch opts.Missing {
+				case CSVMissingNaN:
+					values = append(values, math.NaN())
+				case CSVMissingFill:
+					values = append(values, opts.FillValue)
+				default:
+					return names, fmt.Errorf("CSV record %d has an empty cell in column %d", line+1, col+1)
+				}
+				continue
+			}
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return names, fmt.Errorf("CSV record %d has non-numeric value %q in column %d", line+1, field, col+1)
+			}
+			values = append(values, v)
+		}
+		if err := f(row, values); err != nil {
+			return names, err
+		}
+		row++
+	}
+	return names, nil
+}
+
+// Read a CSV file into a dense matrix, with one matrix row per data record.
+// Returns the matrix and the column names from the header, or nil names if
+// the file has no header.
+func ReadCSV(r io.Reader, opts CSVOptions) (Matrix, []string, error) {
+	var (
+		array []float64
+		cols  int
+	)
+	names, err := ReadCSVFunc(r, opts, func(row int, values []float64) error {
+		cols = len(values)
+		array = append(array, values...)
+		return nil
+	})
+	if err != nil {
+		return nil, names, err
+	}
+	if cols == 0 {
+		cols = len(names)
+	}
+	rows := 0
+	if cols > 0 {
+		rows = len(array) / cols
+	}
+	return &denseF64Array{
+		shape: []int{rows, cols},
+		array: array,
+	}, names, nil
+}
+
+// Write rows to a CSV file, calling next to get each row until it returns
+// false. This is useful when the full matrix would not fit in memory.
+func WriteCSVFunc(w io.Writer, opts CSVOptions, next func() ([]float64, bool)) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.delimiter()
+	if len(opts.ColumnNames) > 0 {
+		if err := cw.Write(opts.ColumnNames); err != nil {
+			return err
+		}
+	}
+	var record []string
+	for {
+		values, ok := next()
+		if !ok {
+			break
+		}
+		record = record[:0]
+		for _, v := range values {
+			record = append(record, opts.format(v))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Write a matrix to a CSV file, one record per row
+func WriteCSV(w io.Writer, m Matrix, opts CSVOptions) error {
+	if len(opts.ColumnNames) > 0 && len(opts.ColumnNames) != m.Cols() {
+		return fmt.Errorf("Can't write %d column names for a %d-column matrix", len(opts.ColumnNames), m.Cols())
+	}
+	row := 0
+	values := make([]float64, m.Cols())
+	return WriteCSVFunc(w, opts, func() ([]float64, bool) {
+		if row >= m.Rows() {
+			return nil, false
+		}
+		for col := range values {
+			values[col] = m.Item(row, col)
+		}
+		row++
+		return values, true
+	})
+}
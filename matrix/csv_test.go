@@ -0,0 +1,153 @@
+package matrix
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	Convey("ReadCSV detects a header", t, func() {
+		m, names, err := ReadCSV(strings.NewReader("a,b,c\n1,2,3\n4,5,6\n"), CSVOptions{})
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"a", "b", "c"})
+		So(m.Shape(), ShouldResemble, []int{2, 3})
+		So(m.Array(), ShouldResemble, []float64{1, 2, 3, 4, 5, 6})
+	})
+
+	Convey("ReadCSV reads a file without a header", t, func() {
+		m, names, err := ReadCSV(strings.NewReader("1,2\n3,4\n"), CSVOptions{})
+		So(err, ShouldBeNil)
+		So(names, ShouldBeNil)
+		So(m.Array(), ShouldResemble, []float64{1, 2, 3, 4})
+	})
+
+	Convey("ReadCSV honors explicit header settings", t, func() {
+		m, names, err := ReadCSV(strings.NewReader("1,2\n3,4\n"), CSVOptions{Header: CSVHeaderPresent})
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"1", "2"})
+		So(m.Array(), ShouldResemble, []float64{3, 4})
+
+		_, _, err = ReadCSV(strings.NewReader("a,b\n3,4\n"), CSVOptions{Header: CSVHeaderAbsent})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("ReadCSV reads TSV files with comments and skipped columns", t, func() {
+		input := "# generated data\nid\tx\ty\n# another comment\nfoo\t1.5\t2\nbar\t-3\t4e1\n"
+		m, names, err := ReadCSV(strings.NewReader(input), CSVOptions{
+			Delimiter:   '\t',
+			Comment:     "#",
+			SkipColumns: []int{0},
+		})
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"x", "y"})
+		So(m.Array(), ShouldResemble, []float64{1.5, 2, -3, 40})
+	})
+
+	Convey("ReadCSV handles empty cells", t, func() {
+		input := "x,y\n1,\n,4\n"
+
+		m, _, err := ReadCSV(strings.NewReader(input), CSVOptions{})
+		So(err, ShouldBeNil)
+		So(m.Item(0, 0), ShouldEqual, 1)
+		So(math.IsNaN(m.Item(0, 1)), ShouldBeTrue)
+		So(math.IsNaN(m.Item(1, 0)), ShouldBeTrue)
+		So(m.Item(1, 1), ShouldEqual, 4)
+
+		m, _, err = ReadCSV(strings.NewReader(input), CSVOptions{
+			Missing:   CSVMissingFill,
+			FillValue: -1,
+		})
+		So(err, ShouldBeNil)
+		So(m.Array(), ShouldResemble, []float64{1, -1, -1, 4})
+
+		_, _, err = ReadCSV(strings.NewReader(input), CSVOptions{Missing: CSVMissingError})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("ReadCSV rejects ragged records", t, func() {
+		_, _, err := ReadCSV(strings.NewReader("1,2\n3\n"), CSVOptions{})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestReadCSVFunc(t *testing.T) {
+	Convey("ReadCSVFunc visits each row in order", t, func() {
+		var rows [][]float64
+		names, err := ReadCSVFunc(strings.NewReader("a,b\n1,2\n3,4\n5,6\n"), CSVOptions{},
+			func(row int, values []float64) error {
+				So(row, ShouldEqual, len(rows))
+				rows = append(rows, append([]float64{}, values...))
+				return nil
+			})
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"a", "b"})
+		So(rows, ShouldResemble, [][]float64{{1, 2}, {3, 4}, {5, 6}})
+	})
+
+	Convey("ReadCSVFunc stops when the callback fails", t, func() {
+		stop := errors.New("stop")
+		count := 0
+		_, err := ReadCSVFunc(strings.NewReader("1\n2\n3\n"), CSVOptions{},
+			func(row int, values []float64) error {
+				count++
+				if row == 1 {
+					return stop
+				}
+				return nil
+			})
+		So(err, ShouldEqual, stop)
+		So(count, ShouldEqual, 2)
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	Convey("Given a matrix", t, func() {
+		m := M(2, 3,
+			1, 2.5, -3,
+			0.125, 5, 6)
+
+		Convey("WriteCSV writes values exactly by default", func() {
+			var buf bytes.Buffer
+			So(WriteCSV(&buf, m, CSVOptions{}), ShouldBeNil)
+			So(buf.String(), ShouldEqual, "1,2.5,-3\n0.125,5,6\n")
+		})
+
+		Convey("WriteCSV honors precision, delimiter and column names", func() {
+			var buf bytes.Buffer
+			So(WriteCSV(&buf, m, CSVOptions{
+				Delimiter:   '\t',
+				Precision:   2,
+				ColumnNames: []string{"a", "b", "c"},
+			}), ShouldBeNil)
+			So(buf.String(), ShouldEqual, "a\tb\tc\n1.00\t2.50\t-3.00\n0.12\t5.00\t6.00\n")
+		})
+
+		Convey("WriteCSV rejects the wrong number of column names", func() {
+			var buf bytes.Buffer
+			So(WriteCSV(&buf, m, CSVOptions{ColumnNames: []string{"a"}}), ShouldNotBeNil)
+		})
+
+		Convey("The matrix survives a round trip", func() {
+			var buf bytes.Buffer
+			So(WriteCSV(&buf, m.T(), CSVOptions{ColumnNames: []string{"x", "y"}}), ShouldBeNil)
+			m2, names, err := ReadCSV(&buf, CSVOptions{})
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"x", "y"})
+			So(m2.Equal(m.T()), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a sparse matrix", t, func() {
+		m := Diag(1, 2)
+
+		Convey("WriteCSV writes all values", func() {
+			var buf bytes.Buffer
+			So(WriteCSV(&buf, m, CSVOptions{}), ShouldBeNil)
+			So(buf.String(), ShouldEqual, "1,0\n0,2\n")
+		})
+	})
+}
@@ -0,0 +1,179 @@
+package matrix
+
+import (
+	"fmt"
+)
+
+// Apply f to each lane of array along axis, where a lane holds the values
+// whose positions differ only at that axis. f reads a lane from in and writes
+// outLen values to out. Returns a dense array whose length along axis is
+// outLen.
+func mapLanes(array NDArray, axis, outLen int, f func(in, out []float64)) NDArray {
+	shape := array.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	outer, inner := 1, 1
+	for _, sz := range shape[:axis] {
+		outer *= sz
+	}
+	for _, sz := range shape[axis+1:] {
+		inner *= sz
+	}
+	n := shape[axis]
+	outShape := make([]int, len(shape))
+	copy(outShape, shape)
+	outShape[axis] = outLen
+	result := Dense(outShape...)
+
+	src := array.Dense()
+	in := make([]float64, n)
+	out := make([]float64, outLen)
+	for o := 0; o < outer; o++ {
+		for k := 0; k < inner; k++ {
+			for i := range in {
+				in[i] = src.FlatItem((o*n+i)*inner + k)
+			}
+			f(in, out)
+			for i, v := range out {
+				result.FlatItemSet(v, (o*outLen+i)*inner+k)
+			}
+		}
+	}
+	return result
+}
+
+// Get the cumulative sum of the elements along an axis, as a dense array
+func CumSum(array NDArray, axis int) NDArray {
+	return mapLanes(array, axis, array.Shape()[axis], func(in, out []float64) {
+		var sum float64
+		for i, v := range in {
+			sum += v
+			out[i] = sum
+		}
+	})
+}
+
+// Get the cumulative product of the elements along an axis, as a dense array
+func CumProd(array NDArray, axis int) NDArray {
+	return mapLanes(array, axis, array.Shape()[axis], func(in, out []float64) {
+		prod := 1.0
+		for i, v := range in {
+			prod *= v
+			out[i] = prod
+		}
+	})
+}
+
+// Get the n-th discrete difference along an axis: the first difference is
+// out[i] = in[i+1] - in[i], and higher differences are taken recursively.
+// The result is n elements shorter along the axis. Dense arrays produce a
+// dense result, and sparse matrices produce a sparse coo result.
+func Diff(array NDArray, n, axis int) NDArray {
+	shape := array.Shape()
+	if n < 0 {
+		panic(fmt.Sprintf("Diff order %d must be nonnegative", n))
+	}
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	if n > shape[axis] {
+		n = shape[axis]
+	}
+
+	if array.Sparsity() == DenseArray {
+		return mapLanes(array, axis, shape[axis]-n, func(in, out []float64) {
+			work := append([]float64{}, in...)
+			for d := 0; d < n; d++ {
+				for i := 0; i < len(work)-1-d; i++ {
+					work[i] = work[i+1] - work[i]
+				}
+			}
+			copy(out, work)
+		})
+	}
+
+	// Each nonzero contributes to at most two differences
+	result := array.M().SparseCoo()
+	for d := 0; d < n; d++ {
+		sh := result.Shape()
+		out := []int{sh[0], sh[1]}
+		out[axis]--
+		next := SparseCoo(out[0], out[1])
+		result.VisitNonzero(func(pos []int, value float64) bool {
+			if pos[axis] > 0 {
+				p := []int{pos[0], pos[1]}
+				p[axis]--
+				next.ItemSet(next.Item(p[0], p[1])+value, p[0], p[1])
+			}
+			if pos[axis] < sh[axis]-1 {
+				next.ItemSet(next.Item(pos[0], pos[1])-value, pos[0], pos[1])
+			}
+			return true
+		})
+		result = next
+	}
+	return result
+}
+
+// Get the gradient along an axis of an array sampled with uniform spacing.
+// Interior points use second-order central differences; the edges use
+// one-sided differences of order edgeOrder, which must be 1 or 2. The result
+// is a dense array with the same shape as the input.
+func Gradient(array NDArray, axis int, spacing float64, edgeOrder int) NDArray {
+	shape := array.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	coords := make([]float64, shape[axis])
+	for i := range coords {
+		coords[i] = float64(i) * spacing
+	}
+	return GradientCoords(array, axis, coords, edgeOrder)
+}
+
+// Get the gradient along an axis of an array sampled at the given increasing
+// coordinates, which need not be evenly spaced. See Gradient.
+func GradientCoords(array NDArray, axis int, coords []float64, edgeOrder int) NDArray {
+	shape := array.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	n := shape[axis]
+	if len(coords) != n {
+		panic(fmt.Sprintf("Gradient got %d coordinates for an axis of length %d", len(coords), n))
+	}
+	if edgeOrder != 1 && edgeOrder != 2 {
+		panic(fmt.Sprintf("Gradient edge order %d should be 1 or 2", edgeOrder))
+	}
+	if n < edgeOrder+1 {
+		panic(fmt.Sprintf("Gradient needs at least %d points for edge order %d, but got %d", edgeOrder+1, edgeOrder, n))
+	}
+	x := coords
+	return mapLanes(array, axis, n, func(f, g []float64) {
+		for i := 1; i < n-1; i++ {
+			dx1, dx2 := x[i]-x[i-1], x[i+1]-x[i]
+			a := -dx2 / (dx1 * (dx1 + dx2))
+			b := (dx2 - dx1) / (dx1 * dx2)
+			c := dx1 / (dx2 * (dx1 + dx2))
+			g[i] = a*f[i-1] + b*f[i] + c*f[i+1]
+		}
+		if edgeOrder == 1 {
+			g[0] = (f[1] - f[0]) / (x[1] - x[0])
+			g[n-1] = (f[n-1] - f[n-2]) / (x[n-1] - x[n-2])
+			return
+		}
+
+		dx1, dx2 := x[1]-x[0], x[2]-x[1]
+		a := -(2*dx1 + dx2) / (dx1 * (dx1 + dx2))
+		b := (dx1 + dx2) / (dx1 * dx2)
+		c := -dx1 / (dx2 * (dx1 + dx2))
+		g[0] = a*f[0] + b*f[1] + c*f[2]
+
+		dx1, dx2 = x[n-2]-x[n-3], x[n-1]-x[n-2]
+		a = dx2 / (dx1 * (dx1 + dx2))
+		b = -(dx2 + dx1) / (dx1 * dx2)
+		c = (2*dx2 + dx1) / (dx2 * (dx1 + dx2))
+		g[n-1] = a*f[n-3] + b*f[n-2] + c*f[n-1]
+	})
+}
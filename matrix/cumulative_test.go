@@ -0,0 +1,143 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestCumSumProd(t *testing.T) {
+	Convey("Given a matrix", t, func() {
+		m := M(2, 3,
+			1, 2, 3,
+			4, 5, 6)
+
+		Convey("CumSum works along both axes", func() {
+			So(CumSum(m, 0).Equal(M(2, 3, 1, 2, 3, 5, 7, 9)), ShouldBeTrue)
+			So(CumSum(m, 1).Equal(M(2, 3, 1, 3, 6, 4, 9, 15)), ShouldBeTrue)
+		})
+
+		Convey("CumProd works along both axes", func() {
+			So(CumProd(m, 0).Equal(M(2, 3, 1, 2, 3, 4, 10, 18)), ShouldBeTrue)
+			So(CumProd(m, 1).Equal(M(2, 3, 1, 2, 6, 4, 20, 120)), ShouldBeTrue)
+		})
+
+		Convey("Transposed and sparse matrices produce dense results", func() {
+			So(CumSum(m.T(), 1).Equal(M(3, 2, 1, 5, 2, 7, 3, 9)), ShouldBeTrue)
+			s := CumSum(Diag(1, 2, 3), 0)
+			So(s.Sparsity(), ShouldEqual, DenseArray)
+			So(s.Equal(M(3, 3, 1, 0, 0, 1, 2, 0, 1, 2, 3)), ShouldBeTrue)
+		})
+
+		Convey("Invalid axes panic", func() {
+			So(func() { CumSum(m, 2) }, ShouldPanic)
+			So(func() { CumProd(m, -1) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a 3D array", t, func() {
+		a := A([]int{2, 2, 2}, 1, 2, 3, 4, 5, 6, 7, 8)
+		So(CumSum(a, 1).Equal(A([]int{2, 2, 2}, 1, 2, 4, 6, 5, 6, 12, 14)), ShouldBeTrue)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	Convey("Given a 1D array", t, func() {
+		a := A1(1, 2, 4, 7, 0)
+
+		Convey("Diff takes repeated differences", func() {
+			So(Diff(a, 1, 0).Equal(A1(1, 2, 3, -7)), ShouldBeTrue)
+			So(Diff(a, 2, 0).Equal(A1(1, 1, -10)), ShouldBeTrue)
+			So(Diff(a, 0, 0).Equal(a), ShouldBeTrue)
+			So(Diff(a, 5, 0).Size(), ShouldEqual, 0)
+		})
+
+		Convey("Negative orders panic", func() {
+			So(func() { Diff(a, -1, 0) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a dense matrix", t, func() {
+		m := M(3, 3,
+			1, 2, 4,
+			3, 3, 3,
+			9, 0, 1)
+		So(Diff(m, 1, 0).Equal(M(2, 3, 2, 1, -1, 6, -3, -2)), ShouldBeTrue)
+		So(Diff(m, 1, 1).Equal(M(3, 2, 1, 2, 0, 0, -9, 1)), ShouldBeTrue)
+		So(Diff(m.T(), 1, 1).Equal(Diff(m, 1, 0).M().T()), ShouldBeTrue)
+	})
+
+	Convey("Given a sparse diagonal matrix", t, func() {
+		d := Diag(1, 2, 3)
+
+		Convey("Diff produces a sparse coo result", func() {
+			r := Diff(d, 1, 0)
+			So(r.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(r.Equal(M(2, 3,
+				-1, 2, 0,
+				0, -2, 3)), ShouldBeTrue)
+			So(Diff(d, 1, 1).Equal(M(3, 2,
+				-1, 0,
+				2, -2,
+				0, 3)), ShouldBeTrue)
+		})
+
+		Convey("Higher differences match the dense result", func() {
+			So(Diff(d, 2, 0).Equal(Diff(d.Dense(), 2, 0)), ShouldBeTrue)
+			So(Diff(d.T(), 2, 1).Equal(Diff(d.Dense(), 2, 1)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a sparse coo matrix", t, func() {
+		m := SparseCoo(2, 4, 0, 5, 5, 0, 1, 0, 0, 0)
+		r := Diff(m, 1, 1)
+		So(r.Sparsity(), ShouldEqual, SparseCooMatrix)
+		So(r.CountNonzero(), ShouldEqual, 3)
+		So(r.Equal(M(2, 3, 5, 0, -5, -1, 0, 0)), ShouldBeTrue)
+	})
+}
+
+func TestGradient(t *testing.T) {
+	close := func(v1, v2 float64) bool { return math.Abs(v1-v2) < Eps }
+
+	Convey("Given evenly spaced samples of a quadratic", t, func() {
+		a := A1(1, 4, 9, 16, 25)
+
+		Convey("First-order edges use one-sided differences", func() {
+			So(Gradient(a, 0, 1, 1).AllF2(close, A1(3, 4, 6, 8, 9)), ShouldBeTrue)
+		})
+
+		Convey("Second-order edges are exact for quadratics", func() {
+			So(Gradient(a, 0, 1, 2).AllF2(close, A1(2, 4, 6, 8, 10)), ShouldBeTrue)
+		})
+
+		Convey("Spacing scales the gradient", func() {
+			So(Gradient(a, 0, 2, 2).AllF2(close, A1(1, 2, 3, 4, 5)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given unevenly spaced samples of a quadratic", t, func() {
+		x := []float64{0, 1, 3, 4, 7}
+		f := Dense(5)
+		for i, v := range x {
+			f.FlatItemSet(v*v, i)
+		}
+		So(GradientCoords(f, 0, x, 2).AllF2(close, A1(0, 2, 6, 8, 14)), ShouldBeTrue)
+	})
+
+	Convey("Given a matrix", t, func() {
+		m := M(3, 2,
+			1, 2,
+			2, 4,
+			4, 8)
+		So(Gradient(m, 0, 1, 1).AllF2(close, M(3, 2, 1, 2, 1.5, 3, 2, 4)), ShouldBeTrue)
+		So(Gradient(m, 1, 1, 1).AllF2(close, M(3, 2, 1, 1, 2, 2, 4, 4)), ShouldBeTrue)
+	})
+
+	Convey("Invalid parameters panic", t, func() {
+		So(func() { Gradient(A1(1, 2), 0, 1, 2) }, ShouldPanic)
+		So(func() { Gradient(A1(1, 2, 3), 0, 1, 3) }, ShouldPanic)
+		So(func() { Gradient(A1(1, 2, 3), 1, 1, 1) }, ShouldPanic)
+		So(func() { GradientCoords(A1(1, 2, 3), 0, []float64{0, 1}, 1) }, ShouldPanic)
+	})
+}
@@ -0,0 +1,178 @@
+package decomp
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// Principal component analysis, which projects the rows of a matrix onto the
+// directions of greatest variance. Dense inputs are factored exactly; sparse
+// coo inputs are factored by RandomizedSVD with the mean subtracted
+// implicitly, so that they are never densified.
+type PCA struct {
+	// The number of components to keep, or 0 to keep min(rows, cols)
+	NComponents int
+
+	// Whether to scale the transformed components to unit variance
+	Whiten bool
+
+	// The oversamples and power iterations used for sparse inputs
+	Oversamples, PowerIters int
+
+	// The principal axes, one per row, in order of decreasing variance
+	Components matrix.Matrix
+
+	// The mean of each feature in the fitted data
+	Mean matrix.NDArray
+
+	// The variance of the fitted data along each component
+	ExplainedVariance matrix.NDArray
+
+	// The singular values of the centered data for each component
+	SingularValues matrix.NDArray
+
+	// The total variance of the fitted data across all features
+	totalVariance float64
+}
+
+// Create a PCA which keeps nComponents components
+func NewPCA(nComponents int, whiten bool) *PCA {
+	return &PCA{
+		NComponents: nComponents,
+		Whiten:      whiten,
+		Oversamples: 10,
+		PowerIters:  4,
+	}
+}
+
+// Find the principal components of the rows of m, and return the PCA
+func (pca *PCA) Fit(m matrix.Matrix) *PCA {
+	rows, cols := m.Rows(), m.Cols()
+	size := rows
+	if cols < size {
+		size = cols
+	}
+	k := pca.NComponents
+	if k == 0 {
+		k = size
+	}
+	if rows < 2 {
+		panic(fmt.Sprintf("PCA can't fit %d samples", rows))
+	} else if k < 0 || k > size {
+		panic(fmt.Sprintf("PCA can't find %d components of a %dx%d matrix", k, rows, cols))
+	}
+
+	// Find the mean and total variance of each feature
+	mean := make([]float64, cols)
+	squares := make([]float64, cols)
+	m.VisitNonzero(func(pos []int, value float64) bool {
+		mean[pos[1]] += value
+		squares[pos[1]] += value * value
+		return true
+	})
+	pca.totalVariance = 0
+	for j := range mean {
+		mean[j] /= float64(rows)
+		pca.totalVariance += (squares[j] - float64(rows)*mean[j]*mean[j]) / float64(rows-1)
+	}
+
+	var s matrix.NDArray
+	var vt matrix.Matrix
+	if m.Sparsity() == matrix.SparseCooMatrix {
+		_, s, vt = randomizedSVD(operator{m: m, mean: mean}, k, pca.Oversamples, pca.PowerIters)
+	} else {
+		centered := append([]float64(nil), m.Array()...)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				centered[i*cols+j] -= mean[j]
+			}
+		}
+		_, s, vt = SVD(matrix.M(rows, cols, centered...))
+
+		// The exact singular values give a more accurate total variance
+		pca.totalVariance = 0
+		for _, value := range s.Array() {
+			pca.totalVariance += value * value / float64(rows-1)
+		}
+		s = matrix.A1(s.Array()[:k]...)
+		vt = fromRows(toRows(vt)[:k])
+	}
+
+	pca.Components = vt
+	pca.Mean = matrix.A1(mean...)
+	pca.SingularValues = s
+	pca.ExplainedVariance = s.Apply(func(v float64) float64 {
+		return v * v / float64(rows-1)
+	})
+	return pca
+}
+
+// Project the rows of m onto the principal components, giving a matrix with
+// one column per component
+func (pca *PCA) Transform(m matrix.Matrix) matrix.Matrix {
+	pca.checkFitted("Transform", m.Cols(), pca.Components.Cols())
+	rows, k := m.Rows(), pca.Components.Rows()
+	values := m.MProd(pca.Components.T()).Array()
+	shift := matrix.MProd(matrix.M(1, pca.Mean.Size(), pca.Mean.Array()...), pca.Components.T()).Array()
+	scale := pca.scales()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < k; j++ {
+			values[i*k+j] = (values[i*k+j] - shift[j]) / scale[j]
+		}
+	}
+	return matrix.M(rows, k, values...)
+}
+
+// Map projected rows back to the original feature space. This inverts
+// Transform up to the variance in the discarded components.
+func (pca *PCA) InverseTransform(m matrix.Matrix) matrix.Matrix {
+	pca.checkFitted("InverseTransform", m.Cols(), pca.Components.Rows())
+	rows, k := m.Rows(), pca.Components.Rows()
+	scaled := append([]float64(nil), m.Array()...)
+	scale := pca.scales()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < k; j++ {
+			scaled[i*k+j] *= scale[j]
+		}
+	}
+	cols := pca.Components.Cols()
+	values := matrix.MProd(matrix.M(rows, k, scaled...), pca.Components).Array()
+	mean := pca.Mean.Array()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			values[i*cols+j] += mean[j]
+		}
+	}
+	return matrix.M(rows, cols, values...)
+}
+
+// Get the fraction of the total variance explained by each component
+func (pca *PCA) ExplainedVarianceRatio() matrix.NDArray {
+	if pca.ExplainedVariance == nil {
+		panic("ExplainedVarianceRatio called before Fit")
+	}
+	return pca.ExplainedVariance.ItemDiv(pca.totalVariance)
+}
+
+// Panic unless the PCA has been fitted and the input has the right width
+func (pca *PCA) checkFitted(name string, got, want int) {
+	if pca.Components == nil {
+		panic(fmt.Sprintf("%s called before Fit", name))
+	} else if got != want {
+		panic(fmt.Sprintf("%s expected %d columns but got %d", name, want, got))
+	}
+}
+
+// Get the factor which divides each transformed component
+func (pca *PCA) scales() []float64 {
+	scale := make([]float64, pca.Components.Rows())
+	variance := pca.ExplainedVariance.Array()
+	for j := range scale {
+		scale[j] = 1
+		if pca.Whiten && variance[j] > 0 {
+			scale[j] = math.Sqrt(variance[j])
+		}
+	}
+	return scale
+}
@@ -0,0 +1,103 @@
+package decomp
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"testing"
+)
+
+func TestPCA(t *testing.T) {
+	Convey("Given points along a line", t, func() {
+		m := matrix.M(5, 2,
+			1, 2,
+			2, 4,
+			3, 6,
+			4, 8,
+			5, 10)
+		pca := NewPCA(0, false).Fit(m)
+
+		Convey("Fit finds the mean and the direction of the line", func() {
+			So(matrix.AllClose(pca.Mean, matrix.A1(3, 6), 0, Eps, false), ShouldBeTrue)
+			So(pca.Components.Shape(), ShouldResemble, []int{2, 2})
+			dir := pca.Components.Row(0)
+			So(dir[0]*dir[0], ShouldAlmostEqual, 0.2, Eps)
+			So(dir[1]/dir[0], ShouldAlmostEqual, 2, Eps)
+		})
+
+		Convey("The first component explains all of the variance", func() {
+			So(pca.ExplainedVariance.Array()[0], ShouldAlmostEqual, 12.5, Eps)
+			So(matrix.AllClose(pca.ExplainedVarianceRatio(), matrix.A1(1, 0), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Fit doesn't modify its input", func() {
+			So(m.Item(4, 1), ShouldEqual, 10)
+		})
+	})
+
+	Convey("Given dense data with several components", t, func() {
+		m := matrix.M(6, 3,
+			2, 0, 1,
+			1, 3, 0,
+			0, 1, 4,
+			1, 1, 1,
+			3, 2, 2,
+			0, 4, 1)
+
+		Convey("Transform and InverseTransform are inverses with all components", func() {
+			pca := NewPCA(3, false).Fit(m)
+			z := pca.Transform(m)
+			So(z.Shape(), ShouldResemble, []int{6, 3})
+			So(matrix.AllClose(matrix.MeanAxis(z, 0), matrix.Zeros(3), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(pca.InverseTransform(z), m, 0, Eps, false), ShouldBeTrue)
+			ratios := pca.ExplainedVarianceRatio()
+			So(ratios.Sum(), ShouldAlmostEqual, 1, Eps)
+			So(ratios.Array()[0], ShouldBeGreaterThan, ratios.Array()[1])
+		})
+
+		Convey("The transformed components have the explained variances", func() {
+			pca := NewPCA(2, false).Fit(m)
+			z := pca.Transform(m)
+			So(matrix.AllClose(matrix.VarAxis(z, 0, 1), pca.ExplainedVariance, 0, Eps, false), ShouldBeTrue)
+			So(pca.ExplainedVarianceRatio().Sum(), ShouldBeLessThan, 1)
+		})
+
+		Convey("Whitening gives unit variance and still inverts", func() {
+			pca := NewPCA(3, true).Fit(m)
+			z := pca.Transform(m)
+			So(matrix.AllClose(matrix.VarAxis(z, 0, 1), matrix.Ones(3), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(pca.InverseTransform(z), m, 0, Eps, false), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a sparse low-rank matrix", t, func() {
+		m := lowRank()
+		dense := matrix.M(12, 8, m.Array()...)
+		exact := NewPCA(2, false).Fit(dense)
+		approx := NewPCA(2, false).Fit(m)
+
+		Convey("Fit matches the dense fit", func() {
+			So(matrix.AllClose(approx.Mean, exact.Mean, 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(approx.ExplainedVariance, exact.ExplainedVariance, 0, 1e-8, false), ShouldBeTrue)
+			So(matrix.AllClose(approx.ExplainedVarianceRatio(), exact.ExplainedVarianceRatio(), 0, 1e-8, false), ShouldBeTrue)
+			So(approx.ExplainedVarianceRatio().Sum(), ShouldAlmostEqual, 1, 1e-8)
+			So(matrix.AllClose(approx.Components, exact.Components, 0, 1e-8, false), ShouldBeTrue)
+		})
+
+		Convey("Transform matches the dense transform and keeps the input sparse", func() {
+			z := approx.Transform(m)
+			So(matrix.AllClose(z, exact.Transform(dense), 0, 1e-8, false), ShouldBeTrue)
+			So(matrix.AllClose(approx.InverseTransform(z), dense, 0, 1e-8, false), ShouldBeTrue)
+			So(m.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+		})
+	})
+
+	Convey("Invalid use of a PCA panics", t, func() {
+		So(func() { NewPCA(1, false).Fit(matrix.M(1, 2, 1, 2)) }, ShouldPanic)
+		So(func() { NewPCA(3, false).Fit(matrix.Rand(4, 2).M()) }, ShouldPanic)
+		So(func() { NewPCA(1, false).Transform(matrix.Rand(4, 2).M()) }, ShouldPanic)
+		So(func() { NewPCA(1, false).ExplainedVarianceRatio() }, ShouldPanic)
+		pca := NewPCA(1, false).Fit(matrix.Rand(4, 2).M())
+		So(func() { pca.Transform(matrix.Rand(4, 3).M()) }, ShouldPanic)
+		So(func() { pca.InverseTransform(matrix.Rand(4, 2).M()) }, ShouldPanic)
+	})
+}
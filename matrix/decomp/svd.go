@@ -0,0 +1,197 @@
+// The decomp package factors matrices and reduces their dimension, loosely
+// following numpy.linalg.svd and sklearn.decomposition.
+//
+// To project a sparse feature matrix onto its top 50 principal components:
+//     features := matrix.SparseCoo(rows, cols)
+//     ...
+//     pca := decomp.NewPCA(50, false).Fit(features)
+//     reduced := pca.Transform(features)
+//     fmt.Println(pca.ExplainedVarianceRatio())
+package decomp
+
+import (
+	"fmt"
+	gonum "github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// Find the thin singular value decomposition m = u * diag(s) * vt. For an
+// r x c matrix with k = min(r, c), u is r x k, s holds the k singular values
+// in decreasing order, and vt is k x c. The sign of each singular vector is
+// chosen so that the largest entry of each column of u is positive. Sparse
+// matrices are densified; use RandomizedSVD to keep them sparse.
+func SVD(m matrix.Matrix) (u matrix.Matrix, s matrix.NDArray, vt matrix.Matrix) {
+	rows, cols := m.Rows(), m.Cols()
+	if rows == 0 || cols == 0 {
+		panic(fmt.Sprintf("SVD can't factor a %dx%d matrix", rows, cols))
+	}
+	svd := factorSVD(m)
+	var uDense, vDense mat64.Dense
+	uDense.UFromSVD(svd)
+	vDense.VFromSVD(svd)
+	u, s = matrix.ToMatrix(&uDense), matrix.A1(svd.Values(nil)...)
+	vt = fromRows(toColumns(matrix.ToMatrix(&vDense)))
+	flipSigns(u, vt)
+	return
+}
+
+// Find the thin SVD of a matrix with mat64
+func factorSVD(m matrix.Matrix) *mat64.SVD {
+	var svd mat64.SVD
+	if !svd.Factorize(matrix.ToMat64(m), gonum.SVDThin) {
+		panic(fmt.Sprintf("SVD failed to converge for a %dx%d matrix", m.Rows(), m.Cols()))
+	}
+	return &svd
+}
+
+// Approximate the top k singular triplets of m by the randomized range
+// finder of Halko, Martinsson and Tropp. The range of m is sampled with
+// k+oversamples random vectors and sharpened by powerIters power iterations;
+// more of either gives a better approximation for more work. m is only
+// touched through MProd, so sparse coo matrices are never densified. The
+// results have the same shapes and sign convention as SVD, truncated to k.
+func RandomizedSVD(m matrix.Matrix, k, oversamples, powerIters int) (u matrix.Matrix, s matrix.NDArray, vt matrix.Matrix) {
+	return randomizedSVD(operator{m: m}, k, oversamples, powerIters)
+}
+
+// The randomized SVD of a linear operator
+func randomizedSVD(op operator, k, oversamples, powerIters int) (u matrix.Matrix, s matrix.NDArray, vt matrix.Matrix) {
+	rows, cols := op.m.Rows(), op.m.Cols()
+	size := rows
+	if cols < size {
+		size = cols
+	}
+	if k < 1 || k > size {
+		panic(fmt.Sprintf("RandomizedSVD can't find %d singular values of a %dx%d matrix", k, rows, cols))
+	} else if oversamples < 0 || powerIters < 0 {
+		panic(fmt.Sprintf("RandomizedSVD got negative oversamples %d or power iterations %d", oversamples, powerIters))
+	}
+	samples := k + oversamples
+	if samples > size {
+		samples = size
+	}
+
+	// Find an orthonormal basis q for the sampled range of m
+	q := orthonormalize(op.mul(matrix.RandN(cols, samples).M()))
+	for iter := 0; iter < powerIters; iter++ {
+		q = orthonormalize(op.mul(orthonormalize(op.tmul(q))))
+	}
+
+	// With bt = m' * q = ub * diag(s) * vb', m ~= (q * vb) * diag(s) * ub'
+	ub, sv, vbt := SVD(op.tmul(q))
+	u = fromColumns(toColumns(matrix.MProd(q, vbt.T()))[:k])
+	s = matrix.A1(sv.Array()[:k]...)
+	vt = fromRows(toColumns(ub)[:k])
+	flipSigns(u, vt)
+	return
+}
+
+// A matrix, optionally with a mean row subtracted from every row, which is
+// only accessed through products so that it can remain sparse
+type operator struct {
+	m    matrix.Matrix
+	mean []float64
+}
+
+// Find (m - 1 * mean') * x
+func (op operator) mul(x matrix.Matrix) matrix.Matrix {
+	rows, cols := op.m.Rows(), x.Cols()
+	values := op.m.MProd(x).Array()
+	if op.mean != nil {
+		xValues := x.Array()
+		for j := 0; j < cols; j++ {
+			var shift float64
+			for i, mu := range op.mean {
+				shift += mu * xValues[i*cols+j]
+			}
+			for i := 0; i < rows; i++ {
+				values[i*cols+j] -= shift
+			}
+		}
+	}
+	return matrix.M(rows, cols, values...)
+}
+
+// Find (m - 1 * mean')' * y
+func (op operator) tmul(y matrix.Matrix) matrix.Matrix {
+	rows, cols := op.m.Cols(), y.Cols()
+	values := op.m.T().MProd(y).Array()
+	if op.mean != nil {
+		sums := matrix.SumAxis(y, 0).Array()
+		for i, mu := range op.mean {
+			for j := 0; j < cols; j++ {
+				values[i*cols+j] -= mu * sums[j]
+			}
+		}
+	}
+	return matrix.M(rows, cols, values...)
+}
+
+// Find an orthonormal basis for the range of a tall matrix, as the left
+// singular vectors of its thin SVD. mat64.QR would also do, but it only
+// builds the full r x r Q, which is too large for a tall sparse operator.
+func orthonormalize(m matrix.Matrix) matrix.Matrix {
+	var u mat64.Dense
+	u.UFromSVD(factorSVD(m))
+	return matrix.ToMatrix(&u)
+}
+
+// Copy the columns of a matrix
+func toColumns(m matrix.Matrix) [][]float64 {
+	cols := make([][]float64, m.Cols())
+	for j := range cols {
+		cols[j] = m.Col(j)
+	}
+	return cols
+}
+
+// Copy the rows of a matrix
+func toRows(m matrix.Matrix) [][]float64 {
+	return toColumns(m.T())
+}
+
+// Build a dense matrix from a list of equal-length rows
+func fromRows(rows [][]float64) matrix.Matrix {
+	m := matrix.Dense(len(rows), len(rows[0])).M()
+	for i, row := range rows {
+		m.RowSet(i, row)
+	}
+	return m
+}
+
+// Build a dense matrix from a list of equal-length columns
+func fromColumns(cols [][]float64) matrix.Matrix {
+	m := matrix.Dense(len(cols[0]), len(cols)).M()
+	for j, col := range cols {
+		m.ColSet(j, col)
+	}
+	return m
+}
+
+// Flip the signs of matching columns of u and rows of vt so that the
+// largest entry of each column of u is positive
+func flipSigns(u, vt matrix.Matrix) {
+	for j := 0; j < u.Cols(); j++ {
+		col := u.Col(j)
+		var largest float64
+		for _, value := range col {
+			if math.Abs(value) > math.Abs(largest) {
+				largest = value
+			}
+		}
+		if largest >= 0 {
+			continue
+		}
+		row := vt.Row(j)
+		for i := range col {
+			col[i] = -col[i]
+		}
+		for i := range row {
+			row[i] = -row[i]
+		}
+		u.ColSet(j, col)
+		vt.RowSet(j, row)
+	}
+}
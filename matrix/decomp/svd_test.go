@@ -0,0 +1,147 @@
+package decomp
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+const Eps = 1e-9
+
+// Multiply out a singular value decomposition
+func recompose(u matrix.Matrix, s matrix.NDArray, vt matrix.Matrix) matrix.Matrix {
+	return matrix.MProd(u, matrix.Diag(s.Array()...), vt)
+}
+
+// A sparse 12x8 matrix of rank 2
+func lowRank() matrix.Matrix {
+	u1 := []float64{1, 0, 2, 0, 0, 3, 0, 1, 0, 0, 2, 0}
+	v1 := []float64{1, 0, 0, 2, 0, 1, 0, 0}
+	u2 := []float64{0, 1, 0, 0, 2, 0, 1, 0, 0, 3, 0, 1}
+	v2 := []float64{0, 3, 1, 0, 0, 0, 2, 0}
+	m := matrix.SparseCoo(12, 8)
+	for i := range u1 {
+		for j := range v1 {
+			if value := u1[i]*v1[j] + u2[i]*v2[j]; value != 0 {
+				m.ItemSet(value, i, j)
+			}
+		}
+	}
+	return m
+}
+
+func TestSVD(t *testing.T) {
+	Convey("Given a tall matrix", t, func() {
+		m := matrix.M(4, 3,
+			2, 0, 1,
+			1, 3, 0,
+			0, 1, 4,
+			1, 1, 1)
+		u, s, vt := SVD(m)
+
+		Convey("The factors have the thin shapes", func() {
+			So(u.Shape(), ShouldResemble, []int{4, 3})
+			So(s.Shape(), ShouldResemble, []int{3})
+			So(vt.Shape(), ShouldResemble, []int{3, 3})
+		})
+
+		Convey("The factors multiply back to the matrix", func() {
+			So(matrix.AllClose(recompose(u, s, vt), m, 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("The singular vectors are orthonormal", func() {
+			So(matrix.AllClose(matrix.MProd(u.T(), u), matrix.Eye(3), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(matrix.MProd(vt, vt.T()), matrix.Eye(3), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("The singular values decrease and match the eigenvalues of m'm", func() {
+			values := s.Array()
+			So(values[0], ShouldBeGreaterThan, values[1])
+			So(values[1], ShouldBeGreaterThan, values[2])
+			var sumSquares float64
+			for _, value := range values {
+				sumSquares += value * value
+			}
+			So(sumSquares, ShouldAlmostEqual, 35, Eps)
+		})
+
+		Convey("The largest entry of each left singular vector is positive", func() {
+			for j := 0; j < 3; j++ {
+				col := u.Col(j)
+				largest := col[0]
+				for _, value := range col {
+					if math.Abs(value) > math.Abs(largest) {
+						largest = value
+					}
+				}
+				So(largest, ShouldBeGreaterThan, 0)
+			}
+		})
+	})
+
+	Convey("SVD of a wide matrix gives the thin factors", t, func() {
+		m := matrix.M(2, 3,
+			3, 0, 0,
+			0, 0, -2)
+		u, s, vt := SVD(m)
+		So(u.Shape(), ShouldResemble, []int{2, 2})
+		So(vt.Shape(), ShouldResemble, []int{2, 3})
+		So(matrix.AllClose(s, matrix.A1(3, 2), 0, Eps, false), ShouldBeTrue)
+		So(matrix.AllClose(recompose(u, s, vt), m, 0, Eps, false), ShouldBeTrue)
+	})
+
+	Convey("SVD of a rank-deficient matrix has zero singular values", t, func() {
+		m := lowRank()
+		u, s, vt := SVD(m)
+		So(s.Array()[1], ShouldBeGreaterThan, 1)
+		So(s.Array()[2], ShouldAlmostEqual, 0, Eps)
+		So(matrix.AllClose(recompose(u, s, vt), m, 0, Eps, false), ShouldBeTrue)
+	})
+
+	Convey("SVD of an empty matrix panics", t, func() {
+		So(func() { SVD(matrix.Dense(0, 3).M()) }, ShouldPanic)
+	})
+}
+
+func TestRandomizedSVD(t *testing.T) {
+	Convey("Given a sparse low-rank matrix", t, func() {
+		m := lowRank()
+		u, s, vt := SVD(m)
+
+		Convey("RandomizedSVD recovers the top singular triplets", func() {
+			ru, rs, rvt := RandomizedSVD(m, 2, 2, 1)
+			So(ru.Shape(), ShouldResemble, []int{12, 2})
+			So(rs.Shape(), ShouldResemble, []int{2})
+			So(rvt.Shape(), ShouldResemble, []int{2, 8})
+			So(matrix.AllClose(rs, matrix.A1(s.Array()[:2]...), 0, 1e-8, false), ShouldBeTrue)
+			So(matrix.AllClose(ru, matrix.Slice(u, []int{0, 0}, []int{12, 2}), 0, 1e-8, false), ShouldBeTrue)
+			So(matrix.AllClose(rvt, matrix.Slice(vt, []int{0, 0}, []int{2, 8}), 0, 1e-8, false), ShouldBeTrue)
+			So(matrix.AllClose(recompose(ru, rs, rvt), m, 0, 1e-8, false), ShouldBeTrue)
+		})
+
+		Convey("RandomizedSVD leaves the matrix sparse", func() {
+			RandomizedSVD(m, 1, 3, 2)
+			So(m.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+		})
+
+		Convey("RandomizedSVD works on the transpose", func() {
+			_, rs, _ := RandomizedSVD(m.T(), 2, 0, 2)
+			So(matrix.AllClose(rs, matrix.A1(s.Array()[:2]...), 0, 1e-8, false), ShouldBeTrue)
+		})
+
+		Convey("RandomizedSVD panics on invalid arguments", func() {
+			So(func() { RandomizedSVD(m, 0, 2, 1) }, ShouldPanic)
+			So(func() { RandomizedSVD(m, 9, 2, 1) }, ShouldPanic)
+			So(func() { RandomizedSVD(m, 2, -1, 1) }, ShouldPanic)
+			So(func() { RandomizedSVD(m, 2, 2, -1) }, ShouldPanic)
+		})
+	})
+
+	Convey("RandomizedSVD approximates a dense matrix with decaying spectrum", t, func() {
+		m := matrix.MProd(matrix.RandN(40, 30).M(), matrix.Diag(matrix.Logspace(0, -6, 30, true, 10).Array()...))
+		_, s, _ := SVD(m)
+		_, rs, _ := RandomizedSVD(m, 5, 10, 3)
+		So(matrix.AllClose(rs, matrix.A1(s.Array()[:5]...), 0, 1e-6, false), ShouldBeTrue)
+	})
+}
@@ -49,9 +49,12 @@ func (array denseF64Array) AnyF2(f func(v1, v2 float64) bool, other NDArray) boo
 // Return the result of applying a function to all elements
 func (array denseF64Array) Apply(f func(float64) float64) NDArray {
 	result := array.copy()
+	errs := floatErrors{op: "Apply"}
 	for i, val := range result.array {
 		result.array[i] = f(val)
+		errs.check(val, result.array[i])
 	}
+	errs.report()
 	return result
 }
 
@@ -200,6 +203,12 @@ func (array denseF64Array) FlatItemSet(value float64, index int) {
 	array.array[index] = value
 }
 
+// Format the array for display with fmt. The verbs %v and %s use NumPy's
+// formatting rules, while %e, %f and %g format values as for float64.
+func (array denseF64Array) Format(f fmt.State, verb rune) {
+	formatState(&array, f, verb)
+}
+
 // Get the matrix inverse
 func (array denseF64Array) Inverse() (Matrix, error) {
 	return Inverse(&array)
@@ -227,9 +236,12 @@ func (array *denseF64Array) ItemAdd(value float64) NDArray {
 // Divide each array element by a scalar value
 func (array *denseF64Array) ItemDiv(value float64) NDArray {
 	result := array.copy()
-	for idx := range result.array {
-		result.array[idx] /= value
+	errs := floatErrors{op: "ItemDiv"}
+	for idx, v := range result.array {
+		result.array[idx] = v / value
+		errs.checkDiv(v, value, result.array[idx])
 	}
+	errs.report()
 	return result
 }
 
@@ -381,6 +393,11 @@ func (array denseF64Array) Sparsity() ArraySparsity {
 	return DenseArray
 }
 
+// Get a string representation of the array, formatted as for %v
+func (array denseF64Array) String() string {
+	return formatArray(&array, 'v', 0, false)
+}
+
 // Return the element-wise difference of this array and one or more others
 func (array denseF64Array) Sub(other ...NDArray) NDArray {
 	return Sub(&array, other...)
@@ -0,0 +1,568 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+func init() {
+	// Register the array types so NDArray and Matrix interface values can be
+	// sent through gob
+	gob.Register(&denseF64Array{})
+	gob.Register(&sparseCooF64Matrix{})
+	gob.Register(&sparseDiagF64Matrix{})
+}
+
+// The magic string which begins every binary-encoded array
+const binaryMagic = "NGA"
+
+// The current version of the binary encoding
+const binaryVersion = 1
+
+// The most rows a decoded sparse coo matrix may have. Every row is allocated,
+// even when it's empty, so this bounds the memory an encoding can request.
+const maxDecodedSparseRows = 1 << 24
+
+// Encode an array as a byte slice. The encoding contains the array shape,
+// sparsity and transpose flag; sparse arrays store only their nonzero values.
+func marshalBinary(array NDArray) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(binaryMagic)
+	buf.WriteByte(binaryVersion)
+	buf.WriteByte(byte(array.Sparsity()))
+	putUvarint := func(v int) {
+		var tmp [binary.MaxVarintLen64]byte
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(v))])
+	}
+	putFloat := func(v float64) {
+		var tmp [8]byte
+		binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v))
+		buf.Write(tmp[:])
+	}
+	putBool := func(v bool) {
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	}
+
+	shape := array.Shape()
+	putUvarint(len(shape))
+	for _, v := range shape {
+		putUvarint(v)
+	}
+
+	switch a := array.(type) {
+	case *denseF64Array:
+		putBool(a.transpose)
+		putUvarint(len(a.array))
+		for _, v := range a.array {
+			putFloat(v)
+		}
+
+	case *sparseCooF64Matrix:
+		putBool(a.transpose)
+		putUvarint(a.CountNonzero())
+		for row, val := range a.values {
+			for _, col := range sortedKeys(val) {
+				putUvarint(row)
+				putUvarint(col)
+				putFloat(val[col])
+			}
+		}
+
+	case *sparseDiagF64Matrix:
+		putUvarint(len(a.diag))
+		for _, v := range a.diag {
+			putFloat(v)
+		}
+
+	default:
+		return nil, fmt.Errorf("Can't encode array of type %T", array)
+	}
+	return buf.Bytes(), nil
+}
+
+// Get the keys of a sparse row in increasing order
+func sortedKeys(row map[int]float64) []int {
+	keys := make([]int, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// Decode an array from the binary encoding
+func unmarshalBinary(data []byte) (NDArray, error) {
+	r := bytes.NewReader(data)
+	header := make([]byte, len(binaryMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(binaryMagic)]) != binaryMagic {
+		return nil, fmt.Errorf("Invalid binary array encoding")
+	} else if header[len(binaryMagic)] != binaryVersion {
+		return nil, fmt.Errorf("Unsupported binary array encoding version %d", header[len(binaryMagic)])
+	}
+	sparsity := ArraySparsity(header[len(binaryMagic)+1])
+
+	var err error
+	getUvarint := func() int {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = binary.ReadUvarint(r)
+		if err == nil && v > math.MaxInt32 {
+			err = fmt.Errorf("value %d is too large", v)
+		}
+		return int(v)
+	}
+	getFloat := func() float64 {
+		if err != nil {
+			return 0
+		}
+		var tmp [8]byte
+		_, err = io.ReadFull(r, tmp[:])
+		return math.Float64frombits(binary.LittleEndian.Uint64(tmp[:]))
+	}
+	getBool := func() bool {
+		if err != nil {
+			return false
+		}
+		var b byte
+		b, err = r.ReadByte()
+		return b != 0
+	}
+
+	// Check each length against the remaining bytes before allocating: a
+	// dimension takes at least one byte and a float takes eight
+	ndim := getUvarint()
+	if err == nil && ndim > r.Len() {
+		err = fmt.Errorf("%d dimensions given in %d bytes", ndim, r.Len())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid binary array encoding: %v", err)
+	}
+	shape := make([]int, ndim)
+	size, overflow := 1, false
+	for i := range shape {
+		shape[i] = getUvarint()
+		if size > 0 && shape[i] > math.MaxInt32/size {
+			overflow = true
+		} else {
+			size *= shape[i]
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid binary array encoding: %v", err)
+	}
+	checkCount := func(count, itemSize int) {
+		if err == nil && count > r.Len()/itemSize {
+			err = fmt.Errorf("%d values given in %d bytes", count, r.Len())
+		}
+	}
+
+	var result NDArray
+	switch sparsity {
+	case DenseArray:
+		a := &denseF64Array{shape: shape}
+		a.transpose = getBool()
+		count := getUvarint()
+		if err == nil && overflow && size != 0 {
+			err = fmt.Errorf("shape %v is too large", shape)
+		}
+		if err == nil && count != size {
+			err = fmt.Errorf("%d values given for shape %v", count, shape)
+		}
+		checkCount(count, 8)
+		if err == nil {
+			a.array = make([]float64, count)
+			for i := range a.array {
+				a.array[i] = getFloat()
+			}
+		}
+		if err == nil && a.transpose && len(shape) != 2 {
+			err = fmt.Errorf("a %d-dim array can't be transposed", len(shape))
+		}
+		result = a
+
+	case SparseCooMatrix:
+		if len(shape) != 2 {
+			return nil, fmt.Errorf("Invalid binary array encoding: sparse shape %v", shape)
+		}
+		transpose := getBool()
+		rows, cols := shape[0], shape[1]
+		if transpose {
+			rows, cols = cols, rows
+		}
+
+		// Each item takes at least two bytes for its position and eight for
+		// its value
+		count := getUvarint()
+		checkCount(count, 10)
+		if err == nil && rows > maxDecodedSparseRows {
+			err = fmt.Errorf("%d rows is more than the limit of %d", rows, maxDecodedSparseRows)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid binary array encoding: %v", err)
+		}
+		a := SparseCoo(rows, cols).(*sparseCooF64Matrix)
+		a.shape = shape
+		a.transpose = transpose
+		for i := 0; i < count && err == nil; i++ {
+			row, col, v := getUvarint(), getUvarint(), getFloat()
+			if err == nil && (row >= rows || col >= cols) {
+				err = fmt.Errorf("item (%d, %d) is out of bounds", row, col)
+			} else if err == nil && v != 0 {
+				a.values[row][col] = v
+			}
+		}
+		result = a
+
+	case SparseDiagMatrix:
+		if len(shape) != 2 {
+			return nil, fmt.Errorf("Invalid binary array encoding: sparse shape %v", shape)
+		}
+		diagLen := shape[0]
+		if shape[1] < diagLen {
+			diagLen = shape[1]
+		}
+		count := getUvarint()
+		if err == nil && count != diagLen {
+			err = fmt.Errorf("%d diagonal values given for shape %v", count, shape)
+		}
+		checkCount(count, 8)
+		a := &sparseDiagF64Matrix{shape: shape}
+		if err == nil {
+			a.diag = make([]float64, count)
+			for i := range a.diag {
+				a.diag[i] = getFloat()
+			}
+		}
+		result = a
+
+	default:
+		return nil, fmt.Errorf("Invalid binary array encoding: unknown sparsity %d", sparsity)
+	}
+	if err == nil && r.Len() > 0 {
+		err = fmt.Errorf("%d trailing bytes", r.Len())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid binary array encoding: %v", err)
+	}
+	return result, nil
+}
+
+// A float64 which encodes NaN and the infinities as JSON strings
+type jsonFloat float64
+
+func (v jsonFloat) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	switch {
+	case math.IsNaN(f):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(f, 1):
+		return []byte(`"Inf"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return []byte(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+func (v *jsonFloat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		switch s {
+		case "NaN":
+			*v = jsonFloat(math.NaN())
+		case "Inf":
+			*v = jsonFloat(math.Inf(1))
+		case "-Inf":
+			*v = jsonFloat(math.Inf(-1))
+		default:
+			return fmt.Errorf("Invalid array value %q", s)
+		}
+		return nil
+	}
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*v = jsonFloat(f)
+	return nil
+}
+
+// The JSON representation of an array
+type jsonArray struct {
+	Sparsity  string      `json:"sparsity"`
+	Shape     []int       `json:"shape"`
+	Transpose bool        `json:"transpose,omitempty"`
+	Rows      []int       `json:"rows,omitempty"`
+	Cols      []int       `json:"cols,omitempty"`
+	Data      []jsonFloat `json:"data"`
+}
+
+// The names used for each sparsity in JSON
+var jsonSparsity = map[ArraySparsity]string{
+	DenseArray:       "dense",
+	SparseCooMatrix:  "coo",
+	SparseDiagMatrix: "diag",
+}
+
+// Encode an array as JSON. Dense arrays store their data in their internal
+// order; sparse coo matrices store parallel lists of rows, columns and values;
+// and sparse diagonal matrices store their diagonal.
+func marshalJSON(array NDArray) ([]byte, error) {
+	js := jsonArray{
+		Sparsity: jsonSparsity[array.Sparsity()],
+		Shape:    array.Shape(),
+	}
+	switch a := array.(type) {
+	case *denseF64Array:
+		js.Transpose = a.transpose
+		js.Data = make([]jsonFloat, len(a.array))
+		for i, v := range a.array {
+			js.Data[i] = jsonFloat(v)
+		}
+
+	case *sparseCooF64Matrix:
+		js.Transpose = a.transpose
+		count := a.CountNonzero()
+		js.Rows = make([]int, 0, count)
+		js.Cols = make([]int, 0, count)
+		js.Data = make([]jsonFloat, 0, count)
+		for row, val := range a.values {
+			for _, col := range sortedKeys(val) {
+				js.Rows = append(js.Rows, row)
+				js.Cols = append(js.Cols, col)
+				js.Data = append(js.Data, jsonFloat(val[col]))
+			}
+		}
+
+	case *sparseDiagF64Matrix:
+		js.Data = make([]jsonFloat, len(a.diag))
+		for i, v := range a.diag {
+			js.Data[i] = jsonFloat(v)
+		}
+
+	default:
+		return nil, fmt.Errorf("Can't encode array of type %T", array)
+	}
+	return json.Marshal(js)
+}
+
+// Decode an array from JSON
+func unmarshalJSON(data []byte) (NDArray, error) {
+	var js jsonArray
+	if err := json.Unmarshal(data, &js); err != nil {
+		return nil, err
+	}
+	size, overflow := 1, false
+	for _, v := range js.Shape {
+		if v < 0 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("Invalid JSON array shape %v", js.Shape)
+		} else if size > 0 && v > math.MaxInt32/size {
+			overflow = true
+		} else {
+			size *= v
+		}
+	}
+	values := make([]float64, len(js.Data))
+	for i, v := range js.Data {
+		values[i] = float64(v)
+	}
+
+	switch js.Sparsity {
+	case "dense":
+		if overflow && size != 0 {
+			return nil, fmt.Errorf("Invalid JSON array: shape %v is too large", js.Shape)
+		} else if len(values) != size {
+			return nil, fmt.Errorf("Invalid JSON array: %d values given for shape %v", len(values), js.Shape)
+		} else if js.Transpose && len(js.Shape) != 2 {
+			return nil, fmt.Errorf("Invalid JSON array: a %d-dim array can't be transposed", len(js.Shape))
+		}
+		return &denseF64Array{
+			shape:     js.Shape,
+			array:     values,
+			transpose: js.Transpose,
+		}, nil
+
+	case "coo":
+		if len(js.Shape) != 2 {
+			return nil, fmt.Errorf("Invalid JSON array: sparse shape %v", js.Shape)
+		} else if len(js.Rows) != len(values) || len(js.Cols) != len(values) {
+			return nil, fmt.Errorf("Invalid JSON array: rows, cols and data have different lengths")
+		}
+		rows, cols := js.Shape[0], js.Shape[1]
+		if js.Transpose {
+			rows, cols = cols, rows
+		}
+		if rows > maxDecodedSparseRows {
+			return nil, fmt.Errorf("Invalid JSON array: %d rows is more than the limit of %d", rows, maxDecodedSparseRows)
+		}
+		a := SparseCoo(rows, cols).(*sparseCooF64Matrix)
+		a.shape = js.Shape
+		a.transpose = js.Transpose
+		for i, v := range values {
+			row, col := js.Rows[i], js.Cols[i]
+			if row < 0 || row >= rows || col < 0 || col >= cols {
+				return nil, fmt.Errorf("Invalid JSON array: item (%d, %d) is out of bounds", row, col)
+			} else if v != 0 {
+				a.values[row][col] = v
+			}
+		}
+		return a, nil
+
+	case "diag":
+		if len(js.Shape) != 2 {
+			return nil, fmt.Errorf("Invalid JSON array: sparse shape %v", js.Shape)
+		}
+		diagLen := js.Shape[0]
+		if js.Shape[1] < diagLen {
+			diagLen = js.Shape[1]
+		}
+		if len(values) != diagLen {
+			return nil, fmt.Errorf("Invalid JSON array: %d diagonal values given for shape %v", len(values), js.Shape)
+		}
+		a := SparseDiag(js.Shape[0], js.Shape[1]).(*sparseDiagF64Matrix)
+		copy(a.diag, values)
+		return a, nil
+
+	default:
+		return nil, fmt.Errorf("Invalid JSON array sparsity %q", js.Sparsity)
+	}
+}
+
+// Decode an array which was encoded with MarshalBinary, GobEncode or
+// MarshalJSON, returning the array with its original representation.
+func Unmarshal(data []byte) (NDArray, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return unmarshalJSON(trimmed)
+	}
+	return unmarshalBinary(data)
+}
+
+// Replace the contents of array with the decoded array, which must have the
+// same representation
+func unmarshalInto(array NDArray, decode func([]byte) (NDArray, error), data []byte) error {
+	result, err := decode(data)
+	if err != nil {
+		return err
+	}
+	switch a := array.(type) {
+	case *denseF64Array:
+		if r, ok := result.(*denseF64Array); ok {
+			*a = *r
+			return nil
+		}
+	case *sparseCooF64Matrix:
+		if r, ok := result.(*sparseCooF64Matrix); ok {
+			*a = *r
+			return nil
+		}
+	case *sparseDiagF64Matrix:
+		if r, ok := result.(*sparseDiagF64Matrix); ok {
+			*a = *r
+			return nil
+		}
+	}
+	return fmt.Errorf("Can't decode a %T into a %T; use Unmarshal instead", result, array)
+}
+
+// Encode the array in a compact binary form
+func (array denseF64Array) MarshalBinary() ([]byte, error) {
+	return marshalBinary(&array)
+}
+
+// Decode an array encoded by MarshalBinary
+func (array *denseF64Array) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(array, unmarshalBinary, data)
+}
+
+// Encode the array for use with encoding/gob
+func (array denseF64Array) GobEncode() ([]byte, error) {
+	return marshalBinary(&array)
+}
+
+// Decode an array encoded by GobEncode
+func (array *denseF64Array) GobDecode(data []byte) error {
+	return unmarshalInto(array, unmarshalBinary, data)
+}
+
+// Encode the array as JSON
+func (array denseF64Array) MarshalJSON() ([]byte, error) {
+	return marshalJSON(&array)
+}
+
+// Decode an array encoded by MarshalJSON
+func (array *denseF64Array) UnmarshalJSON(data []byte) error {
+	return unmarshalInto(array, unmarshalJSON, data)
+}
+
+// Encode the array in a compact binary form
+func (array sparseCooF64Matrix) MarshalBinary() ([]byte, error) {
+	return marshalBinary(&array)
+}
+
+// Decode an array encoded by MarshalBinary
+func (array *sparseCooF64Matrix) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(array, unmarshalBinary, data)
+}
+
+// Encode the array for use with encoding/gob
+func (array sparseCooF64Matrix) GobEncode() ([]byte, error) {
+	return marshalBinary(&array)
+}
+
+// Decode an array encoded by GobEncode
+func (array *sparseCooF64Matrix) GobDecode(data []byte) error {
+	return unmarshalInto(array, unmarshalBinary, data)
+}
+
+// Encode the array as JSON
+func (array sparseCooF64Matrix) MarshalJSON() ([]byte, error) {
+	return marshalJSON(&array)
+}
+
+// Decode an array encoded by MarshalJSON
+func (array *sparseCooF64Matrix) UnmarshalJSON(data []byte) error {
+	return unmarshalInto(array, unmarshalJSON, data)
+}
+
+// Encode the array in a compact binary form
+func (array sparseDiagF64Matrix) MarshalBinary() ([]byte, error) {
+	return marshalBinary(&array)
+}
+
+// Decode an array encoded by MarshalBinary
+func (array *sparseDiagF64Matrix) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(array, unmarshalBinary, data)
+}
+
+// Encode the array for use with encoding/gob
+func (array sparseDiagF64Matrix) GobEncode() ([]byte, error) {
+	return marshalBinary(&array)
+}
+
+// Decode an array encoded by GobEncode
+func (array *sparseDiagF64Matrix) GobDecode(data []byte) error {
+	return unmarshalInto(array, unmarshalBinary, data)
+}
+
+// Encode the array as JSON
+func (array sparseDiagF64Matrix) MarshalJSON() ([]byte, error) {
+	return marshalJSON(&array)
+}
+
+// Decode an array encoded by MarshalJSON
+func (array *sparseDiagF64Matrix) UnmarshalJSON(data []byte) error {
+	return unmarshalInto(array, unmarshalJSON, data)
+}
@@ -0,0 +1,175 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	Convey("Given arrays of each type", t, func() {
+		arrays := map[string]NDArray{
+			"dense":            A([]int{2, 3, 2}, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12),
+			"transposed dense": M(2, 3, 1, 2, 3, 4, 5, 6).T(),
+			"coo":              SparseCoo(3, 4, 0, 1, 0, 0, 2, 0, 0, 3),
+			"transposed coo":   SparseCoo(3, 4, 0, 1, 0, 0, 2, 0, 0, 3).T(),
+			"diag":             SparseDiag(3, 5, 1, 2, 3),
+		}
+
+		for name, array := range arrays {
+			array := array
+			Convey("A "+name+" array survives a binary round trip", func() {
+				data, err := array.(interface {
+					MarshalBinary() ([]byte, error)
+				}).MarshalBinary()
+				So(err, ShouldBeNil)
+				result, err := Unmarshal(data)
+				So(err, ShouldBeNil)
+				So(result.Sparsity(), ShouldEqual, array.Sparsity())
+				So(result.Shape(), ShouldResemble, array.Shape())
+				So(result.Array(), ShouldResemble, array.Array())
+			})
+
+			Convey("A "+name+" array survives a JSON round trip", func() {
+				data, err := json.Marshal(array)
+				So(err, ShouldBeNil)
+				result, err := Unmarshal(data)
+				So(err, ShouldBeNil)
+				So(result.Sparsity(), ShouldEqual, array.Sparsity())
+				So(result.Shape(), ShouldResemble, array.Shape())
+				So(result.Array(), ShouldResemble, array.Array())
+			})
+
+			Convey("A "+name+" array survives a gob round trip as an NDArray", func() {
+				var buf bytes.Buffer
+				So(gob.NewEncoder(&buf).Encode(&array), ShouldBeNil)
+				var result NDArray
+				So(gob.NewDecoder(&buf).Decode(&result), ShouldBeNil)
+				So(result.Sparsity(), ShouldEqual, array.Sparsity())
+				So(result.Shape(), ShouldResemble, array.Shape())
+				So(result.Array(), ShouldResemble, array.Array())
+			})
+		}
+	})
+
+	Convey("Sparse encodings store only nonzero values", t, func() {
+		data, err := SparseCoo(1000, 1000).(*sparseCooF64Matrix).MarshalBinary()
+		So(err, ShouldBeNil)
+		So(len(data), ShouldBeLessThan, 32)
+
+		data, err = json.Marshal(SparseCoo(2, 2, 0, 5))
+		So(err, ShouldBeNil)
+		So(string(data), ShouldEqual, `{"sparsity":"coo","shape":[2,2],"rows":[0],"cols":[1],"data":[5]}`)
+	})
+
+	Convey("Non-finite values survive a JSON round trip", t, func() {
+		data, err := json.Marshal(A1(math.NaN(), math.Inf(1), math.Inf(-1), 1))
+		So(err, ShouldBeNil)
+		result, err := Unmarshal(data)
+		So(err, ShouldBeNil)
+		So(math.IsNaN(result.FlatItem(0)), ShouldBeTrue)
+		So(math.IsInf(result.FlatItem(1), 1), ShouldBeTrue)
+		So(math.IsInf(result.FlatItem(2), -1), ShouldBeTrue)
+		So(result.FlatItem(3), ShouldEqual, 1)
+	})
+
+	Convey("Arrays can be decoded into a concrete type", t, func() {
+		data, err := json.Marshal(Diag(1, 2))
+		So(err, ShouldBeNil)
+		var diag sparseDiagF64Matrix
+		So(json.Unmarshal(data, &diag), ShouldBeNil)
+		So(diag.Array(), ShouldResemble, []float64{1, 0, 0, 2})
+
+		Convey("But not into a different type", func() {
+			var dense denseF64Array
+			So(json.Unmarshal(data, &dense), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Unmarshal rejects invalid data", t, func() {
+		for _, data := range []string{
+			"",
+			"garbage",
+			"NGA",
+			`{"sparsity":"dense","shape":[2],"data":[1]}`,
+			`{"sparsity":"coo","shape":[2,2],"rows":[2],"cols":[0],"data":[1]}`,
+			`{"sparsity":"diag","shape":[2,2],"data":[1]}`,
+			`{"sparsity":"csr","shape":[2,2],"data":[]}`,
+		} {
+			_, err := Unmarshal([]byte(data))
+			So(err, ShouldNotBeNil)
+		}
+
+		data, _ := A1(1, 2).(*denseF64Array).MarshalBinary()
+		_, err := Unmarshal(data[:len(data)-1])
+		So(err, ShouldNotBeNil)
+		_, err = Unmarshal(append(data, 0))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Unmarshal rejects lengths which don't fit in the data", t, func() {
+		encode := func(sparsity ArraySparsity, values ...uint64) []byte {
+			data := []byte{'N', 'G', 'A', binaryVersion, byte(sparsity)}
+			for _, v := range values {
+				var tmp [binary.MaxVarintLen64]byte
+				data = append(data, tmp[:binary.PutUvarint(tmp[:], v)]...)
+			}
+			return data
+		}
+		for _, data := range [][]byte{
+			encode(DenseArray, 1<<30),
+			encode(DenseArray, 3, 1<<20, 1<<20, 1<<20, 0),
+			encode(DenseArray, 2, 1<<15, 1<<15, 0, 1<<30),
+			encode(SparseCooMatrix, 2, 1<<30, 1, 0, 0),
+			encode(SparseCooMatrix, 2, 4, 4, 0, 1<<20),
+			encode(SparseDiagMatrix, 2, 1<<20, 1<<20, 1<<20),
+		} {
+			_, err := Unmarshal(data)
+			So(err, ShouldNotBeNil)
+		}
+
+		coo := SparseCoo(3, 3)
+		coo.ItemSet(5, 1, 2)
+		for _, array := range []NDArray{A1(1, 2, 3), Diag(1, 2), coo} {
+			data, _ := marshalBinary(array)
+			for end := 0; end < len(data); end++ {
+				_, err := Unmarshal(data[:end])
+				So(err, ShouldNotBeNil)
+			}
+		}
+	})
+
+	Convey("Unmarshal rejects JSON shapes which don't fit the data", t, func() {
+		for _, data := range []string{
+			`{"sparsity":"diag","shape":[2000000000000,2000000000000],"data":[]}`,
+			`{"sparsity":"diag","shape":[2000000000,2000000000],"data":[]}`,
+			`{"sparsity":"dense","shape":[65536,65536],"data":[]}`,
+			`{"sparsity":"dense","shape":[4611686018427387904,4],"data":[]}`,
+			`{"sparsity":"coo","shape":[2000000000,1],"rows":[],"cols":[],"data":[]}`,
+			`{"sparsity":"coo","shape":[1,2000000000],"transpose":true,"rows":[],"cols":[],"data":[]}`,
+		} {
+			_, err := Unmarshal([]byte(data))
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("Large sparse shapes decode when their data fits", t, func() {
+		coo := SparseCoo(1000, 100000000)
+		coo.ItemSet(5, 999, 99999999)
+		jsonData, err := json.Marshal(coo)
+		So(err, ShouldBeNil)
+		binaryData, err := marshalBinary(coo)
+		So(err, ShouldBeNil)
+		for _, data := range [][]byte{jsonData, binaryData} {
+			decoded, err := Unmarshal(data)
+			So(err, ShouldBeNil)
+			So(decoded.Shape(), ShouldResemble, []int{1000, 100000000})
+			So(decoded.Item(999, 99999999), ShouldEqual, 5)
+			So(decoded.CountNonzero(), ShouldEqual, 1)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// The kinds of floating-point error which ErrState can detect
+type FloatErrorKind int
+
+const (
+	// A nonzero finite value was divided by zero, producing an infinity
+	DivideByZero FloatErrorKind = iota
+
+	// A finite computation produced an infinity
+	Overflow
+
+	// A computation on non-NaN values produced NaN, as with Inf / Inf
+	Invalid
+)
+
+func (kind FloatErrorKind) String() string {
+	switch kind {
+	case DivideByZero:
+		return "divide by zero"
+	case Overflow:
+		return "overflow"
+	case Invalid:
+		return "invalid value"
+	default:
+		return fmt.Sprintf("FloatErrorKind(%d)", int(kind))
+	}
+}
+
+// A floating-point error detected by an array operation
+type FloatError struct {
+
+	// The kind of error
+	Kind FloatErrorKind
+
+	// The name of the operation, such as "Div"
+	Op string
+
+	// The number of array elements which encountered the error
+	Count int
+}
+
+func (err *FloatError) Error() string {
+	return fmt.Sprintf("%s encountered in %s (%d elements)", err.Kind, err.Op, err.Count)
+}
+
+// What to do when a floating-point error is detected
+type ErrAction int
+
+const (
+	// Keep the result silently
+	ErrIgnore ErrAction = iota
+
+	// Keep the result, and pass the error to ErrState.Callback
+	ErrWarn
+
+	// Panic with the *FloatError; see CatchFloatError
+	ErrRaise
+)
+
+// Decides how Div, ItemDiv, Apply and Normalize handle floating-point errors.
+// The zero value ignores all errors, which was the only behavior before
+// error states were introduced.
+type ErrState struct {
+	Divide   ErrAction
+	Overflow ErrAction
+	Invalid  ErrAction
+
+	// Receives errors whose action is ErrWarn. If nil, warnings are dropped.
+	Callback func(err *FloatError)
+}
+
+var (
+	errStateLock sync.RWMutex
+	errState     ErrState
+)
+
+// Get the current error state
+func GetErr() ErrState {
+	errStateLock.RLock()
+	defer errStateLock.RUnlock()
+	return errState
+}
+
+// Replace the error state, returning the old one so it can be restored.
+// The state is shared by all goroutines.
+//
+// To panic on any NaN produced by a block of code:
+//     old := SetErr(ErrState{Invalid: ErrRaise})
+//     defer SetErr(old)
+func SetErr(state ErrState) ErrState {
+	errStateLock.Lock()
+	defer errStateLock.Unlock()
+	old := errState
+	errState = state
+	return old
+}
+
+// Set every action in the error state to the same value, returning the old
+// state
+func SetErrAll(action ErrAction) ErrState {
+	state := GetErr()
+	state.Divide, state.Overflow, state.Invalid = action, action, action
+	return SetErr(state)
+}
+
+// Run f, converting a *FloatError panic raised by ErrRaise into an error.
+// Other panics are propagated.
+func CatchFloatError(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if fe, ok := r.(*FloatError); ok {
+				err = fe
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	f()
+	return nil
+}
+
+// Counts the floating-point errors encountered by one operation
+type floatErrors struct {
+	op     string
+	counts [3]int
+}
+
+// Check the result of a unary operation
+func (fe *floatErrors) check(in, out float64) {
+	if math.IsNaN(out) && !math.IsNaN(in) {
+		fe.counts[Invalid]++
+	} else if math.IsInf(out, 0) && !math.IsInf(in, 0) && !math.IsNaN(in) {
+		fe.counts[Overflow]++
+	}
+}
+
+// Check the result of a division
+func (fe *floatErrors) checkDiv(num, den, out float64) {
+	if math.IsNaN(out) && !math.IsNaN(num) && !math.IsNaN(den) {
+		fe.counts[Invalid]++
+	} else if math.IsInf(out, 0) && !math.IsInf(num, 0) && !math.IsNaN(num) {
+		if den == 0 {
+			fe.counts[DivideByZero]++
+		} else {
+			fe.counts[Overflow]++
+		}
+	}
+}
+
+// Handle the counted errors according to the current error state
+func (fe *floatErrors) report() {
+	state := GetErr()
+	actions := [3]ErrAction{state.Divide, state.Overflow, state.Invalid}
+	for kind, count := range fe.counts {
+		if count == 0 {
+			continue
+		}
+		err := &FloatError{
+			Kind:  FloatErrorKind(kind),
+			Op:    fe.op,
+			Count: count,
+		}
+		switch actions[kind] {
+		case ErrWarn:
+			if state.Callback != nil {
+				state.Callback(err)
+			}
+		case ErrRaise:
+			panic(err)
+		}
+	}
+}
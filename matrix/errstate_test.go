@@ -0,0 +1,109 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestErrState(t *testing.T) {
+	Convey("Given the default error state", t, func() {
+		So(GetErr(), ShouldResemble, ErrState{})
+
+		Convey("Errors are ignored", func() {
+			So(math.IsInf(A1(1, 0).ItemDiv(0).FlatItem(0), 1), ShouldBeTrue)
+			So(A1(1, 0).Div(A1(0, 0)).FlatItem(1), ShouldEqual, 0)
+			So(math.IsNaN(A1(-1).Apply(math.Sqrt).FlatItem(0)), ShouldBeTrue)
+			So(A1(1, -1).Normalize().Equal(A1(1, -1)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given an error state which warns", t, func() {
+		var warnings []*FloatError
+		old := SetErrAll(ErrWarn)
+		state := GetErr()
+		state.Callback = func(err *FloatError) {
+			warnings = append(warnings, err)
+		}
+		SetErr(state)
+		Reset(func() {
+			SetErr(old)
+		})
+
+		Convey("Division by zero is reported", func() {
+			r := A1(1, 2, 0).Div(A1(0, 0, 0))
+			So(r.Equal(A1(math.Inf(1), math.Inf(1), 0)), ShouldBeTrue)
+			So(warnings, ShouldResemble, []*FloatError{{DivideByZero, "Div", 2}})
+		})
+
+		Convey("Scalar division by zero is reported", func() {
+			SparseCoo(2, 2, 0, -3).ItemDiv(0)
+			So(warnings, ShouldResemble, []*FloatError{{DivideByZero, "ItemDiv", 1}})
+		})
+
+		Convey("Overflow is reported", func() {
+			A1(1e300, 1).ItemDiv(1e-10)
+			A1(710).Apply(math.Exp)
+			So(warnings, ShouldResemble, []*FloatError{
+				{Overflow, "ItemDiv", 1},
+				{Overflow, "Apply", 1},
+			})
+		})
+
+		Convey("Invalid operations are reported", func() {
+			A1(math.Inf(1)).Div(A1(math.Inf(-1)))
+			A1(-1, -4, 4).Apply(math.Sqrt)
+			So(warnings, ShouldResemble, []*FloatError{
+				{Invalid, "Div", 1},
+				{Invalid, "Apply", 2},
+			})
+		})
+
+		Convey("Existing NaN and infinite values are not reported", func() {
+			A1(math.NaN(), math.Inf(1)).ItemDiv(2)
+			A1(math.NaN(), math.Inf(1)).Apply(math.Sqrt)
+			So(warnings, ShouldBeEmpty)
+		})
+
+		Convey("Normalizing an array which sums to zero is reported", func() {
+			So(A1(1, -1).Normalize().Equal(A1(1, -1)), ShouldBeTrue)
+			Zeros(3).Normalize()
+			So(warnings, ShouldResemble, []*FloatError{{DivideByZero, "Normalize", 1}})
+		})
+
+		Convey("Normalizing an infinite array is reported", func() {
+			A1(math.Inf(1), 1).Normalize()
+			So(warnings, ShouldResemble, []*FloatError{{Invalid, "Normalize", 1}})
+		})
+	})
+
+	Convey("Given an error state which raises", t, func() {
+		old := SetErr(ErrState{Invalid: ErrRaise})
+		Reset(func() {
+			SetErr(old)
+		})
+
+		Convey("Invalid operations panic", func() {
+			So(func() { A1(-1).Apply(math.Sqrt) }, ShouldPanic)
+		})
+
+		Convey("Ignored errors don't panic", func() {
+			So(func() { A1(1).ItemDiv(0) }, ShouldNotPanic)
+		})
+
+		Convey("CatchFloatError returns the error", func() {
+			err := CatchFloatError(func() {
+				A1(-1).Apply(math.Log)
+			})
+			So(err, ShouldResemble, &FloatError{Invalid, "Apply", 1})
+			So(err.Error(), ShouldEqual, "invalid value encountered in Apply (1 elements)")
+			So(CatchFloatError(func() {}), ShouldBeNil)
+		})
+
+		Convey("CatchFloatError propagates other panics", func() {
+			So(func() {
+				CatchFloatError(func() { panic("other") })
+			}, ShouldPanicWith, "other")
+		})
+	})
+}
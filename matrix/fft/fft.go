@@ -0,0 +1,307 @@
+// The fft package computes discrete Fourier transforms of arrays, loosely
+// following the numpy.fft module. Since arrays in the matrix package hold
+// float64 values, complex arrays are stored as a pair of real arrays holding
+// the real and imaginary parts.
+//
+// To get the spectrum of a real signal and recover the signal:
+//     spectrum := fft.RFFT(signal, 0)
+//     freqs := fft.RFFTFreq(signal.Size(), 1.0/sampleRate)
+//     recovered := fft.IRFFT(spectrum, signal.Size(), 0)
+//
+// Transforms of any length take O(n log n) time.
+package fft
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math/cmplx"
+)
+
+// A complex-valued array, stored as two real arrays of the same shape
+type ComplexArray struct {
+	Real matrix.NDArray
+	Imag matrix.NDArray
+}
+
+// Create a complex array from its real and imaginary parts. If imag is nil,
+// the imaginary part is zero.
+func NewComplex(real, imag matrix.NDArray) ComplexArray {
+	if imag == nil {
+		imag = matrix.Zeros(real.Shape()...)
+	}
+	sh1, sh2 := real.Shape(), imag.Shape()
+	if len(sh1) != len(sh2) {
+		panic(fmt.Sprintf("Real shape %v doesn't match imaginary shape %v", sh1, sh2))
+	}
+	for i := range sh1 {
+		if sh1[i] != sh2[i] {
+			panic(fmt.Sprintf("Real shape %v doesn't match imaginary shape %v", sh1, sh2))
+		}
+	}
+	return ComplexArray{
+		Real: real,
+		Imag: imag,
+	}
+}
+
+// A slice giving the size of all array dimensions
+func (c ComplexArray) Shape() []int {
+	return c.Real.Shape()
+}
+
+// Get an array element
+func (c ComplexArray) Item(index ...int) complex128 {
+	re := c.Real.Item(append([]int{}, index...)...)
+	im := c.Imag.Item(append([]int{}, index...)...)
+	return complex(re, im)
+}
+
+// Get the magnitude of each element
+func (c ComplexArray) Abs() matrix.NDArray {
+	return c.mapReal(cmplx.Abs)
+}
+
+// Get the phase angle of each element, in radians
+func (c ComplexArray) Angle() matrix.NDArray {
+	return c.mapReal(cmplx.Phase)
+}
+
+// Get the complex conjugate of the array
+func (c ComplexArray) Conj() ComplexArray {
+	return ComplexArray{
+		Real: c.Real.Copy(),
+		Imag: c.Imag.ItemProd(-1),
+	}
+}
+
+// Apply a function from complex to real values to each element
+func (c ComplexArray) mapReal(f func(complex128) float64) matrix.NDArray {
+	result := matrix.Dense(c.Shape()...)
+	for i := 0; i < result.Size(); i++ {
+		result.FlatItemSet(f(complex(c.Real.FlatItem(i), c.Imag.FlatItem(i))), i)
+	}
+	return result
+}
+
+// Visit each lane of an array with the given shape along axis, passing the
+// flat index of the lane's first element and the distance between elements
+func visitLanes(shape []int, axis int, f func(start, stride int)) {
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	outer, inner := 1, 1
+	for _, sz := range shape[:axis] {
+		outer *= sz
+	}
+	for _, sz := range shape[axis+1:] {
+		inner *= sz
+	}
+	for o := 0; o < outer; o++ {
+		for k := 0; k < inner; k++ {
+			f(o*shape[axis]*inner+k, inner)
+		}
+	}
+}
+
+// Transform each lane of x along an axis, producing outLen values per lane.
+// Each lane is read into a buffer of length bufLen, zero-padded or
+// truncated, and f transforms the buffer in place before its first outLen
+// values are stored.
+func transformLanes(x ComplexArray, axis, bufLen, outLen int, f func(buf []complex128)) ComplexArray {
+	shape := x.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	n := shape[axis]
+	outShape := append([]int{}, shape...)
+	outShape[axis] = outLen
+	result := ComplexArray{
+		Real: matrix.Dense(outShape...),
+		Imag: matrix.Dense(outShape...),
+	}
+	if outLen == 0 {
+		return result
+	}
+	buf := make([]complex128, bufLen)
+	visitLanes(outShape, axis, func(start, stride int) {
+		// Lanes of the input and output differ only in their length
+		inStart := start / (outLen * stride) * (n * stride)
+		inStart += start % stride
+		for i := range buf {
+			if i < n {
+				j := inStart + i*stride
+				buf[i] = complex(x.Real.FlatItem(j), x.Imag.FlatItem(j))
+			} else {
+				buf[i] = 0
+			}
+		}
+		f(buf)
+		for i := 0; i < outLen; i++ {
+			j := start + i*stride
+			result.Real.FlatItemSet(real(buf[i]), j)
+			result.Imag.FlatItemSet(imag(buf[i]), j)
+		}
+	})
+	return result
+}
+
+// Get the discrete Fourier transform of x along an axis
+func FFT(x ComplexArray, axis int) ComplexArray {
+	n := x.Shape()[axis]
+	return transformLanes(x, axis, n, n, func(buf []complex128) {
+		Transform(buf, false)
+	})
+}
+
+// Get the inverse discrete Fourier transform of x along an axis, normalized
+// so that IFFT(FFT(x)) == x
+func IFFT(x ComplexArray, axis int) ComplexArray {
+	n := x.Shape()[axis]
+	return transformLanes(x, axis, n, n, func(buf []complex128) {
+		Transform(buf, true)
+	})
+}
+
+// Get the discrete Fourier transform of a real array along an axis. Since
+// the transform of a real signal is Hermitian-symmetric, only the n/2 + 1
+// non-negative frequency terms are returned.
+func RFFT(x matrix.NDArray, axis int) ComplexArray {
+	n := x.Shape()[axis]
+	if n < 1 {
+		panic("RFFT: can't transform an empty axis")
+	}
+	return transformLanes(NewComplex(x, nil), axis, n, n/2+1, func(buf []complex128) {
+		Transform(buf, false)
+	})
+}
+
+// Get the real inverse of RFFT along an axis, producing n values per lane.
+// The input holds the non-negative frequency terms; it's truncated or
+// zero-padded to n/2 + 1 terms, and the imaginary parts of the zero and
+// Nyquist frequencies are ignored.
+func IRFFT(x ComplexArray, n, axis int) matrix.NDArray {
+	if n < 1 {
+		panic(fmt.Sprintf("IRFFT: invalid output length %d", n))
+	}
+	terms := n/2 + 1
+	result := transformLanes(x, axis, n, n, func(buf []complex128) {
+		for i := terms; i < n; i++ {
+			buf[i] = cmplx.Conj(buf[n-i])
+		}
+		buf[0] = complex(real(buf[0]), 0)
+		if n%2 == 0 {
+			buf[n/2] = complex(real(buf[n/2]), 0)
+		}
+		Transform(buf, true)
+	})
+	return result.Real
+}
+
+// Get the axes to transform: all axes if none are given
+func allAxes(shape []int, axes []int) []int {
+	if len(axes) > 0 {
+		return axes
+	}
+	axes = make([]int, len(shape))
+	for i := range axes {
+		axes[i] = i
+	}
+	return axes
+}
+
+// Get the N-dimensional discrete Fourier transform of x over the given axes,
+// or all axes if none are given
+func FFTN(x ComplexArray, axes ...int) ComplexArray {
+	for _, axis := range allAxes(x.Shape(), axes) {
+		x = FFT(x, axis)
+	}
+	return x
+}
+
+// Get the N-dimensional inverse discrete Fourier transform of x over the
+// given axes, or all axes if none are given
+func IFFTN(x ComplexArray, axes ...int) ComplexArray {
+	for _, axis := range allAxes(x.Shape(), axes) {
+		x = IFFT(x, axis)
+	}
+	return x
+}
+
+// Get the 2-dimensional discrete Fourier transform of x over its last two
+// axes
+func FFT2(x ComplexArray) ComplexArray {
+	nd := len(x.Shape())
+	if nd < 2 {
+		panic(fmt.Sprintf("FFT2 needs at least 2 axes, but got shape %v", x.Shape()))
+	}
+	return FFTN(x, nd-2, nd-1)
+}
+
+// Get the 2-dimensional inverse discrete Fourier transform of x over its
+// last two axes
+func IFFT2(x ComplexArray) ComplexArray {
+	nd := len(x.Shape())
+	if nd < 2 {
+		panic(fmt.Sprintf("IFFT2 needs at least 2 axes, but got shape %v", x.Shape()))
+	}
+	return IFFTN(x, nd-2, nd-1)
+}
+
+// Get the frequencies of the terms returned by FFT for a signal of length n
+// with sample spacing d. The frequencies are in cycles per unit of d, with
+// zero first, then positive frequencies, then negative ones.
+func FFTFreq(n int, d float64) matrix.NDArray {
+	result := matrix.Dense(n)
+	for i := 0; i < n; i++ {
+		k := i
+		if i >= (n+1)/2 {
+			k = i - n
+		}
+		result.FlatItemSet(float64(k)/(float64(n)*d), i)
+	}
+	return result
+}
+
+// Get the frequencies of the terms returned by RFFT for a signal of length
+// n with sample spacing d
+func RFFTFreq(n int, d float64) matrix.NDArray {
+	result := matrix.Dense(n/2 + 1)
+	for i := 0; i <= n/2; i++ {
+		result.FlatItemSet(float64(i)/(float64(n)*d), i)
+	}
+	return result
+}
+
+// Roll an array along the given axes, or all axes if none are given, so
+// that index i moves to index (i + shift(n)) mod n
+func roll(x matrix.NDArray, shift func(n int) int, axes []int) matrix.NDArray {
+	shape := x.Shape()
+	result := x.Dense()
+	for _, axis := range allAxes(shape, axes) {
+		src := result
+		result = matrix.Dense(shape...)
+		n := shape[axis]
+		if n == 0 {
+			continue
+		}
+		s := shift(n)
+		visitLanes(shape, axis, func(start, stride int) {
+			for i := 0; i < n; i++ {
+				j := (i + s) % n
+				result.FlatItemSet(src.FlatItem(start+i*stride), start+j*stride)
+			}
+		})
+	}
+	return result
+}
+
+// Shift the zero-frequency term to the center of the given axes, or all axes
+// if none are given
+func FFTShift(x matrix.NDArray, axes ...int) matrix.NDArray {
+	return roll(x, func(n int) int { return n / 2 }, axes)
+}
+
+// Undo FFTShift over the given axes, or all axes if none are given
+func IFFTShift(x matrix.NDArray, axes ...int) matrix.NDArray {
+	return roll(x, func(n int) int { return n - n/2 }, axes)
+}
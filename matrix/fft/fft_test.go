@@ -0,0 +1,112 @@
+package fft
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	Convey("Given a real signal", t, func() {
+		x := matrix.A1(1, 2, 3, 4)
+
+		Convey("FFT gives the expected spectrum", func() {
+			y := FFT(NewComplex(x, nil), 0)
+			So(matrix.AllClose(y.Real, matrix.A1(10, -2, -2, -2), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(y.Imag, matrix.A1(0, 2, 0, -2), 0, Eps, false), ShouldBeTrue)
+			So(y.Item(1), ShouldEqual, complex(-2, 2))
+		})
+
+		Convey("IFFT undoes FFT", func() {
+			y := IFFT(FFT(NewComplex(x, nil), 0), 0)
+			So(matrix.AllClose(y.Real, x, 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(y.Imag, matrix.Zeros(4), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Abs and Angle give magnitude and phase", func() {
+			y := FFT(NewComplex(x, nil), 0)
+			So(matrix.AllClose(y.Abs(), matrix.A1(10, math.Sqrt(8), 2, math.Sqrt(8)), 0, Eps, false), ShouldBeTrue)
+			So(y.Angle().FlatItem(1), ShouldAlmostEqual, 3*math.Pi/4, Eps)
+			So(y.Conj().Imag.FlatItem(1), ShouldAlmostEqual, -2, Eps)
+		})
+	})
+
+	Convey("RFFT matches the first half of FFT, and IRFFT inverts it", t, func() {
+		for _, n := range []int{1, 5, 6, 9} {
+			x := matrix.Rand(n)
+			full := FFT(NewComplex(x, nil), 0)
+			half := RFFT(x, 0)
+			So(half.Shape(), ShouldResemble, []int{n/2 + 1})
+			So(matrix.AllClose(half.Real, full.Real.Slice([]int{0}, []int{n/2 + 1}), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(half.Imag, full.Imag.Slice([]int{0}, []int{n/2 + 1}), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(IRFFT(half, n, 0), x, 0, Eps, false), ShouldBeTrue)
+		}
+		So(func() { RFFT(matrix.Dense(0), 0) }, ShouldPanic)
+		So(func() { IRFFT(RFFT(matrix.Rand(4), 0), 0, 0) }, ShouldPanic)
+	})
+
+	Convey("Given a 2D array", t, func() {
+		m := matrix.M(2, 3,
+			1, 2, 3,
+			4, 5, 6)
+
+		Convey("FFT along an axis transforms each lane", func() {
+			y := FFT(NewComplex(m, nil), 0)
+			So(matrix.AllClose(y.Real, matrix.M(2, 3, 5, 7, 9, -3, -3, -3), 0, Eps, false), ShouldBeTrue)
+			y = FFT(NewComplex(m.T(), nil), 1)
+			So(matrix.AllClose(y.Real, matrix.M(3, 2, 5, -3, 7, -3, 9, -3), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("FFT2 matches FFTN over all axes", func() {
+			y := FFT2(NewComplex(m, nil))
+			z := FFTN(NewComplex(m, nil))
+			So(matrix.AllClose(y.Real, z.Real, 0, Eps, false), ShouldBeTrue)
+			So(y.Real.FlatItem(0), ShouldAlmostEqual, 21, Eps)
+			So(y.Real.Item(1, 0), ShouldAlmostEqual, -9, Eps)
+			back := IFFT2(y)
+			So(matrix.AllClose(back.Real, m, 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(IFFTN(z).Real, m, 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("FFTN can transform chosen axes of a 3D array", func() {
+			a := matrix.Rand(2, 3, 4)
+			y := FFTN(NewComplex(a, nil), 2, 0)
+			So(y.Shape(), ShouldResemble, []int{2, 3, 4})
+			So(matrix.AllClose(IFFTN(y, 0, 2).Real, a, 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Invalid axes panic", func() {
+			So(func() { FFT(NewComplex(m, nil), 2) }, ShouldPanic)
+			So(func() { FFT2(NewComplex(matrix.A1(1), nil)) }, ShouldPanic)
+		})
+	})
+
+	Convey("Mismatched real and imaginary shapes panic", t, func() {
+		So(func() { NewComplex(matrix.Dense(2), matrix.Dense(3)) }, ShouldPanic)
+	})
+}
+
+func TestFreq(t *testing.T) {
+	Convey("FFTFreq lists positive then negative frequencies", t, func() {
+		So(FFTFreq(8, 0.5).Equal(matrix.A1(0, 0.25, 0.5, 0.75, -1, -0.75, -0.5, -0.25)), ShouldBeTrue)
+		So(matrix.AllClose(FFTFreq(5, 1), matrix.A1(0, 0.2, 0.4, -0.4, -0.2), 0, Eps, false), ShouldBeTrue)
+	})
+
+	Convey("RFFTFreq lists non-negative frequencies", t, func() {
+		So(RFFTFreq(8, 0.5).Equal(matrix.A1(0, 0.25, 0.5, 0.75, 1)), ShouldBeTrue)
+		So(matrix.AllClose(RFFTFreq(5, 1), matrix.A1(0, 0.2, 0.4), 0, Eps, false), ShouldBeTrue)
+	})
+
+	Convey("FFTShift centers the zero frequency", t, func() {
+		So(matrix.AllClose(FFTShift(FFTFreq(5, 1)), matrix.A1(-0.4, -0.2, 0, 0.2, 0.4), 0, Eps, false), ShouldBeTrue)
+		So(FFTShift(matrix.A1(0, 1, 2, 3)).Equal(matrix.A1(2, 3, 0, 1)), ShouldBeTrue)
+		So(IFFTShift(FFTShift(matrix.A1(0, 1, 2, 3, 4))).Equal(matrix.A1(0, 1, 2, 3, 4)), ShouldBeTrue)
+	})
+
+	Convey("FFTShift can shift chosen axes", t, func() {
+		m := matrix.M(2, 3, 0, 1, 2, 3, 4, 5)
+		So(FFTShift(m, 1).Equal(matrix.M(2, 3, 2, 0, 1, 5, 3, 4)), ShouldBeTrue)
+		So(FFTShift(m).Equal(matrix.M(2, 3, 5, 3, 4, 2, 0, 1)), ShouldBeTrue)
+	})
+}
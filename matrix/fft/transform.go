@@ -0,0 +1,119 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Returns true if n is a power of two
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// Get the twiddle factor exp(sign * 2 pi i k / n), computed directly from k
+// so that errors don't accumulate
+func twiddle(k, n int, sign float64) complex128 {
+	s, c := math.Sincos(sign * 2 * math.Pi * float64(k) / float64(n))
+	return complex(c, s)
+}
+
+// Compute the discrete Fourier transform of data in place. The forward
+// transform uses exp(-2 pi i jk / n) and is unnormalized. The inverse uses
+// exp(+2 pi i jk / n) and divides by n, so it undoes the forward transform.
+// Lengths which are powers of two use the radix-2 Cooley-Tukey algorithm,
+// and other lengths use Bluestein's algorithm, so every length takes
+// O(n log n) time.
+func Transform(data []complex128, inverse bool) {
+	n := len(data)
+	if n <= 1 {
+		return
+	}
+	if isPowerOfTwo(n) {
+		radix2(data, inverse)
+	} else {
+		bluestein(data, inverse)
+	}
+	if inverse {
+		scale := complex(1/float64(n), 0)
+		for i := range data {
+			data[i] *= scale
+		}
+	}
+}
+
+// An unnormalized radix-2 transform of data, whose length is a power of two
+func radix2(data []complex128, inverse bool) {
+	n := len(data)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+
+	w := make([]complex128, n/2)
+	for k := range w {
+		w[k] = twiddle(k, n, sign)
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half, step := size/2, n/size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				t := w[k*step] * data[start+k+half]
+				data[start+k+half] = data[start+k] - t
+				data[start+k] += t
+			}
+		}
+	}
+}
+
+// An unnormalized transform of data of any length, computed as a
+// convolution with a chirp using power-of-two transforms
+func bluestein(data []complex128, inverse bool) {
+	n := len(data)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+
+	// chirp[k] = exp(sign * pi i k^2 / n); k^2 is reduced mod 2n for accuracy
+	chirp := make([]complex128, n)
+	for k := range chirp {
+		k2 := (k * k) % (2 * n)
+		s, c := math.Sincos(sign * math.Pi * float64(k2) / float64(n))
+		chirp[k] = complex(c, s)
+	}
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = data[k] * chirp[k]
+		b[k] = cmplx.Conj(chirp[k])
+		if k > 0 {
+			b[m-k] = b[k]
+		}
+	}
+	radix2(a, false)
+	radix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	radix2(a, true)
+	scale := complex(1/float64(m), 0)
+	for k := 0; k < n; k++ {
+		data[k] = a[k] * scale * chirp[k]
+	}
+}
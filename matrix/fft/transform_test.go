@@ -0,0 +1,81 @@
+package fft
+
+import (
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+const Eps = 1e-9
+
+// A direct O(n^2) discrete Fourier transform
+func naiveDFT(x []complex128, inverse bool) []complex128 {
+	n := len(x)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	result := make([]complex128, n)
+	for k := range result {
+		for j, v := range x {
+			result[k] += v * cmplx.Exp(complex(0, sign*2*math.Pi*float64(j*k)/float64(n)))
+		}
+		if inverse {
+			result[k] /= complex(float64(n), 0)
+		}
+	}
+	return result
+}
+
+// Assert that two complex slices are close
+func shouldBeClose(actual, expected []complex128, tol float64) {
+	So(len(actual), ShouldEqual, len(expected))
+	for i := range actual {
+		So(cmplx.Abs(actual[i]-expected[i]), ShouldBeLessThan, tol)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	Convey("Given random inputs of many lengths", t, func() {
+		rng := rand.New(rand.NewSource(1))
+		for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 12, 16, 17, 31, 64, 100} {
+			x := make([]complex128, n)
+			for i := range x {
+				x[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+			}
+
+			Convey(fmt.Sprintf("Forward and inverse transforms of length %d match the DFT", n), func() {
+				y := append([]complex128{}, x...)
+				Transform(y, false)
+				shouldBeClose(y, naiveDFT(x, false), 1e-9*float64(n))
+				Transform(y, true)
+				shouldBeClose(y, x, 1e-9*float64(n))
+			})
+		}
+	})
+
+	Convey("The transform of an impulse is flat", t, func() {
+		x := []complex128{1, 0, 0, 0, 0, 0}
+		Transform(x, false)
+		shouldBeClose(x, []complex128{1, 1, 1, 1, 1, 1}, Eps)
+	})
+
+	Convey("Empty inputs are unchanged", t, func() {
+		So(func() { Transform(nil, false) }, ShouldNotPanic)
+	})
+
+	Convey("Long prime lengths are accurate", t, func() {
+		n := 1009
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(math.Cos(2*math.Pi*5*float64(i)/float64(n)), 0)
+		}
+		Transform(x, false)
+		So(real(x[5]), ShouldAlmostEqual, float64(n)/2, 1e-7)
+		So(real(x[n-5]), ShouldAlmostEqual, float64(n)/2, 1e-7)
+		So(cmplx.Abs(x[6]), ShouldBeLessThan, 1e-7)
+	})
+}
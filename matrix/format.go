@@ -0,0 +1,243 @@
+package matrix
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options which control how arrays are printed
+type PrintOptions struct {
+
+	// Arrays with more than this many elements (or sparse matrices with more
+	// than this many nonzero elements) are summarized with "..."
+	Threshold int
+
+	// The number of items shown at the beginning and end of each axis of a
+	// summarized array
+	EdgeItems int
+
+	// The number of digits shown after the decimal point, unless the format
+	// verb specifies a precision
+	Precision int
+}
+
+// The options used to print all arrays. Modify this to change how arrays
+// are displayed.
+var PrintOpts = PrintOptions{
+	Threshold: 1000,
+	EdgeItems: 3,
+	Precision: 8,
+}
+
+// Get a function which formats array values for the given verb and precision.
+// For %v and %s, the values are formatted as NumPy does: in fixed point with
+// trailing zeros removed, or in scientific notation if they vary widely in
+// magnitude.
+func valueFormatter(values []float64, verb rune, prec int, hasPrec bool) func(float64) string {
+	if !hasPrec {
+		prec = PrintOpts.Precision
+	}
+	nonFinite := func(v float64) (string, bool) {
+		switch {
+		case math.IsNaN(v):
+			return "nan", true
+		case math.IsInf(v, 1):
+			return "inf", true
+		case math.IsInf(v, -1):
+			return "-inf", true
+		}
+		return "", false
+	}
+
+	switch verb {
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if !hasPrec && (verb == 'g' || verb == 'G') {
+			prec = -1
+		}
+		return func(v float64) string {
+			if s, ok := nonFinite(v); ok {
+				return s
+			}
+			return strconv.FormatFloat(v, byte(verb), prec, 64)
+		}
+	}
+
+	var maxAbs, minAbs = 0.0, math.Inf(1)
+	for _, v := range values {
+		if abs := math.Abs(v); v != 0 && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			maxAbs = math.Max(maxAbs, abs)
+			minAbs = math.Min(minAbs, abs)
+		}
+	}
+	sci := maxAbs >= 1e8 || (maxAbs > 0 && (minAbs < 1e-4 || maxAbs/minAbs > 1e3))
+	return func(v float64) string {
+		if s, ok := nonFinite(v); ok {
+			return s
+		}
+		if sci {
+			s := strconv.FormatFloat(v, 'e', prec, 64)
+			exp := strings.Index(s, "e")
+			mantissa := strings.TrimRight(s[:exp], "0")
+			if strings.HasSuffix(mantissa, ".") {
+				mantissa += "0"
+			}
+			return mantissa + s[exp:]
+		}
+		s := strconv.FormatFloat(v, 'f', prec, 64)
+		if strings.Contains(s, ".") {
+			s = strings.TrimRight(s, "0")
+		} else {
+			s += "."
+		}
+		return s
+	}
+}
+
+// Get the indices to display along an axis of the given size. A -1 marks
+// the position of the "..." which replaces skipped indices.
+func displayIndices(size int, summarize bool) []int {
+	edge := PrintOpts.EdgeItems
+	var result []int
+	if summarize && size > 2*edge {
+		for i := 0; i < edge; i++ {
+			result = append(result, i)
+		}
+		result = append(result, -1)
+		for i := size - edge; i < size; i++ {
+			result = append(result, i)
+		}
+	} else {
+		for i := 0; i < size; i++ {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Format a dense view of an array with nested brackets
+func formatDense(array NDArray, verb rune, prec int, hasPrec bool) string {
+	shape := array.Shape()
+	if len(shape) == 0 {
+		return valueFormatter([]float64{array.FlatItem(0)}, verb, prec, hasPrec)(array.FlatItem(0))
+	} else if array.Size() == 0 {
+		return strings.Repeat("[", len(shape)) + strings.Repeat("]", len(shape))
+	}
+	summarize := array.Size() > PrintOpts.Threshold
+
+	// Visit the displayed elements to choose the number format and width
+	var values []float64
+	var visit func(axis int, index []int)
+	visit = func(axis int, index []int) {
+		for _, i := range displayIndices(shape[axis], summarize) {
+			if i < 0 {
+				continue
+			}
+			index[axis] = i
+			if axis == len(shape)-1 {
+				values = append(values, array.FlatItem(ndToFlat(shape, index)))
+			} else {
+				visit(axis+1, index)
+			}
+		}
+	}
+	visit(0, make([]int, len(shape)))
+	format := valueFormatter(values, verb, prec, hasPrec)
+	width := 0
+	for _, v := range values {
+		if w := len(format(v)); w > width {
+			width = w
+		}
+	}
+
+	var render func(axis int, index []int) string
+	render = func(axis int, index []int) string {
+		var parts []string
+		for _, i := range displayIndices(shape[axis], summarize) {
+			if i < 0 {
+				parts = append(parts, "...")
+				continue
+			}
+			index[axis] = i
+			if axis == len(shape)-1 {
+				s := format(array.FlatItem(ndToFlat(shape, index)))
+				parts = append(parts, strings.Repeat(" ", width-len(s))+s)
+			} else {
+				parts = append(parts, render(axis+1, index))
+			}
+		}
+		sep := " "
+		if axis < len(shape)-1 {
+			sep = strings.Repeat("\n", len(shape)-axis-1) + strings.Repeat(" ", axis+1)
+		}
+		return "[" + strings.Join(parts, sep) + "]"
+	}
+	return render(0, make([]int, len(shape)))
+}
+
+// Format a sparse matrix as a listing of its nonzero values
+func formatSparse(array NDArray, verb rune, prec int, hasPrec bool) string {
+	type entry struct {
+		row, col int
+		value    float64
+	}
+	var entries []entry
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		entries = append(entries, entry{pos[0], pos[1], value})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].row != entries[j].row {
+			return entries[i].row < entries[j].row
+		}
+		return entries[i].col < entries[j].col
+	})
+
+	shown := displayIndices(len(entries), len(entries) > PrintOpts.Threshold)
+	values := make([]float64, 0, len(shown))
+	for _, i := range shown {
+		if i >= 0 {
+			values = append(values, entries[i].value)
+		}
+	}
+	format := valueFormatter(values, verb, prec, hasPrec)
+
+	var buf bytes.Buffer
+	for n, i := range shown {
+		if n > 0 {
+			buf.WriteString("\n")
+		}
+		if i < 0 {
+			buf.WriteString("  :\t:")
+		} else {
+			fmt.Fprintf(&buf, "  (%d, %d)\t%s", entries[i].row, entries[i].col, format(entries[i].value))
+		}
+	}
+	return buf.String()
+}
+
+// Format an array for display. Dense arrays are shown with nested brackets,
+// as NumPy does, and sparse matrices are shown as a list of their nonzero
+// values.
+func formatArray(array NDArray, verb rune, prec int, hasPrec bool) string {
+	if array.Sparsity() == DenseArray {
+		return formatDense(array, verb, prec, hasPrec)
+	}
+	return formatSparse(array, verb, prec, hasPrec)
+}
+
+// Implement fmt.Formatter for an array. The verbs %v and %s use NumPy's
+// formatting rules, while %e, %f and %g format values as for float64. All
+// verbs accept a precision, such as %.3f or %.2v.
+func formatState(array NDArray, f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's', 'e', 'E', 'f', 'F', 'g', 'G':
+		prec, hasPrec := f.Precision()
+		fmt.Fprint(f, formatArray(array, verb, prec, hasPrec))
+	default:
+		fmt.Fprintf(f, "%%!%c(%T)", verb, array)
+	}
+}
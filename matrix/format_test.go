@@ -0,0 +1,122 @@
+package matrix
+
+import (
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestFormatDense(t *testing.T) {
+	Convey("Given dense arrays", t, func() {
+		Convey("1D arrays print on one line", func() {
+			So(A1(1, 2.5, -3).String(), ShouldEqual, "[ 1. 2.5 -3.]")
+			So(fmt.Sprint(A1(1, 2, 3)), ShouldEqual, "[1. 2. 3.]")
+		})
+
+		Convey("2D arrays print with aligned columns", func() {
+			m := M(2, 3,
+				1, 20, 3,
+				4, 5, 600)
+			So(fmt.Sprintf("%v", m), ShouldEqual, "[[  1.  20.   3.]\n [  4.   5. 600.]]")
+		})
+
+		Convey("Transposed matrices print in logical order", func() {
+			m := M(2, 3, 1, 2, 3, 4, 5, 6).T()
+			So(m.String(), ShouldEqual, "[[1. 4.]\n [2. 5.]\n [3. 6.]]")
+		})
+
+		Convey("3D arrays print with nested brackets", func() {
+			a := A([]int{2, 2, 2}, 1, 2, 3, 4, 5, 6, 7, 8)
+			So(a.String(), ShouldEqual, "[[[1. 2.]\n  [3. 4.]]\n\n [[5. 6.]\n  [7. 8.]]]")
+		})
+
+		Convey("Widely varying values use scientific notation", func() {
+			So(A1(1, 1e10).String(), ShouldEqual, "[1.0e+00 1.0e+10]")
+			So(A1(0.5, 0.00001).String(), ShouldEqual, "[5.0e-01 1.0e-05]")
+		})
+
+		Convey("Non-finite values are printed", func() {
+			So(A1(math.NaN(), math.Inf(1), math.Inf(-1)).String(), ShouldEqual, "[ nan  inf -inf]")
+		})
+
+		Convey("Format verbs and precision are honored", func() {
+			a := A1(1, 2.0/3)
+			So(fmt.Sprintf("%.3f", a), ShouldEqual, "[1.000 0.667]")
+			So(fmt.Sprintf("%.2e", a), ShouldEqual, "[1.00e+00 6.67e-01]")
+			So(fmt.Sprintf("%.2v", a), ShouldEqual, "[  1. 0.67]")
+			So(fmt.Sprintf("%v", a), ShouldEqual, "[        1. 0.66666667]")
+			So(fmt.Sprintf("%g", a), ShouldEqual, "[                 1 0.6666666666666666]")
+			So(fmt.Sprintf("%d", a), ShouldEqual, "%!d(*matrix.denseF64Array)")
+		})
+
+		Convey("Empty arrays print as brackets", func() {
+			So(Dense(0).String(), ShouldEqual, "[]")
+			So(Dense(2, 0).String(), ShouldEqual, "[[]]")
+		})
+	})
+
+	Convey("Given large dense arrays", t, func() {
+		saved := PrintOpts
+		PrintOpts.Threshold = 10
+		PrintOpts.EdgeItems = 2
+		Reset(func() {
+			PrintOpts = saved
+		})
+
+		Convey("Long 1D arrays are summarized", func() {
+			a := Dense(20)
+			for i := 0; i < 20; i++ {
+				a.FlatItemSet(float64(i), i)
+			}
+			So(a.String(), ShouldEqual, "[ 0.  1. ... 18. 19.]")
+		})
+
+		Convey("Large 2D arrays are summarized along both axes", func() {
+			m := Dense(5, 5)
+			for i := 0; i < 25; i++ {
+				m.FlatItemSet(float64(i), i)
+			}
+			So(m.String(), ShouldEqual, "[[ 0.  1. ...  3.  4.]\n [ 5.  6. ...  8.  9.]\n ...\n [15. 16. ... 18. 19.]\n [20. 21. ... 23. 24.]]")
+		})
+
+		Convey("Small arrays are not summarized", func() {
+			So(A1(1, 2, 3, 4, 5).String(), ShouldEqual, "[1. 2. 3. 4. 5.]")
+		})
+	})
+}
+
+func TestFormatSparse(t *testing.T) {
+	Convey("Given sparse matrices", t, func() {
+		Convey("Sparse coo matrices list their nonzero values", func() {
+			m := SparseCoo(3, 4,
+				0, 1.5, 0, 0,
+				2, 0, 0, 3)
+			So(m.String(), ShouldEqual, "  (0, 1)\t1.5\n  (1, 0)\t2.\n  (1, 3)\t3.")
+			So(fmt.Sprintf("%.2f", m), ShouldEqual, "  (0, 1)\t1.50\n  (1, 0)\t2.00\n  (1, 3)\t3.00")
+		})
+
+		Convey("Transposed sparse coo matrices list their values in logical order", func() {
+			m := SparseCoo(2, 2, 0, 1, 2, 0).T()
+			So(m.String(), ShouldEqual, "  (0, 1)\t2.\n  (1, 0)\t1.")
+		})
+
+		Convey("Sparse diagonal matrices list their diagonal", func() {
+			So(fmt.Sprint(Diag(1, 2)), ShouldEqual, "  (0, 0)\t1.\n  (1, 1)\t2.")
+		})
+
+		Convey("Empty sparse matrices print nothing", func() {
+			So(SparseCoo(2, 2).String(), ShouldEqual, "")
+		})
+
+		Convey("Sparse matrices with many nonzeros are summarized", func() {
+			saved := PrintOpts
+			PrintOpts.Threshold = 3
+			PrintOpts.EdgeItems = 1
+			Reset(func() {
+				PrintOpts = saved
+			})
+			So(Diag(1, 2, 3, 4).String(), ShouldEqual, "  (0, 0)\t1.\n  :\t:\n  (3, 3)\t4.")
+		})
+	})
+}
@@ -0,0 +1,125 @@
+package integrate
+
+import (
+	"github.com/jesand/numgo/matrix"
+	"github.com/jesand/numgo/matrix/optimize"
+	"math"
+)
+
+// Get the Jacobian of the right-hand side, estimating it by forward
+// differences if it wasn't given
+func (s *odeSolver) jacobian(t float64, y, f0 []float64) matrix.Matrix {
+	if s.opts.Jacobian != nil {
+		return s.opts.Jacobian(t, matrix.A1(y...))
+	}
+	return optimize.Jacobian(func(x matrix.NDArray) matrix.NDArray {
+		return matrix.A1(s.eval(t, x.Array())...)
+	}, matrix.A1(y...), &optimize.DiffOptions{F0: matrix.A1(f0...)})
+}
+
+// Solve an initial value problem from t0 to t1 with an implicit backward
+// differentiation formula, adapting the step size to meet the error
+// tolerances. This takes much larger steps than RK45 on stiff problems,
+// whose solutions combine fast and slow decays. The first step uses
+// backward Euler and later steps use the variable-step BDF2 formula; each
+// step solves for the new state with Newton's method. Integration runs
+// backward if t1 < t0.
+func BDF(f ODEFunc, t0, t1 float64, y0 matrix.NDArray, opts *ODEOptions) (*ODESolution, error) {
+	s := newODESolver("BDF", f, t0, t1, y0, opts)
+	n := len(s.y)
+	newtonTol := math.Max(10*2.220446049250313e-16/s.opts.RelTol, math.Min(0.03, math.Sqrt(s.opts.RelTol)))
+	fCur := s.eval(s.t, s.y)
+	h := s.initialStep(fCur, 1)
+	var yPrev []float64
+	var hPrev float64
+	for !s.done() {
+		h = math.Min(h, s.stepLimit())
+		if err := s.checkStep("BDF", h); err != nil {
+			return s.solution(), err
+		}
+		tNext := s.t + s.dir*h
+
+		// Write the step as y = gamma h f(tNext, y) + psi, and predict y by
+		// extrapolation. The predictor's error estimates the step's error.
+		gamma, errConst, order := 1.0, 0.5, 1
+		psi := make([]float64, n)
+		predicted := make([]float64, n)
+		if yPrev == nil {
+			for i := range psi {
+				psi[i] = s.y[i]
+				predicted[i] = s.y[i] + s.dir*h*fCur[i]
+			}
+		} else {
+			w := h / hPrev
+			gamma, errConst, order = (1+w)/(1+2*w), 0.4, 2
+			for i := range psi {
+				psi[i] = ((1+w)*(1+w)*s.y[i] - w*w*yPrev[i]) / (1 + 2*w)
+				c := (yPrev[i] - s.y[i] + s.dir*hPrev*fCur[i]) / (hPrev * hPrev)
+				predicted[i] = s.y[i] + s.dir*h*fCur[i] + c*h*h
+			}
+		}
+
+		// Solve for the new state with Newton's method
+		y := make([]float64, n)
+		copy(y, predicted)
+		scale := s.scale(s.y, predicted)
+		fy := s.eval(tNext, y)
+		jac := s.jacobian(tNext, y, fy)
+		lhs := matrix.FromFunction([]int{n, n}, func(index []int) float64 {
+			val := -gamma * s.dir * h * jac.Item(index[0], index[1])
+			if index[0] == index[1] {
+				val++
+			}
+			return val
+		}).M()
+		converged := false
+		prevNorm := math.Inf(1)
+		for iter := 0; iter < 4; iter++ {
+			if iter > 0 {
+				fy = s.eval(tNext, y)
+			}
+			rhs := matrix.Dense(n, 1).M()
+			for i := 0; i < n; i++ {
+				rhs.ItemSet(psi[i]+gamma*s.dir*h*fy[i]-y[i], i, 0)
+			}
+			dy := matrix.Solve(lhs, rhs).Array()
+			for i := range y {
+				y[i] += dy[i]
+			}
+			norm := rmsNorm(dy, scale)
+			if norm <= newtonTol {
+				converged = true
+				break
+			} else if !(norm < prevNorm) {
+				break
+			}
+			prevNorm = norm
+		}
+		if !converged {
+			h *= 0.5
+			continue
+		}
+
+		errs := make([]float64, n)
+		for i := range errs {
+			errs[i] = errConst * (y[i] - predicted[i])
+		}
+		errNorm := rmsNorm(errs, s.scale(s.y, y))
+		if errNorm <= 1 {
+			yPrev, hPrev = s.y, h
+			s.accept(h, y)
+			fCur = s.eval(s.t, s.y)
+		}
+
+		// Limit the growth of the step so that BDF2 stays stable
+		factor := 2.0
+		if errNorm > 0 {
+			factor = math.Min(2, math.Max(0.2, 0.9*math.Pow(errNorm, -1/float64(order+1))))
+		}
+		if errNorm > 1 {
+			factor = math.Min(factor, 1)
+		}
+		h *= factor
+	}
+	return s.solution(), nil
+}
@@ -0,0 +1,73 @@
+package integrate
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestBDF(t *testing.T) {
+	Convey("Given a stiff equation", t, func() {
+		// The solution is cos(t), but nearby solutions decay quickly onto it
+		stiff := func(t float64, y matrix.NDArray) matrix.NDArray {
+			return matrix.A1(-1000*(y.FlatItem(0)-math.Cos(t)) - math.Sin(t))
+		}
+		opts := &ODEOptions{RelTol: 1e-6, AbsTol: 1e-9}
+
+		Convey("BDF follows the solution with far fewer steps than RK45", func() {
+			sol, err := BDF(stiff, 0, 10, matrix.A1(1), opts)
+			So(err, ShouldBeNil)
+			for i := 0; i < sol.T.Size(); i++ {
+				So(sol.Y.Item(i, 0), ShouldAlmostEqual, math.Cos(sol.T.FlatItem(i)), 1e-4)
+			}
+
+			explicit, err := RK45(stiff, 0, 10, matrix.A1(1), opts)
+			So(err, ShouldBeNil)
+			So(sol.Steps*2, ShouldBeLessThan, explicit.Steps)
+		})
+
+		Convey("A supplied Jacobian avoids finite differences", func() {
+			jacobian := func(t float64, y matrix.NDArray) matrix.Matrix {
+				return matrix.A2([]float64{-1000}).M()
+			}
+			withJac, err := BDF(stiff, 0, 1, matrix.A1(1), &ODEOptions{RelTol: 1e-6, AbsTol: 1e-9, Jacobian: jacobian})
+			So(err, ShouldBeNil)
+			without, _ := BDF(stiff, 0, 1, matrix.A1(1), opts)
+			So(withJac.Evals, ShouldBeLessThan, without.Evals)
+			last := withJac.T.Size() - 1
+			So(withJac.Y.Item(last, 0), ShouldAlmostEqual, math.Cos(1), 1e-4)
+		})
+	})
+
+	Convey("Given the Robertson chemical kinetics problem", t, func() {
+		robertson := func(t float64, y matrix.NDArray) matrix.NDArray {
+			y1, y2, y3 := y.FlatItem(0), y.FlatItem(1), y.FlatItem(2)
+			return matrix.A1(
+				-0.04*y1+1e4*y2*y3,
+				0.04*y1-1e4*y2*y3-3e7*y2*y2,
+				3e7*y2*y2,
+			)
+		}
+
+		Convey("BDF matches the reference solution at the requested times", func() {
+			sol, err := BDF(robertson, 0, 40, matrix.A1(1, 0, 0),
+				&ODEOptions{RelTol: 1e-6, AbsTol: 1e-10, TEval: []float64{0, 40}})
+			So(err, ShouldBeNil)
+			So(sol.T.Equal(matrix.A1(0, 40)), ShouldBeTrue)
+			So(sol.Y.Item(1, 0), ShouldAlmostEqual, 0.7158271, 1e-4)
+			So(sol.Y.Item(1, 1), ShouldAlmostEqual, 9.185535e-6, 1e-8)
+			So(sol.Y.Item(1, 2), ShouldAlmostEqual, 0.2841637, 1e-4)
+		})
+	})
+
+	Convey("Given an oscillator", t, func() {
+		Convey("BDF integrates backward in time", func() {
+			sol, err := BDF(oscillator, math.Pi, 0, matrix.A1(-1, 0), &ODEOptions{RelTol: 1e-8, AbsTol: 1e-10})
+			So(err, ShouldBeNil)
+			last := sol.T.Size() - 1
+			So(sol.T.FlatItem(last), ShouldEqual, 0)
+			So(sol.Y.Item(last, 0), ShouldAlmostEqual, 1, 1e-4)
+		})
+	})
+}
@@ -0,0 +1,319 @@
+package integrate
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// The right-hand side of an ordinary differential equation dy/dt = f(t, y),
+// where the state y is a 1D array. The result must have the same size as y.
+type ODEFunc func(t float64, y matrix.NDArray) matrix.NDArray
+
+// Options for the ODE solvers. The zero value of each field selects a
+// default.
+type ODEOptions struct {
+	// The relative and absolute error tolerances for each step; the
+	// defaults are 1e-3 and 1e-6
+	RelTol, AbsTol float64
+
+	// The size of the first step; by default it is chosen automatically
+	FirstStep float64
+
+	// The largest step to take; by default steps are unbounded
+	MaxStep float64
+
+	// The most steps to attempt, counting rejected steps, before giving up;
+	// the default is 100000
+	MaxSteps int
+
+	// The times at which to report the solution, between t0 and t1 in the
+	// direction of integration. By default, the solution is reported after
+	// every step.
+	TEval []float64
+
+	// The Jacobian of the right-hand side, used by BDF; by default it is
+	// estimated by finite differences
+	Jacobian func(t float64, y matrix.NDArray) matrix.Matrix
+}
+
+// The trajectory found by an ODE solver
+type ODESolution struct {
+	// The times at which the solution is reported
+	T matrix.NDArray
+
+	// The state at each time, with one row per time and one column per
+	// state variable
+	Y matrix.Matrix
+
+	// The number of steps taken and right-hand side evaluations made
+	Steps, Evals int
+}
+
+// The shared state of an ODE solver
+type odeSolver struct {
+	f        ODEFunc
+	opts     ODEOptions
+	t, t1    float64
+	dir      float64
+	y        []float64
+	evals    int
+	steps    int
+	attempts int
+	nextEval int
+	times    []float64
+	states   [][]float64
+}
+
+// Set up a solver, filling in default options
+func newODESolver(name string, f ODEFunc, t0, t1 float64, y0 matrix.NDArray, opts *ODEOptions) *odeSolver {
+	if y0.NDim() != 1 || y0.Size() == 0 {
+		panic(fmt.Sprintf("%s needs a nonempty 1D initial state, but got shape %v", name, y0.Shape()))
+	} else if t0 == t1 {
+		panic(fmt.Sprintf("%s needs distinct start and end times, but got %v", name, t0))
+	}
+	s := &odeSolver{f: f, t: t0, t1: t1, dir: 1, y: y0.Dense().Array()}
+	if t1 < t0 {
+		s.dir = -1
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.RelTol == 0 {
+		s.opts.RelTol = 1e-3
+	}
+	if s.opts.AbsTol == 0 {
+		s.opts.AbsTol = 1e-6
+	}
+	if s.opts.MaxStep == 0 {
+		s.opts.MaxStep = math.Inf(1)
+	}
+	if s.opts.MaxSteps == 0 {
+		s.opts.MaxSteps = 100000
+	}
+	for i, t := range s.opts.TEval {
+		if (t-t0)*s.dir < 0 || (t-t1)*s.dir > 0 || (i > 0 && (t-s.opts.TEval[i-1])*s.dir < 0) {
+			panic(fmt.Sprintf("%s needs TEval sorted within [%v, %v], but got %v", name, t0, t1, s.opts.TEval))
+		}
+	}
+	if s.opts.TEval == nil {
+		s.record(t0, s.y)
+	} else {
+		s.recordEvals(t0, s.y)
+	}
+	return s
+}
+
+// Evaluate the right-hand side
+func (s *odeSolver) eval(t float64, y []float64) []float64 {
+	s.evals++
+	dy := s.f(t, matrix.A1(y...))
+	if dy.Size() != len(y) {
+		panic(fmt.Sprintf("ODE function returned %d values for a state of size %d", dy.Size(), len(y)))
+	}
+	result := make([]float64, len(y))
+	for i := range result {
+		result[i] = dy.FlatItem(i)
+	}
+	return result
+}
+
+// Save a point of the trajectory
+func (s *odeSolver) record(t float64, y []float64) {
+	state := make([]float64, len(y))
+	copy(state, y)
+	s.times = append(s.times, t)
+	s.states = append(s.states, state)
+}
+
+// Save the state if the current time is the next one requested
+func (s *odeSolver) recordEvals(t float64, y []float64) {
+	for s.nextEval < len(s.opts.TEval) && s.opts.TEval[s.nextEval] == t {
+		s.record(t, y)
+		s.nextEval++
+	}
+}
+
+// Get the largest step allowed from the current time, stopping at the end
+// time and the next requested time
+func (s *odeSolver) stepLimit() float64 {
+	limit := math.Min(s.opts.MaxStep, math.Abs(s.t1-s.t))
+	if s.nextEval < len(s.opts.TEval) {
+		limit = math.Min(limit, math.Abs(s.opts.TEval[s.nextEval]-s.t))
+	}
+	return limit
+}
+
+// Move to the end of an accepted step of the given (unsigned) size. A step
+// that reaches the next requested time or the end time lands on it exactly.
+func (s *odeSolver) accept(h float64, y []float64) {
+	s.steps++
+	if s.nextEval < len(s.opts.TEval) && math.Abs(s.opts.TEval[s.nextEval]-s.t) <= h {
+		s.t = s.opts.TEval[s.nextEval]
+	} else if math.Abs(s.t1-s.t) <= h {
+		s.t = s.t1
+	} else {
+		s.t += s.dir * h
+	}
+	s.y = y
+	if s.opts.TEval == nil {
+		s.record(s.t, s.y)
+	} else {
+		s.recordEvals(s.t, s.y)
+	}
+}
+
+// Check whether the solver has reached the end time
+func (s *odeSolver) done() bool {
+	return s.t == s.t1
+}
+
+// Get the error tolerance for each state variable over a step
+func (s *odeSolver) scale(y0, y1 []float64) []float64 {
+	scale := make([]float64, len(y0))
+	for i := range scale {
+		scale[i] = s.opts.AbsTol + s.opts.RelTol*math.Max(math.Abs(y0[i]), math.Abs(y1[i]))
+	}
+	return scale
+}
+
+// Get the root mean square of a vector divided elementwise by a scale
+func rmsNorm(x, scale []float64) float64 {
+	var sum float64
+	for i, v := range x {
+		sum += (v / scale[i]) * (v / scale[i])
+	}
+	return math.Sqrt(sum / float64(len(x)))
+}
+
+// Choose the size of the first step for a method of the given order, given
+// the derivative at the starting point
+func (s *odeSolver) initialStep(f0 []float64, order int) float64 {
+	if s.opts.FirstStep != 0 {
+		return math.Min(math.Abs(s.opts.FirstStep), s.stepLimit())
+	}
+	scale := s.scale(s.y, s.y)
+	d0, d1 := rmsNorm(s.y, scale), rmsNorm(f0, scale)
+	h0 := 0.01 * d0 / d1
+	if d0 < 1e-5 || d1 < 1e-5 {
+		h0 = 1e-6
+	}
+	h0 = math.Min(h0, s.stepLimit())
+	y1 := make([]float64, len(s.y))
+	for i := range y1 {
+		y1[i] = s.y[i] + s.dir*h0*f0[i]
+	}
+	f1 := s.eval(s.t+s.dir*h0, y1)
+	diff := make([]float64, len(f0))
+	for i := range diff {
+		diff[i] = f1[i] - f0[i]
+	}
+	d2 := rmsNorm(diff, scale) / h0
+	h1 := math.Max(1e-6, h0*1e-3)
+	if d := math.Max(d1, d2); d > 1e-15 {
+		h1 = math.Pow(0.01/d, 1/float64(order+1))
+	}
+	return math.Min(math.Min(100*h0, h1), s.stepLimit())
+}
+
+// Count an attempted step, and check that it is not too small to make
+// progress
+func (s *odeSolver) checkStep(name string, h float64) error {
+	s.attempts++
+	if s.attempts > s.opts.MaxSteps {
+		return fmt.Errorf("%s took more than %d steps", name, s.opts.MaxSteps)
+	} else if math.IsNaN(h) {
+		return fmt.Errorf("%s step size became NaN at t = %v", name, s.t)
+	} else if h < 10*math.Abs(math.Nextafter(s.t, s.t+s.dir)-s.t) {
+		return fmt.Errorf("%s step size became too small at t = %v", name, s.t)
+	}
+	return nil
+}
+
+// Package the trajectory as a solution
+func (s *odeSolver) solution() *ODESolution {
+	return &ODESolution{
+		T:     matrix.A1(s.times...),
+		Y:     matrix.A2(s.states...).M(),
+		Steps: s.steps,
+		Evals: s.evals,
+	}
+}
+
+// The Dormand-Prince 5(4) tableau
+var (
+	dopriC = []float64{0, 1.0 / 5, 3.0 / 10, 4.0 / 5, 8.0 / 9, 1, 1}
+	dopriA = [][]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	}
+	dopriErr = []float64{
+		71.0 / 57600, 0, -71.0 / 16695, 71.0 / 1920, -17253.0 / 339200, 22.0 / 525, -1.0 / 40,
+	}
+)
+
+// Solve an initial value problem from t0 to t1 with the explicit
+// Dormand-Prince Runge-Kutta method of order 5(4), adapting the step size to
+// meet the error tolerances. This is a good default for problems which
+// aren't stiff. Integration runs backward if t1 < t0.
+func RK45(f ODEFunc, t0, t1 float64, y0 matrix.NDArray, opts *ODEOptions) (*ODESolution, error) {
+	s := newODESolver("RK45", f, t0, t1, y0, opts)
+	n := len(s.y)
+	k := make([][]float64, 7)
+	k[0] = s.eval(s.t, s.y)
+	h := s.initialStep(k[0], 4)
+	for !s.done() {
+		h = math.Min(h, s.stepLimit())
+		if err := s.checkStep("RK45", h); err != nil {
+			return s.solution(), err
+		}
+
+		// The last stage is the new state, so its derivative can be reused
+		// as the first stage of the next step
+		var y1 []float64
+		for stage := 1; stage < 7; stage++ {
+			y := make([]float64, n)
+			for i := range y {
+				y[i] = s.y[i]
+				for j, a := range dopriA[stage] {
+					y[i] += s.dir * h * a * k[j][i]
+				}
+			}
+			k[stage] = s.eval(s.t+s.dir*h*dopriC[stage], y)
+			y1 = y
+		}
+
+		errs := make([]float64, n)
+		for i := range errs {
+			for j, e := range dopriErr {
+				errs[i] += h * e * k[j][i]
+			}
+		}
+		// A non-finite error, such as from a derivative which is NaN outside
+		// the domain of f, rejects the step and shrinks it as far as possible
+		errNorm := rmsNorm(errs, s.scale(s.y, y1))
+		if math.IsNaN(errNorm) || math.IsInf(errNorm, 0) {
+			h *= 0.2
+			continue
+		}
+		if errNorm <= 1 {
+			s.accept(h, y1)
+			k[0] = k[6]
+		}
+
+		factor := 10.0
+		if errNorm > 0 {
+			factor = math.Min(10, math.Max(0.2, 0.9*math.Pow(errNorm, -0.2)))
+		}
+		if errNorm > 1 {
+			factor = math.Min(factor, 1)
+		}
+		h *= factor
+	}
+	return s.solution(), nil
+}
@@ -0,0 +1,84 @@
+package integrate
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+// The harmonic oscillator y'' = -y as a first-order system
+func oscillator(t float64, y matrix.NDArray) matrix.NDArray {
+	return matrix.A1(y.FlatItem(1), -y.FlatItem(0))
+}
+
+func TestRK45(t *testing.T) {
+	Convey("Given exponential decay", t, func() {
+		decay := func(t float64, y matrix.NDArray) matrix.NDArray {
+			return y.ItemProd(-0.5)
+		}
+
+		Convey("RK45 follows the exact solution", func() {
+			sol, err := RK45(decay, 0, 10, matrix.A1(100, 1), &ODEOptions{RelTol: 1e-8, AbsTol: 1e-10})
+			So(err, ShouldBeNil)
+			last := sol.T.Size() - 1
+			So(sol.T.FlatItem(0), ShouldEqual, 0)
+			So(sol.T.FlatItem(last), ShouldEqual, 10)
+			So(sol.Y.Shape(), ShouldResemble, []int{sol.T.Size(), 2})
+			So(sol.Y.Item(last, 0), ShouldAlmostEqual, 100*math.Exp(-5), 1e-6)
+			So(sol.Y.Item(last, 1), ShouldAlmostEqual, math.Exp(-5), 1e-8)
+			So(sol.Steps, ShouldEqual, last)
+			So(sol.Evals, ShouldBeGreaterThan, 6*sol.Steps)
+		})
+
+		Convey("RK45 integrates backward in time", func() {
+			sol, err := RK45(decay, 2, 0, matrix.A1(1), &ODEOptions{RelTol: 1e-8, AbsTol: 1e-10})
+			So(err, ShouldBeNil)
+			So(sol.Y.Item(sol.T.Size()-1, 0), ShouldAlmostEqual, math.E, 1e-7)
+		})
+	})
+
+	Convey("Given an oscillator", t, func() {
+		Convey("RK45 reports the solution at the requested times", func() {
+			times := []float64{0, 1, 2.5, 2.5, 2 * math.Pi}
+			sol, err := RK45(oscillator, 0, 2*math.Pi, matrix.A1(1, 0),
+				&ODEOptions{RelTol: 1e-9, AbsTol: 1e-12, TEval: times})
+			So(err, ShouldBeNil)
+			So(sol.T.Equal(matrix.A1(times...)), ShouldBeTrue)
+			for i, t := range times {
+				So(sol.Y.Item(i, 0), ShouldAlmostEqual, math.Cos(t), 1e-7)
+				So(sol.Y.Item(i, 1), ShouldAlmostEqual, -math.Sin(t), 1e-7)
+			}
+		})
+
+		Convey("MaxStep bounds the step size", func() {
+			sol, err := RK45(oscillator, 0, 1, matrix.A1(1, 0), &ODEOptions{MaxStep: 0.1})
+			So(err, ShouldBeNil)
+			So(sol.Steps, ShouldBeGreaterThanOrEqualTo, 10)
+		})
+
+		Convey("Too many steps is an error", func() {
+			sol, err := RK45(oscillator, 0, 100, matrix.A1(1, 0), &ODEOptions{MaxSteps: 5})
+			So(err, ShouldNotBeNil)
+			So(sol.Steps, ShouldEqual, 5)
+		})
+
+		Convey("A right-hand side which becomes NaN is an error, not a hang", func() {
+			decay := func(t float64, y matrix.NDArray) matrix.NDArray {
+				return matrix.A1(-10 * math.Sqrt(y.FlatItem(0)))
+			}
+			sol, err := RK45(decay, 0, 10, matrix.A1(1), nil)
+			So(err, ShouldNotBeNil)
+			So(sol.T.FlatItem(sol.T.Size()-1), ShouldBeLessThan, 10)
+			_, err = RK45(decay, 0, 10, matrix.A1(1), &ODEOptions{MaxSteps: 50})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Bad arguments panic", func() {
+			So(func() { RK45(oscillator, 0, 0, matrix.A1(1, 0), nil) }, ShouldPanic)
+			So(func() { RK45(oscillator, 0, 1, matrix.Dense(2, 2), nil) }, ShouldPanic)
+			So(func() { RK45(oscillator, 0, 1, matrix.A1(1, 0), &ODEOptions{TEval: []float64{2}}) }, ShouldPanic)
+			So(func() { RK45(oscillator, 0, 1, matrix.A1(1, 0, 0), nil) }, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,247 @@
+// The integrate package integrates sampled data and functions, and solves
+// initial value problems for ordinary differential equations, loosely
+// following scipy.integrate.
+//
+// To integrate samples taken every 0.1 seconds:
+//     total := integrate.Simpson(samples, 0.1, 0)
+//
+// To simulate a decaying compartment from t = 0 to 10:
+//     decay := func(t float64, y matrix.NDArray) matrix.NDArray {
+//             return y.ItemProd(-0.5)
+//     }
+//     sol, err := integrate.RK45(decay, 0, 10, matrix.A1(100), nil)
+package integrate
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"sort"
+)
+
+// Reduce each lane of an array along an axis to a single value. Reducing a
+// 1D array gives shape [1].
+func reduceAxis(y matrix.NDArray, axis int, f func(lane []float64) float64) matrix.NDArray {
+	shape := y.Shape()
+	var outShape []int
+	for d, size := range shape {
+		if d != axis {
+			outShape = append(outShape, size)
+		}
+	}
+	if len(outShape) == 0 {
+		outShape = []int{1}
+	}
+	lane := make([]float64, shape[axis])
+	return matrix.FromFunction(outShape, func(index []int) float64 {
+		base := make([]int, len(shape))
+		for d, j := 0, 0; d < len(shape); d++ {
+			if d != axis {
+				base[d] = index[j]
+				j++
+			}
+		}
+		for k := range lane {
+			pos := make([]int, len(base))
+			copy(pos, base)
+			pos[axis] = k
+			lane[k] = y.Item(pos...)
+		}
+		return f(lane)
+	})
+}
+
+// Get coordinates with uniform spacing
+func uniformCoords(n int, dx float64) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i) * dx
+	}
+	return x
+}
+
+// Get the length of an axis, checking that it exists
+func axisLen(y matrix.NDArray, axis int) int {
+	shape := y.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	return shape[axis]
+}
+
+// Check that there is one coordinate per sample along an axis
+func checkCoords(name string, y matrix.NDArray, x []float64, axis int) {
+	if n := axisLen(y, axis); len(x) != n {
+		panic(fmt.Sprintf("%s got %d coordinates for an axis of length %d", name, len(x), n))
+	}
+}
+
+// Integrate samples along an axis with the trapezoidal rule, where the
+// samples are spaced dx apart. See TrapzCoords.
+func Trapz(y matrix.NDArray, dx float64, axis int) matrix.NDArray {
+	return TrapzCoords(y, uniformCoords(axisLen(y, axis), dx), axis)
+}
+
+// Integrate samples along an axis with the trapezoidal rule, where the
+// samples are taken at the coordinates x. The result has the shape of y
+// without the axis; fewer than two samples integrate to zero.
+func TrapzCoords(y matrix.NDArray, x []float64, axis int) matrix.NDArray {
+	checkCoords("Trapz", y, x, axis)
+	return reduceAxis(y, axis, func(f []float64) float64 {
+		var sum float64
+		for i := 1; i < len(f); i++ {
+			sum += (x[i] - x[i-1]) * (f[i] + f[i-1]) / 2
+		}
+		return sum
+	})
+}
+
+// Integrate samples along an axis with Simpson's rule, where the samples are
+// spaced dx apart. See SimpsonCoords.
+func Simpson(y matrix.NDArray, dx float64, axis int) matrix.NDArray {
+	return SimpsonCoords(y, uniformCoords(axisLen(y, axis), dx), axis)
+}
+
+// Integrate samples along an axis with Simpson's rule, where the samples are
+// taken at the coordinates x, which need not be evenly spaced. With an even
+// number of samples, the last interval is integrated with a quadratic
+// through the last three samples. Two samples use the trapezoidal rule.
+func SimpsonCoords(y matrix.NDArray, x []float64, axis int) matrix.NDArray {
+	checkCoords("Simpson", y, x, axis)
+	return reduceAxis(y, axis, func(f []float64) float64 {
+		n := len(f)
+		if n < 3 {
+			var sum float64
+			if n == 2 {
+				sum = (x[1] - x[0]) * (f[0] + f[1]) / 2
+			}
+			return sum
+		}
+		var sum float64
+		last := n - 1
+		if last%2 == 1 {
+			last--
+		}
+		for i := 0; i < last; i += 2 {
+			h0, h1 := x[i+1]-x[i], x[i+2]-x[i+1]
+			h := h0 + h1
+			sum += h / 6 * ((2-h1/h0)*f[i] + h*h/(h0*h1)*f[i+1] + (2-h0/h1)*f[i+2])
+		}
+		if last < n-1 {
+			h0, h1 := x[n-2]-x[n-3], x[n-1]-x[n-2]
+			alpha := (2*h1*h1 + 3*h0*h1) / (6 * (h0 + h1))
+			beta := (h1*h1 + 3*h0*h1) / (6 * h0)
+			eta := h1 * h1 * h1 / (6 * h0 * (h0 + h1))
+			sum += alpha*f[n-1] + beta*f[n-2] - eta*f[n-3]
+		}
+		return sum
+	})
+}
+
+// The Gauss-Kronrod 7-15 rule: the Kronrod nodes in [0, 1), with their
+// weights and the Gauss weights of every second node
+var (
+	kronrodNodes = []float64{
+		0.991455371120812639206854697526329, 0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926, 0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013, 0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245, 0,
+	}
+	kronrodWeights = []float64{
+		0.022935322010529224963732008058970, 0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518, 0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550, 0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649, 0.209482141084727828012999174891714,
+	}
+	gaussWeights = []float64{
+		0.129484966168869693270611432679082, 0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975, 0.417959183673469387755102040816327,
+	}
+)
+
+// The largest number of intervals Quad will subdivide the domain into
+var QuadMaxIntervals = 2000
+
+// An interval in an adaptive quadrature, with its integral and error
+type quadInterval struct {
+	a, b, value, err float64
+}
+
+// Integrate f over [a, b] with the Gauss-Kronrod 7-15 rule
+func gaussKronrod(f func(float64) float64, a, b float64) quadInterval {
+	center, half := (a+b)/2, (b-a)/2
+	fc := f(center)
+	kronrod := fc * kronrodWeights[7]
+	gauss := fc * gaussWeights[3]
+	for i := 0; i < 7; i++ {
+		dx := half * kronrodNodes[i]
+		sum := f(center-dx) + f(center+dx)
+		kronrod += kronrodWeights[i] * sum
+		if i%2 == 1 {
+			gauss += gaussWeights[i/2] * sum
+		}
+	}
+	return quadInterval{a: a, b: b, value: kronrod * half, err: math.Abs((kronrod - gauss) * half)}
+}
+
+// Integrate a function from a to b, either of which may be infinite, with
+// adaptive Gauss-Kronrod quadrature. The interval with the largest error is
+// bisected until the estimated absolute error is at most tol, or until
+// QuadMaxIntervals is reached. Returns the integral and its estimated error.
+func Quad(f func(float64) float64, a, b, tol float64) (value, errEst float64) {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		panic(fmt.Sprintf("Quad got invalid bounds %v and %v", a, b))
+	} else if a == b {
+		return 0, 0
+	} else if a > b {
+		value, errEst = Quad(f, b, a, tol)
+		return -value, errEst
+	}
+
+	// Map infinite domains onto finite ones
+	g := f
+	switch {
+	case math.IsInf(a, -1) && math.IsInf(b, 1):
+		g = func(t float64) float64 {
+			return f(t/(1-t*t)) * (1 + t*t) / ((1 - t*t) * (1 - t*t))
+		}
+		a, b = -1, 1
+	case math.IsInf(b, 1):
+		lo := a
+		g = func(t float64) float64 {
+			return f(lo+t/(1-t)) / ((1 - t) * (1 - t))
+		}
+		a, b = 0, 1
+	case math.IsInf(a, -1):
+		hi := b
+		g = func(t float64) float64 {
+			return f(hi-(1-t)/t) / (t * t)
+		}
+		a, b = 0, 1
+	}
+
+	// Keep the intervals sorted by increasing error
+	intervals := []quadInterval{gaussKronrod(g, a, b)}
+	value, errEst = intervals[0].value, intervals[0].err
+	for errEst > tol && len(intervals) < QuadMaxIntervals {
+		worst := intervals[len(intervals)-1]
+		intervals = intervals[:len(intervals)-1]
+		mid := (worst.a + worst.b) / 2
+		if mid <= worst.a || mid >= worst.b {
+			intervals = append(intervals, worst)
+			break
+		}
+		for _, part := range []quadInterval{gaussKronrod(g, worst.a, mid), gaussKronrod(g, mid, worst.b)} {
+			i := sort.Search(len(intervals), func(i int) bool { return intervals[i].err >= part.err })
+			intervals = append(intervals, quadInterval{})
+			copy(intervals[i+1:], intervals[i:])
+			intervals[i] = part
+		}
+		value, errEst = 0, 0
+		for _, interval := range intervals {
+			value += interval.value
+			errEst += interval.err
+		}
+	}
+	return value, errEst
+}
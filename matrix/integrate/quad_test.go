@@ -0,0 +1,110 @@
+package integrate
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+const Eps = 1e-9
+
+func TestTrapz(t *testing.T) {
+	Convey("Given samples", t, func() {
+		y := matrix.A2([]float64{1, 2, 3}, []float64{0, 4, 0})
+
+		Convey("Trapz integrates along each axis", func() {
+			So(Trapz(y, 1, 1).Equal(matrix.A1(4, 4)), ShouldBeTrue)
+			So(Trapz(y, 0.5, 0).Equal(matrix.A1(0.25, 1.5, 0.75)), ShouldBeTrue)
+			So(Trapz(matrix.A1(1, 2, 3), 2, 0).Equal(matrix.A1(8)), ShouldBeTrue)
+			So(Trapz(matrix.A1(5), 1, 0).Equal(matrix.A1(0)), ShouldBeTrue)
+		})
+
+		Convey("TrapzCoords uses the given coordinates", func() {
+			So(TrapzCoords(y, []float64{0, 1, 3}, 1).Equal(matrix.A1(6.5, 6)), ShouldBeTrue)
+			So(TrapzCoords(y.M().T(), []float64{0, 1, 3}, 0).Equal(matrix.A1(6.5, 6)), ShouldBeTrue)
+		})
+
+		Convey("Bad axes and coordinates panic", func() {
+			So(func() { Trapz(y, 1, 2) }, ShouldPanic)
+			So(func() { TrapzCoords(y, []float64{0, 1}, 1) }, ShouldPanic)
+		})
+	})
+}
+
+func TestSimpson(t *testing.T) {
+	Convey("Given samples of a cubic", t, func() {
+		f := func(x float64) float64 { return x*x*x - x*x + 2 }
+		x := matrix.Linspace(0, 2, 5, true)
+
+		Convey("Simpson is exact with an odd number of samples", func() {
+			So(Simpson(x.Apply(f), 0.5, 0).FlatItem(0), ShouldAlmostEqual, 4-8.0/3+4, Eps)
+		})
+	})
+
+	Convey("Given irregular samples of a quadratic", t, func() {
+		f := func(x float64) float64 { return 3*x*x - x + 1 }
+		integral := func(a, b float64) float64 {
+			F := func(x float64) float64 { return x*x*x - x*x/2 + x }
+			return F(b) - F(a)
+		}
+
+		Convey("SimpsonCoords is exact for odd and even sample counts", func() {
+			for _, coords := range [][]float64{{0, 0.3, 1, 1.2, 2}, {0, 0.3, 1, 1.2}, {-1, 0, 2}} {
+				y := matrix.A1(coords...).Apply(f)
+				So(SimpsonCoords(y, coords, 0).FlatItem(0), ShouldAlmostEqual,
+					integral(coords[0], coords[len(coords)-1]), Eps)
+			}
+		})
+
+		Convey("Two samples use the trapezoidal rule", func() {
+			So(SimpsonCoords(matrix.A1(1, 3), []float64{0, 2}, 0).FlatItem(0), ShouldEqual, 4)
+		})
+
+		Convey("Simpson works along either axis", func() {
+			y := matrix.A2([]float64{1, 1, 1}, []float64{0, 1, 4})
+			So(matrix.AllClose(Simpson(y, 1, 1), matrix.A1(2, 8.0/3), 0, Eps, false), ShouldBeTrue)
+			So(matrix.AllClose(Simpson(y, 1, 0), matrix.A1(0.5, 1, 2.5), 0, Eps, false), ShouldBeTrue)
+		})
+	})
+}
+
+func TestQuad(t *testing.T) {
+	Convey("Given smooth functions", t, func() {
+		Convey("Quad integrates over finite intervals", func() {
+			value, errEst := Quad(math.Sin, 0, math.Pi, 1e-10)
+			So(value, ShouldAlmostEqual, 2, 1e-10)
+			So(errEst, ShouldBeLessThanOrEqualTo, 1e-10)
+		})
+
+		Convey("Reversed bounds negate the integral", func() {
+			value, _ := Quad(math.Exp, 1, 0, 1e-10)
+			So(value, ShouldAlmostEqual, 1-math.E, 1e-10)
+			value, _ = Quad(math.Exp, 1, 1, 1e-10)
+			So(value, ShouldEqual, 0)
+		})
+
+		Convey("Quad integrates over infinite intervals", func() {
+			gauss := func(x float64) float64 { return math.Exp(-x * x) }
+			value, _ := Quad(gauss, math.Inf(-1), math.Inf(1), 1e-10)
+			So(value, ShouldAlmostEqual, math.Sqrt(math.Pi), 1e-9)
+			value, _ = Quad(gauss, 0, math.Inf(1), 1e-10)
+			So(value, ShouldAlmostEqual, math.Sqrt(math.Pi)/2, 1e-9)
+			value, _ = Quad(math.Exp, math.Inf(-1), 0, 1e-10)
+			So(value, ShouldAlmostEqual, 1, 1e-9)
+		})
+	})
+
+	Convey("Given a function with an integrable singularity", t, func() {
+		f := func(x float64) float64 { return 1 / math.Sqrt(x) }
+
+		Convey("Quad refines near the singularity", func() {
+			value, _ := Quad(f, 0, 1, 1e-8)
+			So(value, ShouldAlmostEqual, 2, 1e-6)
+		})
+	})
+
+	Convey("NaN bounds panic", t, func() {
+		So(func() { Quad(math.Sin, math.NaN(), 1, 1e-8) }, ShouldPanic)
+	})
+}
@@ -0,0 +1,126 @@
+package interp
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// How to interpolate between the points of a Grid
+type GridMethod int
+
+const (
+	// Interpolate linearly along each axis
+	GridBilinear GridMethod = iota
+
+	// Interpolate with cubic Hermite polynomials along each axis, using
+	// derivatives estimated by finite differences
+	GridBicubic
+)
+
+// Values sampled on a rectilinear grid: Z[i, j] is the value at row
+// coordinate Y[i] and column coordinate X[j]. The coordinates must be
+// increasing, but need not be evenly spaced.
+type Grid struct {
+	y, x        []float64
+	z           matrix.Matrix
+	dy, dx, dxy matrix.NDArray
+}
+
+// Create a grid from increasing row and column coordinates and the values at
+// each point. The grid must have at least two rows and columns.
+func NewGrid(y, x matrix.NDArray, z matrix.Matrix) *Grid {
+	if z.Rows() != y.Size() || z.Cols() != x.Size() {
+		panic(fmt.Sprintf("NewGrid got %d row and %d column coordinates for values of shape %v",
+			y.Size(), x.Size(), z.Shape()))
+	}
+	ys, _ := knots("NewGrid", y, y, 2, true)
+	xs, _ := knots("NewGrid", x, x, 2, true)
+	g := &Grid{y: ys, x: xs, z: z.Dense().M()}
+	g.derivatives()
+	return g
+}
+
+// Compute the derivatives used by bicubic interpolation. They are computed
+// up front so that a Grid is safe to share between goroutines.
+func (g *Grid) derivatives() {
+	order := func(n int) int {
+		if n < 3 {
+			return 1
+		}
+		return 2
+	}
+	g.dy = matrix.GradientCoords(g.z, 0, g.y, order(len(g.y)))
+	g.dx = matrix.GradientCoords(g.z, 1, g.x, order(len(g.x)))
+	g.dxy = matrix.GradientCoords(g.dx, 0, g.y, order(len(g.y)))
+}
+
+// Interpolate the grid at a point. Points outside the grid are clamped to
+// its edges.
+func (g *Grid) At(y, x float64, method GridMethod) float64 {
+	if math.IsNaN(y) || math.IsNaN(x) {
+		return math.NaN()
+	}
+	y = math.Max(g.y[0], math.Min(y, g.y[len(g.y)-1]))
+	x = math.Max(g.x[0], math.Min(x, g.x[len(g.x)-1]))
+	i, j := segment(g.y, y), segment(g.x, x)
+	hy, hx := g.y[i+1]-g.y[i], g.x[j+1]-g.x[j]
+	u, t := (y-g.y[i])/hy, (x-g.x[j])/hx
+
+	switch method {
+	case GridBilinear:
+		return (1-u)*(1-t)*g.z.Item(i, j) + (1-u)*t*g.z.Item(i, j+1) +
+			u*(1-t)*g.z.Item(i+1, j) + u*t*g.z.Item(i+1, j+1)
+
+	case GridBicubic:
+		// Hermite basis functions for the value and slope at each end
+		value := func(s float64, end int) float64 {
+			if end == 0 {
+				return (1 + 2*s) * (1 - s) * (1 - s)
+			}
+			return s * s * (3 - 2*s)
+		}
+		slope := func(s float64, end int) float64 {
+			if end == 0 {
+				return s * (1 - s) * (1 - s)
+			}
+			return s * s * (s - 1)
+		}
+		var result float64
+		for a := 0; a < 2; a++ {
+			for b := 0; b < 2; b++ {
+				vy, sy := value(u, a), hy*slope(u, a)
+				vx, sx := value(t, b), hx*slope(t, b)
+				result += vy*vx*g.z.Item(i+a, j+b) + vy*sx*g.dx.Item(i+a, j+b) +
+					sy*vx*g.dy.Item(i+a, j+b) + sy*sx*g.dxy.Item(i+a, j+b)
+			}
+		}
+		return result
+
+	default:
+		panic(fmt.Sprintf("Unknown grid method %d", method))
+	}
+}
+
+// Interpolate the grid at the points (y[k], x[k]). The result is dense and
+// has the same shape as y.
+func (g *Grid) Eval(y, x matrix.NDArray, method GridMethod) matrix.NDArray {
+	if !matrix.ShapeEqual(y, x) {
+		panic(fmt.Sprintf("Grid.Eval got coordinates of shapes %v and %v", y.Shape(), x.Shape()))
+	}
+	result := y.Dense()
+	for k := 0; k < result.Size(); k++ {
+		result.FlatItemSet(g.At(y.FlatItem(k), x.FlatItem(k), method), k)
+	}
+	return result
+}
+
+// Resample the grid onto new row and column coordinates,
+// returning a matrix with one row per element of y and one column per
+// element of x
+func (g *Grid) Resample(y, x matrix.NDArray, method GridMethod) matrix.Matrix {
+	ys, xs := y.Ravel(), x.Ravel()
+	return matrix.FromFunction([]int{ys.Size(), xs.Size()}, func(index []int) float64 {
+		return g.At(ys.FlatItem(index[0]), xs.FlatItem(index[1]), method)
+	}).M()
+}
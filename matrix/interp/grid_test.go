@@ -0,0 +1,91 @@
+package interp
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestGrid(t *testing.T) {
+	Convey("Given a grid sampling a bilinear function", t, func() {
+		f := func(y, x float64) float64 { return 1 + 2*y - x + 0.5*x*y }
+		ys := matrix.A1(0, 1, 3)
+		xs := matrix.A1(-1, 0, 0.5, 2)
+		z := matrix.FromFunction([]int{3, 4}, func(index []int) float64 {
+			return f(ys.FlatItem(index[0]), xs.FlatItem(index[1]))
+		}).M()
+		g := NewGrid(ys, xs, z)
+
+		Convey("Both methods reproduce it inside the grid", func() {
+			for _, method := range []GridMethod{GridBilinear, GridBicubic} {
+				So(g.At(2.2, 1.3, method), ShouldAlmostEqual, f(2.2, 1.3), Eps)
+				So(g.At(0.5, -0.7, method), ShouldAlmostEqual, f(0.5, -0.7), Eps)
+			}
+		})
+
+		Convey("Points outside the grid are clamped", func() {
+			So(g.At(-5, 9, GridBilinear), ShouldAlmostEqual, f(0, 2), Eps)
+			So(math.IsNaN(g.At(math.NaN(), 0, GridBilinear)), ShouldBeTrue)
+		})
+
+		Convey("Eval interpolates at pairs of coordinates", func() {
+			v := g.Eval(matrix.A1(0, 1), matrix.A1(2, 0.25), GridBilinear)
+			So(matrix.AllClose(v, matrix.A1(f(0, 2), f(1, 0.25)), 0, Eps, false), ShouldBeTrue)
+			So(func() { g.Eval(matrix.A1(0, 1), matrix.A1(2), GridBilinear) }, ShouldPanic)
+		})
+
+		Convey("Resample gives a matrix over the new coordinates", func() {
+			m := g.Resample(matrix.A1(0.5, 2.5), matrix.A1(-1, 1, 1.5), GridBilinear)
+			So(m.Shape(), ShouldResemble, []int{2, 3})
+			So(m.Item(1, 2), ShouldAlmostEqual, f(2.5, 1.5), Eps)
+		})
+
+		Convey("Unknown methods panic", func() {
+			So(func() { g.At(0, 0, GridMethod(5)) }, ShouldPanic)
+		})
+
+		Convey("The grid keeps its own copy of the coordinates", func() {
+			ys, xs := ys.Copy(), xs.Copy()
+			g := NewGrid(ys, xs, z)
+			ys.FlatItemSet(-5, 0)
+			xs.FlatItemSet(9, 3)
+			So(g.At(2.2, 1.3, GridBilinear), ShouldAlmostEqual, f(2.2, 1.3), Eps)
+		})
+	})
+
+	Convey("Given a grid sampling a quadratic", t, func() {
+		f := func(y, x float64) float64 { return x*x + x*y - 2*y*y }
+		ys := matrix.Linspace(0, 2, 5, true)
+		xs := matrix.A1(0, 0.5, 1.5, 2, 3)
+		z := matrix.FromFunction([]int{5, 5}, func(index []int) float64 {
+			return f(ys.FlatItem(index[0]), xs.FlatItem(index[1]))
+		}).M()
+		g := NewGrid(ys, xs, z)
+
+		Convey("A grid can be shared between goroutines", func() {
+			g := NewGrid(ys, xs, z)
+			results := make(chan float64, 4)
+			for i := 0; i < 4; i++ {
+				go func() { results <- g.At(0.7, 1.1, GridBicubic) }()
+			}
+			for i := 0; i < 4; i++ {
+				So(<-results, ShouldAlmostEqual, f(0.7, 1.1), 1e-9)
+			}
+		})
+
+		Convey("Bicubic interpolation reproduces it", func() {
+			So(g.At(0.7, 1.1, GridBicubic), ShouldAlmostEqual, f(0.7, 1.1), 1e-9)
+			So(g.At(1.9, 2.6, GridBicubic), ShouldAlmostEqual, f(1.9, 2.6), 1e-9)
+		})
+
+		Convey("Bilinear interpolation only approximates it", func() {
+			So(math.Abs(g.At(0.7, 1.1, GridBilinear)-f(0.7, 1.1)), ShouldBeGreaterThan, 1e-3)
+		})
+	})
+
+	Convey("Mismatched shapes panic", t, func() {
+		So(func() { NewGrid(matrix.A1(0, 1), matrix.A1(0, 1), matrix.Dense(2, 3).M()) }, ShouldPanic)
+		So(func() { NewGrid(matrix.A1(0), matrix.A1(0, 1), matrix.Dense(1, 2).M()) }, ShouldPanic)
+	})
+}
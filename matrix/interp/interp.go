@@ -0,0 +1,100 @@
+// The interp package interpolates between samples of a function, loosely
+// following numpy.interp and scipy.interpolate.
+//
+// To resample an irregular time series onto a regular grid:
+//     grid := matrix.Linspace(0, 60, 61, true)
+//     resampled := interp.Interp(grid, times, readings)
+//
+// To fit a smooth curve through the same samples:
+//     spline := interp.NewCubicSpline(times, readings, interp.SplineNotAKnot, 0, 0)
+//     smooth := spline.Eval(grid)
+package interp
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"sort"
+)
+
+// Get copies of the values of 1D sample points, checking that they are
+// nondecreasing (or increasing, if strict) and match the number of sample
+// values
+func knots(name string, xp, fp matrix.NDArray, minSize int, strict bool) ([]float64, []float64) {
+	if xp.NDim() != 1 || fp.NDim() != 1 {
+		panic(fmt.Sprintf("%s needs 1D sample points, but got shapes %v and %v", name, xp.Shape(), fp.Shape()))
+	} else if xp.Size() != fp.Size() {
+		panic(fmt.Sprintf("%s got %d sample points but %d values", name, xp.Size(), fp.Size()))
+	} else if xp.Size() < minSize {
+		panic(fmt.Sprintf("%s needs at least %d sample points, but got %d", name, minSize, xp.Size()))
+	}
+	x := append([]float64(nil), xp.Array()...)
+	y := append([]float64(nil), fp.Array()...)
+	for i := 1; i < len(x); i++ {
+		if x[i] < x[i-1] || (strict && x[i] == x[i-1]) {
+			panic(fmt.Sprintf("%s needs increasing sample points, but got %v", name, x))
+		}
+	}
+	return x, y
+}
+
+// Find the segment [xp[i], xp[i+1]) containing x, clamped to the valid
+// segments 0 .. len(xp)-2. At a repeated knot, this is the segment after
+// all of the copies.
+func segment(xp []float64, x float64) int {
+	i := sort.Search(len(xp), func(i int) bool { return xp[i] > x }) - 1
+	if i < 0 {
+		return 0
+	} else if i > len(xp)-2 {
+		return len(xp) - 2
+	}
+	return i
+}
+
+// Interpolate linearly between the samples fp at the nondecreasing points xp,
+// evaluating at each element of x. Points below xp[0] or above the last
+// point take the first or last sample value. The result is dense and has the
+// same shape as x.
+func Interp(x, xp, fp matrix.NDArray) matrix.NDArray {
+	xs, ys := knots("Interp", xp, fp, 1, false)
+	n := len(xs)
+	return x.Dense().Apply(func(v float64) float64 {
+		switch {
+		case math.IsNaN(v):
+			return math.NaN()
+		case v <= xs[0]:
+			return ys[0]
+		case v >= xs[n-1]:
+			return ys[n-1]
+		}
+		i := segment(xs, v)
+		if xs[i+1] == xs[i] {
+			return ys[i+1]
+		}
+		t := (v - xs[i]) / (xs[i+1] - xs[i])
+		return ys[i] + t*(ys[i+1]-ys[i])
+	})
+}
+
+// Take the value of the nearest sample in fp at each element of x. Points
+// halfway between two samples take the lower one. The result is dense and
+// has the same shape as x.
+func InterpNearest(x, xp, fp matrix.NDArray) matrix.NDArray {
+	xs, ys := knots("InterpNearest", xp, fp, 1, false)
+	n := len(xs)
+	return x.Dense().Apply(func(v float64) float64 {
+		switch {
+		case math.IsNaN(v):
+			return math.NaN()
+		case v <= xs[0]:
+			return ys[0]
+		case v >= xs[n-1]:
+			return ys[n-1]
+		}
+		i := segment(xs, v)
+		if v-xs[i] <= xs[i+1]-v {
+			return ys[i]
+		}
+		return ys[i+1]
+	})
+}
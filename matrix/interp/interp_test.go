@@ -0,0 +1,52 @@
+package interp
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+const Eps = 1e-9
+
+func TestInterp(t *testing.T) {
+	Convey("Given irregular samples", t, func() {
+		xp := matrix.A1(0, 1, 3, 3, 4)
+		fp := matrix.A1(0, 2, 4, 6, 10)
+
+		Convey("Interp interpolates linearly", func() {
+			y := Interp(matrix.A1(0.5, 2, 3.5, 4), xp, fp)
+			So(matrix.AllClose(y, matrix.A1(1, 3, 8, 10), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Interp takes the value after a repeated knot", func() {
+			y := Interp(matrix.A1(3, 2.999), xp, fp)
+			So(matrix.AllClose(y, matrix.A1(6, 3.999), 0, 1e-9, false), ShouldBeTrue)
+			y = Interp(matrix.A1(0.5, 1, 1.5), matrix.A1(0, 1, 1, 2), matrix.A1(0, 1, 3, 4))
+			So(matrix.AllClose(y, matrix.A1(0.5, 3, 3.5), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Interp clamps outside the samples", func() {
+			y := Interp(matrix.A1(-1, 5), xp, fp)
+			So(y.Equal(matrix.A1(0, 10)), ShouldBeTrue)
+		})
+
+		Convey("Interp keeps the shape of x and propagates NaN", func() {
+			y := Interp(matrix.A2([]float64{0, 1}, []float64{math.NaN(), 2}), xp, fp)
+			So(y.Shape(), ShouldResemble, []int{2, 2})
+			So(y.Item(0, 1), ShouldEqual, 2)
+			So(math.IsNaN(y.Item(1, 0)), ShouldBeTrue)
+		})
+
+		Convey("InterpNearest takes the nearest sample", func() {
+			y := InterpNearest(matrix.A1(-1, 0.4, 0.5, 0.6, 2.5, 9), xp, fp)
+			So(y.Equal(matrix.A1(0, 0, 0, 2, 4, 10)), ShouldBeTrue)
+		})
+
+		Convey("Bad samples panic", func() {
+			So(func() { Interp(xp, matrix.A1(1, 0), matrix.A1(1, 2)) }, ShouldPanic)
+			So(func() { Interp(xp, xp, matrix.A1(1, 2)) }, ShouldPanic)
+			So(func() { Interp(xp, matrix.A1(), matrix.A1()) }, ShouldPanic)
+		})
+	})
+}
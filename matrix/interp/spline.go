@@ -0,0 +1,160 @@
+package interp
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+)
+
+// The end conditions of a cubic spline
+type SplineBoundary int
+
+const (
+	// The second derivative is zero at both ends
+	SplineNatural SplineBoundary = iota
+
+	// The first derivative takes given values at both ends
+	SplineClamped
+
+	// The third derivative is continuous at the second and second-to-last
+	// points, so the first two and last two segments are the same cubic
+	SplineNotAKnot
+)
+
+// A piecewise cubic function through a set of points, with continuous first
+// and second derivatives. Outside the points, the end segments are
+// extrapolated.
+type CubicSpline struct {
+	x    []float64
+	coef [][4]float64
+}
+
+// Fit a cubic spline through the points (x, y), where x is increasing. The
+// slopes at the ends are used only for SplineClamped. With two points, a
+// natural or not-a-knot spline is a line; with three, a not-a-knot spline is
+// a parabola.
+func NewCubicSpline(x, y matrix.NDArray, boundary SplineBoundary, startSlope, endSlope float64) *CubicSpline {
+	xs, ys := knots("NewCubicSpline", x, y, 2, true)
+	n := len(xs)
+	dx := make([]float64, n-1)
+	slope := make([]float64, n-1)
+	for i := range dx {
+		dx[i] = xs[i+1] - xs[i]
+		slope[i] = (ys[i+1] - ys[i]) / dx[i]
+	}
+
+	// Solve for the first derivative s at each point. Interior rows make the
+	// second derivative continuous.
+	var s []float64
+	switch {
+	case boundary == SplineNotAKnot && n == 2:
+		s = []float64{slope[0], slope[0]}
+	case boundary == SplineNotAKnot && n == 3:
+		curve := (slope[1] - slope[0]) / (xs[2] - xs[0])
+		s = []float64{
+			slope[0] - curve*dx[0],
+			slope[0] + curve*dx[0],
+			slope[1] + curve*dx[1],
+		}
+	default:
+		lower := make([]float64, n)
+		diag := make([]float64, n)
+		upper := make([]float64, n)
+		rhs := make([]float64, n)
+		for i := 1; i < n-1; i++ {
+			lower[i] = dx[i]
+			diag[i] = 2 * (dx[i-1] + dx[i])
+			upper[i] = dx[i-1]
+			rhs[i] = 3 * (dx[i]*slope[i-1] + dx[i-1]*slope[i])
+		}
+		switch boundary {
+		case SplineNatural:
+			diag[0], upper[0], rhs[0] = 2, 1, 3*slope[0]
+			lower[n-1], diag[n-1], rhs[n-1] = 1, 2, 3*slope[n-2]
+		case SplineClamped:
+			diag[0], rhs[0] = 1, startSlope
+			diag[n-1], rhs[n-1] = 1, endSlope
+		case SplineNotAKnot:
+			d := xs[2] - xs[0]
+			diag[0], upper[0] = dx[1], d
+			rhs[0] = ((dx[0]+2*d)*dx[1]*slope[0] + dx[0]*dx[0]*slope[1]) / d
+			d = xs[n-1] - xs[n-3]
+			lower[n-1], diag[n-1] = d, dx[n-3]
+			rhs[n-1] = (dx[n-2]*dx[n-2]*slope[n-3] + (2*d+dx[n-2])*dx[n-3]*slope[n-2]) / d
+		default:
+			panic(fmt.Sprintf("Unknown spline boundary %d", boundary))
+		}
+		s = solveTridiagonal(lower, diag, upper, rhs)
+	}
+
+	// Each segment is the cubic Hermite polynomial matching the values and
+	// slopes at its ends
+	spline := &CubicSpline{x: xs, coef: make([][4]float64, n-1)}
+	for i := range spline.coef {
+		spline.coef[i] = [4]float64{
+			ys[i],
+			s[i],
+			(3*slope[i] - 2*s[i] - s[i+1]) / dx[i],
+			(s[i] + s[i+1] - 2*slope[i]) / (dx[i] * dx[i]),
+		}
+	}
+	return spline
+}
+
+// Solve a tridiagonal system with the Thomas algorithm. Row i has lower[i],
+// diag[i] and upper[i] as the coefficients of unknowns i-1, i and i+1. The
+// inputs are overwritten.
+func solveTridiagonal(lower, diag, upper, rhs []float64) []float64 {
+	n := len(diag)
+	for i := 1; i < n; i++ {
+		w := lower[i] / diag[i-1]
+		diag[i] -= w * upper[i-1]
+		rhs[i] -= w * rhs[i-1]
+	}
+	x := make([]float64, n)
+	x[n-1] = rhs[n-1] / diag[n-1]
+	for i := n - 2; i >= 0; i-- {
+		x[i] = (rhs[i] - upper[i]*x[i+1]) / diag[i]
+	}
+	return x
+}
+
+// Evaluate the spline at a point
+func (s *CubicSpline) At(x float64) float64 {
+	return s.DerivativeAt(x, 0)
+}
+
+// Evaluate a derivative of the spline at a point. Derivatives above the
+// third are zero.
+func (s *CubicSpline) DerivativeAt(x float64, order int) float64 {
+	i := segment(s.x, x)
+	c := s.coef[i]
+	t := x - s.x[i]
+	switch order {
+	case 0:
+		return c[0] + t*(c[1]+t*(c[2]+t*c[3]))
+	case 1:
+		return c[1] + t*(2*c[2]+t*3*c[3])
+	case 2:
+		return 2*c[2] + 6*t*c[3]
+	case 3:
+		return 6 * c[3]
+	default:
+		if order < 0 {
+			panic(fmt.Sprintf("Can't take derivative of negative order %d", order))
+		}
+		return 0
+	}
+}
+
+// Evaluate the spline at each element of x. The result is dense and has the
+// same shape as x.
+func (s *CubicSpline) Eval(x matrix.NDArray) matrix.NDArray {
+	return x.Dense().Apply(s.At)
+}
+
+// Evaluate a derivative of the spline at each element of x
+func (s *CubicSpline) Derivative(x matrix.NDArray, order int) matrix.NDArray {
+	return x.Dense().Apply(func(v float64) float64 {
+		return s.DerivativeAt(v, order)
+	})
+}
@@ -0,0 +1,102 @@
+package interp
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestCubicSpline(t *testing.T) {
+	Convey("Given samples of a cubic", t, func() {
+		f := func(x float64) float64 { return x*x*x - 2*x*x + 0.5*x + 1 }
+		df := func(x float64) float64 { return 3*x*x - 4*x + 0.5 }
+		x := matrix.A1(-1, -0.2, 0.5, 1, 2.2, 3)
+		y := x.Apply(f)
+		at := matrix.Linspace(-1.5, 3.5, 21, true)
+
+		Convey("A not-a-knot spline reproduces it exactly", func() {
+			s := NewCubicSpline(x, y, SplineNotAKnot, 0, 0)
+			So(matrix.AllClose(s.Eval(at), at.Apply(f), 0, 1e-9, false), ShouldBeTrue)
+			So(matrix.AllClose(s.Derivative(at, 1), at.Apply(df), 0, 1e-9, false), ShouldBeTrue)
+			So(s.DerivativeAt(0.3, 3), ShouldAlmostEqual, 6, 1e-9)
+			So(s.DerivativeAt(0.3, 4), ShouldEqual, 0)
+		})
+
+		Convey("A clamped spline with the true slopes reproduces it exactly", func() {
+			s := NewCubicSpline(x, y, SplineClamped, df(-1), df(3))
+			So(matrix.AllClose(s.Eval(at), at.Apply(f), 0, 1e-9, false), ShouldBeTrue)
+		})
+
+		Convey("A natural spline interpolates with zero end curvature", func() {
+			s := NewCubicSpline(x, y, SplineNatural, 0, 0)
+			So(matrix.AllClose(s.Eval(x), y, 0, 1e-9, false), ShouldBeTrue)
+			So(s.DerivativeAt(-1, 2), ShouldAlmostEqual, 0, 1e-9)
+			So(s.DerivativeAt(3, 2), ShouldAlmostEqual, 0, 1e-9)
+
+			// The second derivative is continuous at the knots
+			for _, knot := range []float64{-0.2, 0.5, 1, 2.2} {
+				So(s.DerivativeAt(knot-1e-9, 2), ShouldAlmostEqual, s.DerivativeAt(knot, 2), 1e-6)
+			}
+		})
+
+		Convey("Negative derivative orders panic", func() {
+			s := NewCubicSpline(x, y, SplineNatural, 0, 0)
+			So(func() { s.DerivativeAt(0, -1) }, ShouldPanic)
+		})
+
+		Convey("The spline keeps its own copy of the samples", func() {
+			x, y := x.Copy(), y.Copy()
+			s := NewCubicSpline(x, y, SplineNotAKnot, 0, 0)
+			x.FlatItemSet(-5, 0)
+			y.FlatItemSet(100, 1)
+			So(matrix.AllClose(s.Eval(at), at.Apply(f), 0, 1e-9, false), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given few samples", t, func() {
+		Convey("Two points give a line", func() {
+			s := NewCubicSpline(matrix.A1(0, 2), matrix.A1(1, 5), SplineNotAKnot, 0, 0)
+			So(s.At(1), ShouldAlmostEqual, 3, Eps)
+			s = NewCubicSpline(matrix.A1(0, 2), matrix.A1(1, 5), SplineNatural, 0, 0)
+			So(s.At(3), ShouldAlmostEqual, 7, Eps)
+		})
+
+		Convey("Three points give a parabola when not-a-knot", func() {
+			s := NewCubicSpline(matrix.A1(0, 1, 3), matrix.A1(0, 1, 9), SplineNotAKnot, 0, 0)
+			So(s.At(2), ShouldAlmostEqual, 4, Eps)
+			So(s.At(-1), ShouldAlmostEqual, 1, Eps)
+		})
+
+		Convey("Bad inputs panic", func() {
+			So(func() { NewCubicSpline(matrix.A1(0), matrix.A1(1), SplineNatural, 0, 0) }, ShouldPanic)
+			So(func() { NewCubicSpline(matrix.A1(0, 0), matrix.A1(1, 2), SplineNatural, 0, 0) }, ShouldPanic)
+			So(func() {
+				NewCubicSpline(matrix.A1(0, 1, 2), matrix.A1(1, 2, 3), SplineBoundary(9), 0, 0)
+			}, ShouldPanic)
+		})
+	})
+
+	Convey("Given a smooth function", t, func() {
+		x := matrix.Linspace(0, math.Pi, 20, true)
+		s := NewCubicSpline(x, x.Apply(math.Sin), SplineNotAKnot, 0, 0)
+
+		Convey("The spline is accurate between samples", func() {
+			at := matrix.Linspace(0, math.Pi, 97, true)
+			So(matrix.AllClose(s.Eval(at), at.Apply(math.Sin), 0, 1e-5, false), ShouldBeTrue)
+		})
+	})
+}
+
+func TestSolveTridiagonal(t *testing.T) {
+	Convey("Given a tridiagonal system", t, func() {
+		x := solveTridiagonal([]float64{0, 1, 1}, []float64{4, 4, 4}, []float64{1, 1, 0},
+			[]float64{6, 12, 14})
+
+		Convey("The solution satisfies it", func() {
+			So(x[0], ShouldAlmostEqual, 1, Eps)
+			So(x[1], ShouldAlmostEqual, 2, Eps)
+			So(x[2], ShouldAlmostEqual, 3, Eps)
+		})
+	})
+}
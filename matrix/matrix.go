@@ -143,53 +143,21 @@ func SparseDiag(rows, cols int, diag ...float64) Matrix {
 	return array
 }
 
-// Create a sparse coo matrix, randomly populated so that approximately
-// density * rows * cols cells are filled with random values uniformly
-// distributed in [0,1). Note that if density is close to 1, this function may
-// be extremely slow.
+// Create a sparse coo matrix, randomly populated so that int(density * rows *
+// cols) cells are filled with random values uniformly distributed in [0,1).
+// See SparseRandOpts for more control over the values and pattern.
 func SparseRand(rows, cols int, density float64) Matrix {
-	if density < 0 || density >= 1 {
-		panic(fmt.Sprintf("Can't create a SparseRand matrix: density %f should be in [0, 1)", density))
-	}
-	matrix := SparseCoo(rows, cols)
-	shape := []int{rows, cols}
-	size := rows * cols
-	count := int(float64(size) * density)
-	for i := 0; i < count; i++ {
-		for {
-			coord := flatToNd(shape, rand.Intn(size))
-			if matrix.Item(coord...) == 0 {
-				matrix.ItemSet(rand.Float64(), coord...)
-				break
-			}
-		}
-	}
-	return matrix
+	return SparseRandOpts(rows, cols, density, SparseRandOptions{})
 }
 
-// Create a sparse coo matrix, randomly populated so that approximately
-// density * rows * cols cells are filled with random values in the range
+// Create a sparse coo matrix, randomly populated so that int(density * rows *
+// cols) cells are filled with random values in the range
 // [-math.MaxFloat64, +math.MaxFloat64] distributed on the standard Normal
-// distribution.  Note that if density is close to 1, this function may
-// be extremely slow.
+// distribution.
 func SparseRandN(rows, cols int, density float64) Matrix {
-	if density < 0 || density >= 1 {
-		panic(fmt.Sprintf("Can't create a SparseRandN matrix: density %f should be in [0, 1)", density))
-	}
-	matrix := SparseCoo(rows, cols)
-	shape := []int{rows, cols}
-	size := rows * cols
-	count := int(float64(size) * density)
-	for i := 0; i < count; i++ {
-		for {
-			coord := flatToNd(shape, rand.Intn(size))
-			if matrix.Item(coord...) == 0 {
-				matrix.ItemSet(rand.NormFloat64(), coord...)
-				break
-			}
-		}
-	}
-	return matrix
+	return SparseRandOpts(rows, cols, density, SparseRandOptions{
+		Values: rand.NormFloat64,
+	})
 }
 
 // Convert our matrix type to mat64's matrix type
@@ -246,7 +246,7 @@ func TestSparseDiag(t *testing.T) {
 func TestSparseRand(t *testing.T) {
 	Convey("SparseRand panics with an invalid density", t, func() {
 		So(func() { SparseRand(2, 3, -1) }, ShouldPanic)
-		So(func() { SparseRand(2, 3, 1) }, ShouldPanic)
+		So(func() { SparseRand(2, 3, 1.5) }, ShouldPanic)
 	})
 
 	Convey("Given a sparse random array with shape 2, 3 and density 0.5", t, func() {
@@ -269,7 +269,7 @@ func TestSparseRand(t *testing.T) {
 func TestSparseRandN(t *testing.T) {
 	Convey("SparseRandN panics with an invalid density", t, func() {
 		So(func() { SparseRandN(2, 3, -1) }, ShouldPanic)
-		So(func() { SparseRandN(2, 3, 1) }, ShouldPanic)
+		So(func() { SparseRandN(2, 3, 1.5) }, ShouldPanic)
 	})
 
 	Convey("Given a sparse random array with shape 2, 3 and density 0.5", t, func() {
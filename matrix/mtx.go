@@ -0,0 +1,248 @@
+package matrix
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The banner which begins every Matrix Market file
+const mtxBanner = "%%MatrixMarket"
+
+// The header fields of a Matrix Market file
+type mtxHeader struct {
+	format   string
+	field    string
+	symmetry string
+}
+
+// Parse the banner line of a Matrix Market file
+func parseMtxHeader(line string) (*mtxHeader, error) {
+	fields := strings.Fields(strings.ToLower(line))
+	if len(fields) != 5 || fields[0] != strings.ToLower(mtxBanner) {
+		return nil, fmt.Errorf("Invalid Matrix Market banner %q", line)
+	} else if fields[1] != "matrix" {
+		return nil, fmt.Errorf("Unsupported Matrix Market object %q", fields[1])
+	}
+	header := &mtxHeader{
+		format:   fields[2],
+		field:    fields[3],
+		symmetry: fields[4],
+	}
+	switch header.format {
+	case "coordinate", "array":
+	default:
+		return nil, fmt.Errorf("Unsupported Matrix Market format %q", header.format)
+	}
+	switch header.field {
+	case "real", "integer":
+	case "pattern":
+		if header.format == "array" {
+			return nil, fmt.Errorf("Matrix Market array files can't have pattern fields")
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported Matrix Market field %q", header.field)
+	}
+	switch header.symmetry {
+	case "general", "symmetric", "skew-symmetric":
+	default:
+		return nil, fmt.Errorf("Unsupported Matrix Market symmetry %q", header.symmetry)
+	}
+	return header, nil
+}
+
+// Read the next line which is neither blank nor a comment
+func nextMtxLine(scanner *bufio.Scanner) ([]string, error) {
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") {
+			continue
+		}
+		return strings.Fields(line), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+// Parse a list of integers from a Matrix Market line
+func parseMtxInts(fields []string, count int) ([]int, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("Expected %d integers in Matrix Market line %v", count, fields)
+	}
+	result := make([]int, count)
+	for i := 0; i < count; i++ {
+		v, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid integer %q in Matrix Market file", fields[i])
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+// Read a matrix from a Matrix Market (.mtx) stream. Files in coordinate
+// format are read as sparse coo matrices, and files in array format are read as
+// dense matrices. The real, integer and pattern fields are supported, with the
+// general, symmetric and skew-symmetric qualifiers. Pattern entries are read
+// as ones.
+func ReadMatrixMarket(r io.Reader) (Matrix, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Empty Matrix Market file")
+	}
+	header, err := parseMtxHeader(scanner.Text())
+	if err != nil {
+		return nil, err
+	}
+
+	sizeLine, err := nextMtxLine(scanner)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read Matrix Market size line: %v", err)
+	}
+	var size []int
+	if header.format == "coordinate" {
+		size, err = parseMtxInts(sizeLine, 3)
+	} else {
+		size, err = parseMtxInts(sizeLine, 2)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := size[0], size[1]
+	if rows < 0 || cols < 0 {
+		return nil, fmt.Errorf("Invalid Matrix Market size %dx%d", rows, cols)
+	} else if header.symmetry != "general" && rows != cols {
+		return nil, fmt.Errorf("A %s Matrix Market matrix must be square, not %dx%d", header.symmetry, rows, cols)
+	}
+
+	var result Matrix
+	if header.format == "coordinate" {
+		result = SparseCoo(rows, cols)
+	} else {
+		result = Dense(rows, cols).M()
+	}
+	set := func(row, col int, value float64) {
+		result.ItemSet(value, row, col)
+		if row != col {
+			switch header.symmetry {
+			case "symmetric":
+				result.ItemSet(value, col, row)
+			case "skew-symmetric":
+				result.ItemSet(-value, col, row)
+			}
+		}
+	}
+	parseValue := func(field string) (float64, error) {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid value %q in Matrix Market file", field)
+		}
+		return v, nil
+	}
+
+	if header.format == "coordinate" {
+		for entry := 0; entry < size[2]; entry++ {
+			line, err := nextMtxLine(scanner)
+			if err != nil {
+				return nil, fmt.Errorf("Can't read Matrix Market entry %d: %v", entry+1, err)
+			}
+			index, err := parseMtxInts(line, 2)
+			if err != nil {
+				return nil, err
+			}
+			row, col := index[0]-1, index[1]-1
+			if row < 0 || row >= rows || col < 0 || col >= cols {
+				return nil, fmt.Errorf("Matrix Market entry (%d, %d) is outside a %dx%d matrix", index[0], index[1], rows, cols)
+			}
+			value := 1.0
+			if header.field != "pattern" {
+				if len(line) < 3 {
+					return nil, fmt.Errorf("Matrix Market entry %d has no value", entry+1)
+				}
+				if value, err = parseValue(line[2]); err != nil {
+					return nil, err
+				}
+			}
+			set(row, col, value)
+		}
+	} else {
+		// Array entries are listed in column-major order. For symmetric
+		// matrices only the lower triangle is given, and skew-symmetric
+		// matrices also omit the diagonal.
+		for col := 0; col < cols; col++ {
+			start := 0
+			switch header.symmetry {
+			case "symmetric":
+				start = col
+			case "skew-symmetric":
+				start = col + 1
+			}
+			for row := start; row < rows; row++ {
+				line, err := nextMtxLine(scanner)
+				if err != nil {
+					return nil, fmt.Errorf("Can't read Matrix Market entry (%d, %d): %v", row+1, col+1, err)
+				}
+				value, err := parseValue(line[0])
+				if err != nil {
+					return nil, err
+				}
+				set(row, col, value)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Write a matrix to a Matrix Market (.mtx) stream. Sparse matrices are
+// written in coordinate format with just their nonzero entries, and dense
+// matrices are written in array format. All matrices are written with the
+// real field and the general qualifier.
+func WriteMatrixMarket(w io.Writer, m Matrix) error {
+	bw := bufio.NewWriter(w)
+	rows, cols := m.Rows(), m.Cols()
+	formatValue := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	if m.Sparsity() == DenseArray {
+		fmt.Fprintf(bw, "%s matrix array real general\n", mtxBanner)
+		fmt.Fprintf(bw, "%d %d\n", rows, cols)
+		for col := 0; col < cols; col++ {
+			for row := 0; row < rows; row++ {
+				fmt.Fprintln(bw, formatValue(m.Item(row, col)))
+			}
+		}
+	} else {
+		type entry struct {
+			row, col int
+			value    float64
+		}
+		entries := make([]entry, 0, m.CountNonzero())
+		m.VisitNonzero(func(pos []int, value float64) bool {
+			entries = append(entries, entry{pos[0], pos[1], value})
+			return true
+		})
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].col != entries[j].col {
+				return entries[i].col < entries[j].col
+			}
+			return entries[i].row < entries[j].row
+		})
+
+		fmt.Fprintf(bw, "%s matrix coordinate real general\n", mtxBanner)
+		fmt.Fprintf(bw, "%d %d %d\n", rows, cols, len(entries))
+		for _, e := range entries {
+			fmt.Fprintf(bw, "%d %d %s\n", e.row+1, e.col+1, formatValue(e.value))
+		}
+	}
+	return bw.Flush()
+}
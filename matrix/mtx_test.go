@@ -0,0 +1,191 @@
+package matrix
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+)
+
+func TestReadMatrixMarket(t *testing.T) {
+	Convey("ReadMatrixMarket reads a general coordinate file", t, func() {
+		m, err := ReadMatrixMarket(strings.NewReader(`%%MatrixMarket matrix coordinate real general
+% A comment
+3 4 3
+
+1 1 1.5
+2 4 -2
+3 2 3e2
+`))
+		So(err, ShouldBeNil)
+		So(m.Sparsity(), ShouldEqual, SparseCooMatrix)
+		So(m.Shape(), ShouldResemble, []int{3, 4})
+		So(m.Array(), ShouldResemble, []float64{
+			1.5, 0, 0, 0,
+			0, 0, 0, -2,
+			0, 300, 0, 0,
+		})
+	})
+
+	Convey("ReadMatrixMarket reads a symmetric integer coordinate file", t, func() {
+		m, err := ReadMatrixMarket(strings.NewReader(`%%MatrixMarket matrix coordinate integer symmetric
+3 3 3
+1 1 4
+3 1 2
+3 2 5
+`))
+		So(err, ShouldBeNil)
+		So(m.Array(), ShouldResemble, []float64{
+			4, 0, 2,
+			0, 0, 5,
+			2, 5, 0,
+		})
+	})
+
+	Convey("ReadMatrixMarket reads a skew-symmetric pattern file", t, func() {
+		m, err := ReadMatrixMarket(strings.NewReader(`%%MatrixMarket matrix coordinate pattern skew-symmetric
+2 2 1
+2 1
+`))
+		So(err, ShouldBeNil)
+		So(m.Array(), ShouldResemble, []float64{
+			0, -1,
+			1, 0,
+		})
+	})
+
+	Convey("ReadMatrixMarket reads a general array file", t, func() {
+		m, err := ReadMatrixMarket(strings.NewReader(`%%MatrixMarket matrix array real general
+2 3
+1
+4
+2
+5
+3
+6
+`))
+		So(err, ShouldBeNil)
+		So(m.Sparsity(), ShouldEqual, DenseArray)
+		So(m.Array(), ShouldResemble, []float64{
+			1, 2, 3,
+			4, 5, 6,
+		})
+	})
+
+	Convey("ReadMatrixMarket reads symmetric and skew-symmetric array files", t, func() {
+		m, err := ReadMatrixMarket(strings.NewReader(`%%MatrixMarket matrix array real symmetric
+2 2
+1
+2
+3
+`))
+		So(err, ShouldBeNil)
+		So(m.Array(), ShouldResemble, []float64{
+			1, 2,
+			2, 3,
+		})
+
+		m, err = ReadMatrixMarket(strings.NewReader(`%%MatrixMarket matrix array real skew-symmetric
+3 3
+1
+2
+3
+`))
+		So(err, ShouldBeNil)
+		So(m.Array(), ShouldResemble, []float64{
+			0, -1, -2,
+			1, 0, -3,
+			2, 3, 0,
+		})
+	})
+
+	Convey("ReadMatrixMarket reports errors", t, func() {
+		for _, input := range []string{
+			"",
+			"%%MatrixMarket vector coordinate real general\n1 1 0\n",
+			"%%MatrixMarket matrix coordinate complex general\n1 1 0\n",
+			"%%MatrixMarket matrix array pattern general\n1 1\n",
+			"%%MatrixMarket matrix coordinate real hermitian\n1 1 0\n",
+			"%%MatrixMarket matrix coordinate real symmetric\n2 3 0\n",
+			"%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n",
+			"%%MatrixMarket matrix coordinate real general\n2 2 1\n3 1 1\n",
+			"%%MatrixMarket matrix coordinate real general\n2 2 1\n1 1 x\n",
+			"%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n",
+		} {
+			_, err := ReadMatrixMarket(strings.NewReader(input))
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestWriteMatrixMarket(t *testing.T) {
+	Convey("Given a sparse coo matrix", t, func() {
+		m := SparseCoo(2, 3,
+			0, 1.5, 0,
+			-2, 0, 3)
+
+		Convey("WriteMatrixMarket writes coordinate format", func() {
+			var buf bytes.Buffer
+			So(WriteMatrixMarket(&buf, m), ShouldBeNil)
+			So(buf.String(), ShouldEqual, `%%MatrixMarket matrix coordinate real general
+2 3 3
+2 1 -2
+1 2 1.5
+2 3 3
+`)
+		})
+
+		Convey("The matrix survives a round trip", func() {
+			var buf bytes.Buffer
+			So(WriteMatrixMarket(&buf, m), ShouldBeNil)
+			m2, err := ReadMatrixMarket(&buf)
+			So(err, ShouldBeNil)
+			So(m2.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(m2.Equal(m), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a sparse diagonal matrix", t, func() {
+		m := Diag(1, 2)
+
+		Convey("WriteMatrixMarket writes coordinate format", func() {
+			var buf bytes.Buffer
+			So(WriteMatrixMarket(&buf, m), ShouldBeNil)
+			So(buf.String(), ShouldEqual, `%%MatrixMarket matrix coordinate real general
+2 2 2
+1 1 1
+2 2 2
+`)
+		})
+	})
+
+	Convey("Given a dense matrix", t, func() {
+		m := M(2, 2,
+			1, 2,
+			3, 4.25)
+
+		Convey("WriteMatrixMarket writes array format", func() {
+			var buf bytes.Buffer
+			So(WriteMatrixMarket(&buf, m), ShouldBeNil)
+			So(buf.String(), ShouldEqual, `%%MatrixMarket matrix array real general
+2 2
+1
+3
+2
+4.25
+`)
+		})
+
+		Convey("The transposed matrix survives a round trip", func() {
+			var buf bytes.Buffer
+			So(WriteMatrixMarket(&buf, m.T()), ShouldBeNil)
+			m2, err := ReadMatrixMarket(&buf)
+			So(err, ShouldBeNil)
+			So(m2.Sparsity(), ShouldEqual, DenseArray)
+			So(m2.Array(), ShouldResemble, []float64{
+				1, 3,
+				2, 4.25,
+			})
+		})
+	})
+}
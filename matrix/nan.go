@@ -0,0 +1,119 @@
+package matrix
+
+import (
+	"math"
+)
+
+// Return the sum of all array elements, treating NaN as zero
+func NanSum(array NDArray) float64 {
+	var result float64
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		if !math.IsNaN(value) {
+			result += value
+		}
+		return true
+	})
+	return result
+}
+
+// Get the arithmetic mean of the array elements which are not NaN. Returns
+// NaN if every element is NaN.
+func NanMean(array NDArray) float64 {
+	var sum float64
+	nans := 0
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		if math.IsNaN(value) {
+			nans++
+		} else {
+			sum += value
+		}
+		return true
+	})
+	if nans == array.Size() {
+		return math.NaN()
+	}
+	return sum / float64(array.Size()-nans)
+}
+
+// Get the value of the largest array element, ignoring NaN. Returns NaN if
+// every element is NaN.
+func NanMax(array NDArray) float64 {
+	max := math.Inf(-1)
+	counted, nans := 0, 0
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		counted++
+		if math.IsNaN(value) {
+			nans++
+		} else if value > max {
+			max = value
+		}
+		return true
+	})
+	if nans == array.Size() {
+		return math.NaN()
+	}
+	if max < 0 && counted < array.Size() {
+		max = 0
+	}
+	return max
+}
+
+// Get the value of the smallest array element, ignoring NaN. Returns NaN if
+// every element is NaN.
+func NanMin(array NDArray) float64 {
+	min := math.Inf(+1)
+	counted, nans := 0, 0
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		counted++
+		if math.IsNaN(value) {
+			nans++
+		} else if value < min {
+			min = value
+		}
+		return true
+	})
+	if nans == array.Size() {
+		return math.NaN()
+	}
+	if min > 0 && counted < array.Size() {
+		min = 0
+	}
+	return min
+}
+
+// Get the flat index of the largest array element, ignoring NaN. If several
+// elements share the largest value, the first is chosen. Use FlatCoord() to
+// convert the result to coordinates. Panics if every element is NaN.
+func NanArgMax(array NDArray) int {
+	shape := array.Shape()
+	max := NanMax(array)
+	if math.IsNaN(max) {
+		panic("NanArgMax: all elements are NaN")
+	}
+	if max == 0 {
+		// The first zero may be implied by a sparse array. Sparse diag arrays
+		// also visit zeros on the diagonal, so check each value.
+		nonzero := make(map[int]bool)
+		array.VisitNonzero(func(pos []int, value float64) bool {
+			if value != 0 {
+				nonzero[ndToFlat(shape, pos)] = true
+			}
+			return true
+		})
+		for i := 0; i < array.Size(); i++ {
+			if !nonzero[i] {
+				return i
+			}
+		}
+	}
+	best := -1
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		if value == max {
+			if i := ndToFlat(shape, pos); best < 0 || i < best {
+				best = i
+			}
+		}
+		return true
+	})
+	return best
+}
@@ -0,0 +1,67 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestNanReductions(t *testing.T) {
+	nan := math.NaN()
+
+	Convey("Given a dense array with NaN values", t, func() {
+		a := A1(2, nan, -1, 5, nan, 5)
+
+		Convey("NaN values are ignored", func() {
+			So(NanSum(a), ShouldEqual, 11)
+			So(NanMean(a), ShouldEqual, 2.75)
+			So(NanMax(a), ShouldEqual, 5)
+			So(NanMin(a), ShouldEqual, -1)
+			So(NanArgMax(a), ShouldEqual, 3)
+		})
+	})
+
+	Convey("Given an array with implied zeros", t, func() {
+		m := SparseCoo(2, 3,
+			-1, nan, 0,
+			-2, 0, -3)
+
+		Convey("Zeros take part in the reductions", func() {
+			So(NanSum(m), ShouldEqual, -6)
+			So(NanMean(m), ShouldEqual, -1.2)
+			So(NanMax(m), ShouldEqual, 0)
+			So(NanMin(m), ShouldEqual, -3)
+			So(NanArgMax(m), ShouldEqual, 2)
+		})
+
+		Convey("Transposed matrices use logical flat indices", func() {
+			So(NanArgMax(m.T()), ShouldEqual, 3)
+			So(NanArgMax(M(2, 2, 1, 3, 3, 2).T()), ShouldEqual, 1)
+		})
+
+		Convey("Positive values are found in sparse matrices", func() {
+			So(NanMin(SparseCoo(2, 2, 0, nan, 4, 0)), ShouldEqual, 0)
+			So(NanArgMax(SparseCoo(2, 2, 0, nan, 4, 0)), ShouldEqual, 2)
+		})
+
+		Convey("Zeros on a sparse diagonal are found", func() {
+			So(NanArgMax(Diag(0, -1)), ShouldEqual, 0)
+			So(NanArgMax(Diag(-1, 0)), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given an array of all NaN", t, func() {
+		a := A1(nan, nan)
+
+		Convey("Reductions return NaN, or zero for NanSum", func() {
+			So(NanSum(a), ShouldEqual, 0)
+			So(math.IsNaN(NanMean(a)), ShouldBeTrue)
+			So(math.IsNaN(NanMax(a)), ShouldBeTrue)
+			So(math.IsNaN(NanMin(a)), ShouldBeTrue)
+		})
+
+		Convey("NanArgMax panics", func() {
+			So(func() { NanArgMax(a) }, ShouldPanic)
+		})
+	})
+}
@@ -38,6 +38,10 @@
 // To create a 2x3 array with random values on the standard normal distribution:
 //     a8 := RandN(2, 3)
 //
+// To create 1D arrays of evenly spaced values, use one of:
+//     a9 := Arange(0, 1, 0.25)
+//     a10 := Linspace(0, 1, 5, true)
+//
 // Matrix
 //
 // The Matrix interface describes operations suited to a two-dimensional array.
@@ -75,6 +79,13 @@
 // To create a 3x4 sparse coo with half the items randomly populated:
 //     m5 := SparseRand(3, 4, 0.5)
 //     m6 := SparseRandN(3, 4, 0.5)
+//
+// To create a 5x5 banded matrix with the main diagonal and its neighbors
+// populated:
+//     m7 := SparseRandOpts(5, 5, 1, SparseRandOptions{
+//             Pattern:   SparsePatternBanded,
+//             Bandwidth: 1,
+//     })
 package matrix
 
 import (
@@ -219,6 +230,11 @@ type NDArray interface {
 	// Ask whether the matrix has a sparse representation (useful for optimization)
 	Sparsity() ArraySparsity
 
+	// Get a string representation of the array. Dense arrays are formatted
+	// with nested brackets, as NumPy does, and sparse matrices list their
+	// nonzero values. See PrintOpts for display options.
+	String() string
+
 	// Return the element-wise difference of this array and one or more others
 	Sub(others ...NDArray) NDArray
 
@@ -0,0 +1,555 @@
+package matrix
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The magic string which begins every .npy file
+const npyMagic = "\x93NUMPY"
+
+var (
+	npyDescrRe   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortranRe = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeRe   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// The parsed header of a .npy file, along with its raw data
+type npyArray struct {
+	order   binary.ByteOrder
+	kind    byte
+	width   int
+	fortran bool
+	shape   []int
+	data    []byte
+}
+
+// The number of elements in the array
+func (npy npyArray) size() int {
+	size := 1
+	for _, v := range npy.shape {
+		size *= v
+	}
+	return size
+}
+
+// Decode the array data as float64 values in 'C' order
+func (npy npyArray) floats() ([]float64, error) {
+	size := npy.size()
+	values := make([]float64, size)
+	for i := 0; i < size; i++ {
+		b := npy.data[i*npy.width : (i+1)*npy.width]
+		switch {
+		case npy.kind == 'f' && npy.width == 8:
+			values[i] = math.Float64frombits(npy.order.Uint64(b))
+		case npy.kind == 'f' && npy.width == 4:
+			values[i] = float64(math.Float32frombits(npy.order.Uint32(b)))
+		case npy.kind == 'i' && npy.width == 8:
+			values[i] = float64(int64(npy.order.Uint64(b)))
+		case npy.kind == 'i' && npy.width == 4:
+			values[i] = float64(int32(npy.order.Uint32(b)))
+		case npy.kind == 'i' && npy.width == 2:
+			values[i] = float64(int16(npy.order.Uint16(b)))
+		case npy.kind == 'i' && npy.width == 1:
+			values[i] = float64(int8(b[0]))
+		case npy.kind == 'u' && npy.width == 8:
+			values[i] = float64(npy.order.Uint64(b))
+		case npy.kind == 'u' && npy.width == 4:
+			values[i] = float64(npy.order.Uint32(b))
+		case npy.kind == 'u' && npy.width == 2:
+			values[i] = float64(npy.order.Uint16(b))
+		case (npy.kind == 'u' || npy.kind == 'b') && npy.width == 1:
+			values[i] = float64(b[0])
+		default:
+			return nil, fmt.Errorf("Can't read .npy data of type %c%d as numbers", npy.kind, npy.width)
+		}
+	}
+	if npy.fortran && len(npy.shape) > 1 {
+		values = fortranToC(npy.shape, values)
+	}
+	return values, nil
+}
+
+// Decode a 0-d or 1-d array of byte or unicode strings into a single string
+func (npy npyArray) str() (string, error) {
+	switch npy.kind {
+	case 'S':
+		return strings.TrimRight(string(npy.data), "\x00"), nil
+	case 'U':
+		var buf bytes.Buffer
+		for i := 0; i+4 <= len(npy.data); i += 4 {
+			r := rune(npy.order.Uint32(npy.data[i : i+4]))
+			if r == 0 {
+				break
+			}
+			buf.WriteRune(r)
+		}
+		return buf.String(), nil
+	default:
+		return "", fmt.Errorf("Can't read .npy data of type %c%d as a string", npy.kind, npy.width)
+	}
+}
+
+// Reorder values stored in 'F' order (leftmost axes change fastest) into 'C'
+// order (rightmost axes change fastest)
+func fortranToC(shape []int, values []float64) []float64 {
+	result := make([]float64, len(values))
+	rev := make([]int, len(shape))
+	for i := range shape {
+		rev[i] = shape[len(shape)-1-i]
+	}
+	fIndex := make([]int, len(shape))
+	for flat := range result {
+		index := flatToNd(shape, flat)
+		for i := range index {
+			fIndex[i] = index[len(index)-1-i]
+		}
+		result[flat] = values[ndToFlat(rev, fIndex)]
+	}
+	return result
+}
+
+// Parse a .npy stream
+func readNpy(r io.Reader) (*npyArray, error) {
+	br := bufio.NewReader(r)
+	preamble := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(br, preamble); err != nil {
+		return nil, fmt.Errorf("Can't read .npy preamble: %v", err)
+	} else if string(preamble[:len(npyMagic)]) != npyMagic {
+		return nil, fmt.Errorf("Not a .npy file: bad magic string %q", preamble[:len(npyMagic)])
+	}
+
+	var headerLen int
+	switch major := preamble[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("Can't read .npy header length: %v", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("Can't read .npy header length: %v", err)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("Unsupported .npy format version %d", major)
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("Can't read .npy header: %v", err)
+	}
+
+	npy, err := parseNpyHeader(string(header))
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the data as it arrives rather than allocating it all up front, so
+	// a header can't claim more data than the stream holds
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, br, int64(npy.size()*npy.width)); err != nil {
+		return nil, fmt.Errorf("Can't read .npy data: %v", err)
+	}
+	npy.data = data.Bytes()
+	return npy, nil
+}
+
+// Parse the Python dict literal which describes a .npy array
+func parseNpyHeader(header string) (*npyArray, error) {
+	descr := npyDescrRe.FindStringSubmatch(header)
+	fortran := npyFortranRe.FindStringSubmatch(header)
+	shape := npyShapeRe.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shape == nil {
+		return nil, fmt.Errorf("Can't parse .npy header %q", header)
+	}
+
+	npy := &npyArray{
+		fortran: fortran[1] == "True",
+		shape:   []int{},
+	}
+	for _, dim := range strings.Split(shape[1], ",") {
+		dim = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(dim), "L"))
+		if dim == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dim)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Can't parse .npy shape %q", shape[1])
+		}
+		npy.shape = append(npy.shape, n)
+	}
+
+	d := descr[1]
+	if len(d) < 3 {
+		return nil, fmt.Errorf("Unsupported .npy dtype %q", d)
+	}
+	switch d[0] {
+	case '<', '|', '=':
+		npy.order = binary.LittleEndian
+	case '>':
+		npy.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("Unsupported .npy dtype %q", d)
+	}
+	npy.kind = d[1]
+	// Item widths are small, and capping them keeps the scaling for
+	// unicode strings from overflowing
+	width, err := strconv.Atoi(d[2:])
+	if err != nil || width < 1 || width > 1<<20 {
+		return nil, fmt.Errorf("Unsupported .npy dtype %q", d)
+	}
+	if npy.kind == 'U' {
+		width *= 4
+	}
+	npy.width = width
+
+	// The data size must fit in an int
+	maxInt, size := int(^uint(0)>>1), width
+	for _, v := range npy.shape {
+		if v != 0 && size > maxInt/v {
+			return nil, fmt.Errorf("The .npy shape %v is too large", npy.shape)
+		}
+		size *= v
+	}
+	return npy, nil
+}
+
+// Write a .npy header and the raw array data
+func writeNpy(w io.Writer, descr string, shape []int, data []byte) error {
+	dims := make([]string, len(shape))
+	for i, v := range shape {
+		dims[i] = strconv.Itoa(v)
+	}
+	shapeStr := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shapeStr)
+
+	// The header is padded with spaces so the data is 64-byte aligned
+	major, prefix := byte(1), len(npyMagic)+4
+	if len(header)+prefix+1 > math.MaxUint16 {
+		major, prefix = 2, len(npyMagic)+6
+	}
+	pad := 64 - (prefix+len(header)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	header += strings.Repeat(" ", pad) + "\n"
+
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{major, 0})
+	if major == 1 {
+		binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	} else {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(header)))
+	}
+	buf.WriteString(header)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// Write an array of float64 values in .npy format
+func writeNpyFloat64(w io.Writer, shape []int, values []float64) error {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[i*8:], math.Float64bits(v))
+	}
+	return writeNpy(w, "<f8", shape, data)
+}
+
+// Write an array of int64 values in .npy format
+func writeNpyInt64(w io.Writer, shape []int, values []int) error {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[i*8:], uint64(v))
+	}
+	return writeNpy(w, "<i8", shape, data)
+}
+
+// Read an array in NumPy's .npy format. Arrays of type float64 and float32
+// (and of the integer and boolean types) are supported in either byte order,
+// stored in either 'C' or 'F' order, and of any dimensionality. The result is
+// a dense array. A zero-dimensional array is read as a 1D array of length 1.
+func LoadNpy(r io.Reader) (NDArray, error) {
+	npy, err := readNpy(r)
+	if err != nil {
+		return nil, err
+	}
+	values, err := npy.floats()
+	if err != nil {
+		return nil, err
+	}
+	shape := npy.shape
+	if len(shape) == 0 {
+		shape = []int{1}
+	}
+	return &denseF64Array{
+		shape: shape,
+		array: values,
+	}, nil
+}
+
+// Write an array in NumPy's .npy format, as little-endian float64 values in
+// 'C' order. Sparse arrays are written in dense form.
+func SaveNpy(w io.Writer, array NDArray) error {
+	return writeNpyFloat64(w, array.Shape(), array.Array())
+}
+
+// Write an array in NumPy's .npy format, as little-endian float32 values in
+// 'C' order. Values are rounded to the nearest float32.
+func SaveNpyFloat32(w io.Writer, array NDArray) error {
+	values := array.Array()
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(float32(v)))
+	}
+	return writeNpy(w, "<f4", array.Shape(), data)
+}
+
+// Read all entries of a .npz archive
+func readNpz(r io.ReaderAt, size int64) (map[string]*npyArray, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read .npz archive: %v", err)
+	}
+	result := make(map[string]*npyArray)
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".npy") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("Can't read .npz entry %s: %v", f.Name, err)
+		}
+		npy, err := readNpy(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Can't read .npz entry %s: %v", f.Name, err)
+		}
+		result[strings.TrimSuffix(f.Name, ".npy")] = npy
+	}
+	return result, nil
+}
+
+// Read a NumPy .npz archive of named arrays, as written by numpy.savez or
+// numpy.savez_compressed. Each array is read as by LoadNpy.
+func LoadNpz(r io.ReaderAt, size int64) (map[string]NDArray, error) {
+	entries, err := readNpz(r, size)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]NDArray)
+	for name, npy := range entries {
+		values, err := npy.floats()
+		if err != nil {
+			return nil, fmt.Errorf("Can't read .npz entry %s: %v", name, err)
+		}
+		shape := npy.shape
+		if len(shape) == 0 {
+			shape = []int{1}
+		}
+		result[name] = &denseF64Array{
+			shape: shape,
+			array: values,
+		}
+	}
+	return result, nil
+}
+
+// Write a set of named arrays as a NumPy .npz archive, readable by
+// numpy.load. Each array is written as by SaveNpy. Entries are compressed.
+func SaveNpz(w io.Writer, arrays map[string]NDArray) error {
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := zw.Create(name + ".npy")
+		if err != nil {
+			return err
+		}
+		if err := SaveNpy(f, arrays[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Read a sparse matrix stored in the .npz layout used by scipy.sparse.save_npz.
+// Matrices in "coo", "csr" and "csc" format are supported. The result is a
+// sparse coo matrix.
+func LoadSparseNpz(r io.ReaderAt, size int64) (Matrix, error) {
+	entries, err := readNpz(r, size)
+	if err != nil {
+		return nil, err
+	}
+	get := func(name string) ([]float64, error) {
+		npy, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("Sparse .npz archive has no %q entry", name)
+		}
+		return npy.floats()
+	}
+
+	fmtEntry, ok := entries["format"]
+	if !ok {
+		return nil, fmt.Errorf("Sparse .npz archive has no %q entry", "format")
+	}
+	format, err := fmtEntry.str()
+	if err != nil {
+		return nil, err
+	}
+	shape, err := get("shape")
+	if err != nil {
+		return nil, err
+	} else if len(shape) != 2 {
+		return nil, fmt.Errorf("Sparse .npz archive has invalid shape %v", shape)
+	}
+	data, err := get("data")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, cols := int(shape[0]), int(shape[1])
+	m := SparseCoo(rows, cols).(*sparseCooF64Matrix)
+	set := func(row, col int, value float64) error {
+		if row < 0 || row >= rows || col < 0 || col >= cols {
+			return fmt.Errorf("Sparse .npz entry (%d, %d) is outside shape %dx%d", row, col, rows, cols)
+		}
+		if v := m.values[row][col] + value; v != 0 {
+			m.values[row][col] = v
+		} else {
+			delete(m.values[row], col)
+		}
+		return nil
+	}
+
+	switch format {
+	case "coo":
+		row, err := get("row")
+		if err != nil {
+			return nil, err
+		}
+		col, err := get("col")
+		if err != nil {
+			return nil, err
+		}
+		if len(row) != len(data) || len(col) != len(data) {
+			return nil, fmt.Errorf("Sparse .npz coo arrays have inconsistent lengths")
+		}
+		for i, v := range data {
+			if err := set(int(row[i]), int(col[i]), v); err != nil {
+				return nil, err
+			}
+		}
+
+	case "csr", "csc":
+		indices, err := get("indices")
+		if err != nil {
+			return nil, err
+		}
+		indptr, err := get("indptr")
+		if err != nil {
+			return nil, err
+		}
+		major := rows
+		if format == "csc" {
+			major = cols
+		}
+		if len(indptr) != major+1 || len(indices) != len(data) {
+			return nil, fmt.Errorf("Sparse .npz %s arrays have inconsistent lengths", format)
+		}
+		for i := 0; i < major; i++ {
+			start, stop := int(indptr[i]), int(indptr[i+1])
+			if start < 0 || stop > len(data) || stop < start {
+				return nil, fmt.Errorf("Sparse .npz %s index pointer is invalid", format)
+			}
+			for j := start; j < stop; j++ {
+				row, col := i, int(indices[j])
+				if format == "csc" {
+					row, col = col, row
+				}
+				if err := set(row, col, data[j]); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("Unsupported sparse .npz format %q", format)
+	}
+	return m, nil
+}
+
+// Write a matrix in the .npz layout used by scipy.sparse.save_npz, so it can
+// be read with scipy.sparse.load_npz. The matrix is stored in "coo" format,
+// and only its nonzero values are written.
+func SaveSparseNpz(w io.Writer, m Matrix) error {
+	var (
+		nnz  = m.CountNonzero()
+		row  = make([]int, 0, nnz)
+		col  = make([]int, 0, nnz)
+		data = make([]float64, 0, nnz)
+	)
+	m.VisitNonzero(func(pos []int, value float64) bool {
+		row = append(row, pos[0])
+		col = append(col, pos[1])
+		data = append(data, value)
+		return true
+	})
+
+	zw := zip.NewWriter(w)
+	write := func(name string, f func(io.Writer) error) error {
+		entry, err := zw.Create(name + ".npy")
+		if err != nil {
+			return err
+		}
+		return f(entry)
+	}
+	err := write("format", func(w io.Writer) error {
+		return writeNpy(w, "|S3", []int{}, []byte("coo"))
+	})
+	if err == nil {
+		err = write("shape", func(w io.Writer) error {
+			return writeNpyInt64(w, []int{2}, []int{m.Rows(), m.Cols()})
+		})
+	}
+	if err == nil {
+		err = write("row", func(w io.Writer) error {
+			return writeNpyInt64(w, []int{nnz}, row)
+		})
+	}
+	if err == nil {
+		err = write("col", func(w io.Writer) error {
+			return writeNpyInt64(w, []int{nnz}, col)
+		})
+	}
+	if err == nil {
+		err = write("data", func(w io.Writer) error {
+			return writeNpyFloat64(w, []int{nnz}, data)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,240 @@
+package matrix
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+// Build a version 1.0 .npy file with the given header and raw data
+func npyBytes(header string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// Build a zip archive containing the given entries
+func zipBytes(entries map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		f, _ := zw.Create(name)
+		f.Write(data)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestNpy(t *testing.T) {
+	Convey("Given a 3D dense array", t, func() {
+		array := A([]int{2, 3, 2},
+			1, 2, 3, 4, 5, 6,
+			7, 8, 9, 10, 11, 12)
+
+		Convey("SaveNpy writes an aligned header", func() {
+			var buf bytes.Buffer
+			So(SaveNpy(&buf, array), ShouldBeNil)
+			So((buf.Len()-12*8)%64, ShouldEqual, 0)
+			So(buf.String()[:len(npyMagic)], ShouldEqual, npyMagic)
+		})
+
+		Convey("SaveNpy and LoadNpy round trip", func() {
+			var buf bytes.Buffer
+			So(SaveNpy(&buf, array), ShouldBeNil)
+			loaded, err := LoadNpy(&buf)
+			So(err, ShouldBeNil)
+			So(loaded.Shape(), ShouldResemble, []int{2, 3, 2})
+			So(loaded.Array(), ShouldResemble, array.Array())
+		})
+
+		Convey("SaveNpyFloat32 and LoadNpy round trip", func() {
+			var buf bytes.Buffer
+			So(SaveNpyFloat32(&buf, array), ShouldBeNil)
+			So((buf.Len()-12*4)%64, ShouldEqual, 0)
+			loaded, err := LoadNpy(&buf)
+			So(err, ShouldBeNil)
+			So(loaded.Shape(), ShouldResemble, []int{2, 3, 2})
+			So(loaded.Array(), ShouldResemble, array.Array())
+		})
+	})
+
+	Convey("Given transposed and sparse matrices", t, func() {
+		Convey("SaveNpy writes a transposed dense matrix in 'C' order", func() {
+			m := M(2, 3, 1, 2, 3, 4, 5, 6).T()
+			var buf bytes.Buffer
+			So(SaveNpy(&buf, m), ShouldBeNil)
+			loaded, err := LoadNpy(&buf)
+			So(err, ShouldBeNil)
+			So(loaded.Shape(), ShouldResemble, []int{3, 2})
+			So(loaded.Array(), ShouldResemble, []float64{1, 4, 2, 5, 3, 6})
+		})
+
+		Convey("SaveNpy writes a sparse matrix densely", func() {
+			m := Diag(1, 2, 3)
+			var buf bytes.Buffer
+			So(SaveNpy(&buf, m), ShouldBeNil)
+			loaded, err := LoadNpy(&buf)
+			So(err, ShouldBeNil)
+			So(loaded.Sparsity(), ShouldEqual, DenseArray)
+			So(loaded.Array(), ShouldResemble, m.Array())
+		})
+	})
+
+	Convey("LoadNpy reads Fortran-order data", t, func() {
+		data := make([]byte, 6*8)
+		for i, v := range []float64{1, 4, 2, 5, 3, 6} {
+			binary.LittleEndian.PutUint64(data[i*8:], math.Float64bits(v))
+		}
+		loaded, err := LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '<f8', 'fortran_order': True, 'shape': (2, 3), }\n", data)))
+		So(err, ShouldBeNil)
+		So(loaded.Shape(), ShouldResemble, []int{2, 3})
+		So(loaded.Array(), ShouldResemble, []float64{1, 2, 3, 4, 5, 6})
+	})
+
+	Convey("LoadNpy reads big-endian float32 data", t, func() {
+		data := make([]byte, 3*4)
+		for i, v := range []float32{1.5, -2, 3.25} {
+			binary.BigEndian.PutUint32(data[i*4:], math.Float32bits(v))
+		}
+		loaded, err := LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '>f4', 'fortran_order': False, 'shape': (3,), }\n", data)))
+		So(err, ShouldBeNil)
+		So(loaded.Shape(), ShouldResemble, []int{3})
+		So(loaded.Array(), ShouldResemble, []float64{1.5, -2, 3.25})
+	})
+
+	Convey("LoadNpy reads a zero-dimensional array as a 1D array", t, func() {
+		data := make([]byte, 8)
+		binary.LittleEndian.PutUint64(data, math.Float64bits(7))
+		loaded, err := LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '<f8', 'fortran_order': False, 'shape': (), }\n", data)))
+		So(err, ShouldBeNil)
+		So(loaded.Shape(), ShouldResemble, []int{1})
+		So(loaded.Array(), ShouldResemble, []float64{7})
+	})
+
+	Convey("LoadNpy reports errors", t, func() {
+		_, err := LoadNpy(bytes.NewReader([]byte("not a numpy file")))
+		So(err, ShouldNotBeNil)
+
+		_, err = LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '<c16', 'fortran_order': False, 'shape': (1,), }\n", make([]byte, 16))))
+		So(err, ShouldNotBeNil)
+
+		_, err = LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '<f8', 'fortran_order': False, 'shape': (4,), }\n", make([]byte, 8))))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("LoadNpy rejects shapes which don't fit the data", t, func() {
+		// The data size overflows an int
+		_, err := LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '<f8', 'fortran_order': False, 'shape': (4611686018427387904, 4), }\n", make([]byte, 8))))
+		So(err, ShouldNotBeNil)
+
+		// The data size fits, but is far more than the stream holds
+		_, err = LoadNpy(bytes.NewReader(npyBytes(
+			"{'descr': '<f8', 'fortran_order': False, 'shape': (1099511627776, 1024), }\n", make([]byte, 8))))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestNpz(t *testing.T) {
+	Convey("Given a set of named arrays", t, func() {
+		arrays := map[string]NDArray{
+			"a": A1(1, 2, 3),
+			"b": M(2, 2, 1, 2, 3, 4),
+		}
+
+		Convey("SaveNpz and LoadNpz round trip", func() {
+			var buf bytes.Buffer
+			So(SaveNpz(&buf, arrays), ShouldBeNil)
+			loaded, err := LoadNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			So(err, ShouldBeNil)
+			So(len(loaded), ShouldEqual, 2)
+			So(loaded["a"].Shape(), ShouldResemble, []int{3})
+			So(loaded["a"].Array(), ShouldResemble, []float64{1, 2, 3})
+			So(loaded["b"].Shape(), ShouldResemble, []int{2, 2})
+			So(loaded["b"].Array(), ShouldResemble, []float64{1, 2, 3, 4})
+		})
+	})
+
+	Convey("LoadNpz rejects data which is not a zip archive", t, func() {
+		data := []byte("not a zip archive")
+		_, err := LoadNpz(bytes.NewReader(data), int64(len(data)))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSparseNpz(t *testing.T) {
+	Convey("Given a sparse coo matrix", t, func() {
+		m := SparseCoo(3, 4,
+			0, 1, 0, 0,
+			2, 0, 0, 3,
+			0, 0, 4, 0)
+
+		Convey("SaveSparseNpz and LoadSparseNpz round trip", func() {
+			var buf bytes.Buffer
+			So(SaveSparseNpz(&buf, m), ShouldBeNil)
+			loaded, err := LoadSparseNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			So(err, ShouldBeNil)
+			So(loaded.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(loaded.Shape(), ShouldResemble, []int{3, 4})
+			So(loaded.CountNonzero(), ShouldEqual, 4)
+			So(loaded.Array(), ShouldResemble, m.Array())
+		})
+
+		Convey("SaveSparseNpz writes a transposed matrix correctly", func() {
+			var buf bytes.Buffer
+			So(SaveSparseNpz(&buf, m.T()), ShouldBeNil)
+			loaded, err := LoadSparseNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			So(err, ShouldBeNil)
+			So(loaded.Shape(), ShouldResemble, []int{4, 3})
+			So(loaded.Array(), ShouldResemble, m.T().Array())
+		})
+	})
+
+	Convey("LoadSparseNpz reads scipy's csr layout", t, func() {
+		var format, shape, indptr, indices, data bytes.Buffer
+		fmtData := make([]byte, 12)
+		for i, r := range "csr" {
+			binary.LittleEndian.PutUint32(fmtData[i*4:], uint32(r))
+		}
+		writeNpy(&format, "<U3", []int{}, fmtData)
+		writeNpyInt64(&shape, []int{2}, []int{2, 3})
+		writeNpyInt64(&indptr, []int{3}, []int{0, 2, 3})
+		writeNpyInt64(&indices, []int{3}, []int{0, 2, 1})
+		writeNpyFloat64(&data, []int{3}, []float64{1, 2, 3})
+		archive := zipBytes(map[string][]byte{
+			"format.npy":  format.Bytes(),
+			"shape.npy":   shape.Bytes(),
+			"indptr.npy":  indptr.Bytes(),
+			"indices.npy": indices.Bytes(),
+			"data.npy":    data.Bytes(),
+		})
+
+		loaded, err := LoadSparseNpz(bytes.NewReader(archive), int64(len(archive)))
+		So(err, ShouldBeNil)
+		So(loaded.Sparsity(), ShouldEqual, SparseCooMatrix)
+		So(loaded.Array(), ShouldResemble, []float64{
+			1, 0, 2,
+			0, 3, 0,
+		})
+	})
+
+	Convey("LoadSparseNpz rejects archives with missing entries", t, func() {
+		var format bytes.Buffer
+		writeNpy(&format, "|S3", []int{}, []byte("coo"))
+		archive := zipBytes(map[string][]byte{"format.npy": format.Bytes()})
+		_, err := LoadSparseNpz(bytes.NewReader(archive), int64(len(archive)))
+		So(err, ShouldNotBeNil)
+	})
+}
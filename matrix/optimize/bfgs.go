@@ -0,0 +1,152 @@
+package optimize
+
+import (
+	"math"
+)
+
+// A point along a line search
+type linePoint struct {
+	step, f float64
+	g       []float64
+	slope   float64
+}
+
+// Search along the descent direction d from x for a step meeting the strong
+// Wolfe conditions: enough decrease in f, and a much flatter slope. Returns
+// false if no such step is found.
+func (p *problem) lineSearch(x []float64, fx float64, g, d []float64) (linePoint, bool) {
+	const (
+		decrease  = 1e-4
+		curvature = 0.9
+	)
+	slope0 := dot(g, d)
+	at := func(step float64) linePoint {
+		y := axpy(x, step, d)
+		pt := linePoint{step: step, f: p.eval(y), g: p.gradient(y)}
+		pt.slope = dot(pt.g, d)
+		return pt
+	}
+	sufficient := func(pt linePoint) bool {
+		return pt.f <= fx+decrease*pt.step*slope0
+	}
+	flat := func(pt linePoint) bool {
+		return math.Abs(pt.slope) <= -curvature*slope0
+	}
+
+	// Narrow down a bracket whose low end satisfies the decrease condition
+	zoom := func(lo, hi linePoint) (linePoint, bool) {
+		for i := 0; i < 30; i++ {
+			width := hi.step - lo.step
+			step := lo.step + width/2
+			if denom := 2 * (hi.f - lo.f - lo.slope*width); denom > 0 {
+				guess := lo.step - lo.slope*width*width/denom
+				if (guess-lo.step)/width >= 0.1 && (hi.step-guess)/width >= 0.1 {
+					step = guess
+				}
+			}
+			pt := at(step)
+			if !sufficient(pt) || pt.f >= lo.f {
+				hi = pt
+				continue
+			}
+			if flat(pt) {
+				return pt, true
+			}
+			if pt.slope*(hi.step-lo.step) >= 0 {
+				hi = lo
+			}
+			lo = pt
+		}
+		return lo, lo.step > 0
+	}
+
+	prev := linePoint{f: fx, g: g, slope: slope0}
+	for i, step := 0, 1.0; i < 30; i, step = i+1, step*2 {
+		pt := at(step)
+		if math.IsNaN(pt.f) || math.IsInf(pt.f, 1) {
+			return zoom(prev, linePoint{step: step, f: math.Inf(1), slope: math.Inf(1)})
+		}
+		if !sufficient(pt) || (i > 0 && pt.f >= prev.f) {
+			return zoom(prev, pt)
+		}
+		if flat(pt) {
+			return pt, true
+		}
+		if pt.slope >= 0 {
+			return zoom(pt, prev)
+		}
+		prev = pt
+	}
+	return prev, prev.step > 0
+}
+
+// Get the identity matrix as slices
+func identity(n int) [][]float64 {
+	h := make([][]float64, n)
+	for i := range h {
+		h[i] = make([]float64, n)
+		h[i][i] = 1
+	}
+	return h
+}
+
+// Minimize with the BFGS quasi-Newton method
+func (p *problem) bfgs(x []float64) *Result {
+	n := p.n
+	f := p.eval(x)
+	g := p.gradient(x)
+	h := identity(n)
+	fresh := true
+	for ; p.result.Iterations < p.opts.MaxIter; p.result.Iterations++ {
+		if maxAbs(g) <= p.opts.GradTol {
+			return p.finish(x, f, g, true, "Gradient is below tolerance")
+		}
+
+		d := make([]float64, n)
+		for i := range d {
+			d[i] = -dot(h[i], g)
+		}
+		pt, ok := p.lineSearch(x, f, g, d)
+		if !ok {
+			if fresh {
+				return p.finish(x, f, g, false, "Line search failed")
+			}
+			h, fresh = identity(n), true
+			p.result.Iterations--
+			continue
+		}
+
+		s := make([]float64, n)
+		y := make([]float64, n)
+		for i := range s {
+			s[i] = pt.step * d[i]
+			y[i] = pt.g[i] - g[i]
+		}
+		x, f, g = axpy(x, pt.step, d), pt.f, pt.g
+
+		sy := dot(s, y)
+		if sy <= 1e-10*math.Sqrt(dot(s, s)*dot(y, y)) {
+			continue
+		}
+		if fresh {
+			// Scale the initial inverse Hessian to the observed curvature
+			scale := sy / dot(y, y)
+			for i := range h {
+				h[i][i] = scale
+			}
+			fresh = false
+		}
+		rho := 1 / sy
+		hy := make([]float64, n)
+		for i := range hy {
+			hy[i] = dot(h[i], y)
+		}
+		yhy := dot(y, hy)
+		for i := range h {
+			for j := range h[i] {
+				h[i][j] += -rho*(s[i]*hy[j]+hy[i]*s[j]) + (rho*rho*yhy+rho)*s[i]*s[j]
+			}
+		}
+	}
+	return p.finish(x, f, g, false, "Maximum iterations reached")
+}
@@ -0,0 +1,41 @@
+package optimize
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"testing"
+)
+
+func TestBFGS(t *testing.T) {
+	Convey("Given a quadratic", t, func() {
+		// f(x) = (x - c)' A (x - c) / 2 with A = [[3, 1], [1, 2]]
+		f := func(x matrix.NDArray) float64 {
+			a, b := x.FlatItem(0)-1, x.FlatItem(1)+2
+			return (3*a*a + 2*a*b + 2*b*b) / 2
+		}
+		grad := func(x matrix.NDArray) matrix.NDArray {
+			a, b := x.FlatItem(0)-1, x.FlatItem(1)+2
+			return matrix.A1(3*a+b, a+2*b)
+		}
+
+		Convey("BFGS converges in a few iterations", func() {
+			result := Minimize(f, matrix.A1(5, 5), BFGS, &Options{Gradient: grad, GradTol: 1e-10})
+			So(result.Converged, ShouldBeTrue)
+			So(result.Iterations, ShouldBeLessThanOrEqualTo, 6)
+			So(matrix.AllClose(result.X, matrix.A1(1, -2), 0, 1e-9, false), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a line search on a quadratic", t, func() {
+		p := newProblem(func(x matrix.NDArray) float64 {
+			return x.FlatItem(0) * x.FlatItem(0)
+		}, matrix.A1(3), BFGS, nil)
+
+		Convey("The step meets the Wolfe conditions", func() {
+			pt, ok := p.lineSearch([]float64{3}, 9, []float64{6}, []float64{-1})
+			So(ok, ShouldBeTrue)
+			So(pt.f, ShouldBeLessThan, 9)
+			So(pt.slope, ShouldBeGreaterThanOrEqualTo, -0.9*6)
+		})
+	})
+}
@@ -0,0 +1,483 @@
+package optimize
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// A finite difference formula
+type DiffMethod int
+
+const (
+	// Forward differences (f(x+h) - f(x)) / h, with error O(h)
+	DiffForward DiffMethod = iota
+
+	// Central differences (f(x+h) - f(x-h)) / 2h, with error O(h^2)
+	DiffCentral
+
+	// The complex step Im f(x + ih) / h, which avoids cancellation and is
+	// accurate to machine precision. It needs the function's extension to
+	// complex arguments.
+	DiffComplexStep
+)
+
+// The extension of a function to complex arguments, for DiffComplexStep. A
+// scalar function returns a single value.
+type ComplexFunc func(z []complex128) []complex128
+
+// Options for Grad, Jacobian and Hessian. The zero value uses forward
+// differences with a default step.
+type DiffOptions struct {
+	// The difference formula to use
+	Method DiffMethod
+
+	// The step for each parameter x_i is Step * max(1, |x_i|). The default
+	// balances truncation against rounding error for the method.
+	Step float64
+
+	// Refine central differences by Richardson extrapolation over a sequence
+	// of shrinking steps (Ridders' method), starting from Step, which then
+	// defaults to 0.1. This costs more evaluations, but chooses the step
+	// size automatically and is often accurate to 1e-10 or better. It needs
+	// DiffCentral and isn't supported by Hessian.
+	Adaptive bool
+
+	// The function's extension to complex arguments, needed by
+	// DiffComplexStep
+	Complex ComplexFunc
+
+	// For Jacobian, a matrix whose nonzero entries mark the entries of the
+	// Jacobian which may be nonzero. Columns with no rows in common are
+	// estimated together, so sparse Jacobians need fewer evaluations.
+	Sparsity matrix.Matrix
+
+	// Lower and upper bounds on each parameter for Grad and Jacobian, which
+	// never evaluate the function outside them. Either may be nil, and
+	// infinite entries are unbounded. Steps which would cross a bound are
+	// taken one-sided, and derivatives by parameters whose bounds are equal
+	// are zero. Bounds aren't supported with Adaptive.
+	Lower, Upper matrix.NDArray
+
+	// The value of the function at x, if it is already known. This saves an
+	// evaluation for forward differences.
+	F0 matrix.NDArray
+}
+
+// Estimates derivatives of a vector function along directions
+type differ struct {
+	f            func(x []float64) []float64
+	x            []float64
+	f0           []float64
+	lower, upper []float64
+	opts         DiffOptions
+}
+
+// Set up a differ, checking the options
+func newDiffer(name string, f func(x []float64) []float64, x matrix.NDArray, opts *DiffOptions) *differ {
+	if x.NDim() != 1 || x.Size() == 0 {
+		panic(fmt.Sprintf("%s needs a nonempty 1D point, but got shape %v", name, x.Shape()))
+	}
+	d := &differ{f: f, x: x.Dense().Array()}
+	if opts != nil {
+		d.opts = *opts
+	}
+	switch d.opts.Method {
+	case DiffForward, DiffCentral:
+	case DiffComplexStep:
+		if d.opts.Complex == nil {
+			panic(fmt.Sprintf("%s needs the Complex function for the complex step method", name))
+		}
+	default:
+		panic(fmt.Sprintf("Unknown difference method %d", d.opts.Method))
+	}
+	if d.opts.Adaptive && d.opts.Method != DiffCentral {
+		panic(fmt.Sprintf("%s only supports adaptive steps for central differences", name))
+	}
+	if d.opts.Step == 0 {
+		eps := 2.220446049250313e-16
+		switch {
+		case d.opts.Adaptive:
+			d.opts.Step = 0.1
+		case d.opts.Method == DiffForward:
+			d.opts.Step = math.Sqrt(eps)
+		case d.opts.Method == DiffCentral:
+			d.opts.Step = math.Cbrt(eps)
+		default:
+			d.opts.Step = 1e-20
+		}
+	}
+	if d.opts.Lower != nil || d.opts.Upper != nil {
+		if d.opts.Adaptive {
+			panic(fmt.Sprintf("%s doesn't support bounds with adaptive steps", name))
+		}
+		d.lower = diffBound(name, d.opts.Lower, len(d.x), math.Inf(-1))
+		d.upper = diffBound(name, d.opts.Upper, len(d.x), math.Inf(1))
+		for i := range d.x {
+			if d.x[i] < d.lower[i] || d.x[i] > d.upper[i] {
+				panic(fmt.Sprintf("%s got x_%d = %v outside its bounds [%v, %v]", name, i, d.x[i], d.lower[i], d.upper[i]))
+			}
+		}
+	}
+	if d.opts.F0 != nil {
+		d.f0 = d.opts.F0.Dense().Array()
+	}
+	return d
+}
+
+// Get bounds of the given size, or fill them with a default
+func diffBound(name string, bound matrix.NDArray, n int, fill float64) []float64 {
+	values := make([]float64, n)
+	if bound == nil {
+		for i := range values {
+			values[i] = fill
+		}
+		return values
+	} else if bound.Size() != n {
+		panic(fmt.Sprintf("%s got %d bounds for %d parameters", name, bound.Size(), n))
+	}
+	for i := range values {
+		values[i] = bound.FlatItem(i)
+	}
+	return values
+}
+
+// Get the function's value at x, evaluating it the first time
+func (d *differ) value() []float64 {
+	if d.f0 == nil {
+		d.f0 = d.f(d.x)
+	}
+	return d.f0
+}
+
+// Get the step for parameter i, rounded so that x + h is exact
+func (d *differ) step(i int, scale float64) float64 {
+	h := scale * math.Max(1, math.Abs(d.x[i]))
+	if d.opts.Method == DiffComplexStep {
+		return h
+	}
+	return (d.x[i] + h) - d.x[i]
+}
+
+// Choose a step for each of the given parameters which stays within the
+// bounds. Returns the step direction and whether the difference must be
+// one-sided. Parameters whose bounds are equal get a zero step.
+func (d *differ) direction(params []int) (dir []float64, oneSided bool) {
+	dir = make([]float64, len(d.x))
+	for _, i := range params {
+		h := d.step(i, d.opts.Step)
+		dir[i] = h
+		if d.lower == nil || d.opts.Method == DiffComplexStep {
+			continue
+		}
+		up, down := d.upper[i]-d.x[i], d.x[i]-d.lower[i]
+		switch {
+		case up >= h && (down >= h || d.opts.Method == DiffForward):
+		case up >= h:
+			oneSided = true
+		case down >= h:
+			dir[i], oneSided = -h, true
+		case up >= down:
+			dir[i], oneSided = up, true
+		default:
+			dir[i], oneSided = -down, true
+		}
+	}
+	return dir, oneSided
+}
+
+// Evaluate the function at x + t * dir
+func (d *differ) at(dir []float64, t float64) []float64 {
+	y := make([]float64, len(d.x))
+	for i := range y {
+		y[i] = d.x[i] + t*dir[i]
+	}
+	return d.f(y)
+}
+
+// Estimate J dir, where J is the Jacobian at x. A one-sided estimate uses
+// a forward difference along dir whatever the method.
+func (d *differ) directional(dir []float64, oneSided bool) []float64 {
+	if d.opts.Adaptive {
+		return d.ridders(dir)
+	}
+	var result []float64
+	method := d.opts.Method
+	if oneSided {
+		method = DiffForward
+	}
+	switch method {
+	case DiffForward:
+		f0 := d.value()
+		result = d.at(dir, 1)
+		for i := range result {
+			result[i] -= f0[i]
+		}
+	case DiffCentral:
+		result = d.at(dir, 1)
+		back := d.at(dir, -1)
+		for i := range result {
+			result[i] = (result[i] - back[i]) / 2
+		}
+	case DiffComplexStep:
+		z := make([]complex128, len(d.x))
+		for i := range z {
+			z[i] = complex(d.x[i], dir[i])
+		}
+		values := d.opts.Complex(z)
+		result = make([]float64, len(values))
+		for i, v := range values {
+			result[i] = imag(v)
+		}
+	}
+	return result
+}
+
+// Estimate J dir with Ridders' method: extrapolate central differences over
+// steps shrinking by a constant factor, keeping the estimate of each output
+// with the smallest apparent error
+func (d *differ) ridders(dir []float64) []float64 {
+	const (
+		shrink = 1.4
+		tries  = 10
+		safe   = 2.0
+	)
+	central := func(t float64) []float64 {
+		fwd, back := d.at(dir, t), d.at(dir, -t)
+		for i := range fwd {
+			fwd[i] = (fwd[i] - back[i]) / (2 * t)
+		}
+		return fwd
+	}
+
+	prevRow := [][]float64{central(1)}
+	m := len(prevRow[0])
+	best := make([]float64, m)
+	copy(best, prevRow[0])
+	errs := make([]float64, m)
+	for i := range errs {
+		errs[i] = math.Inf(1)
+	}
+	done := make([]bool, m)
+	t := 1.0
+	for k := 1; k < tries; k++ {
+		t /= shrink
+		row := [][]float64{central(t)}
+		fac := shrink * shrink
+		for j := 1; j <= k; j++ {
+			next := make([]float64, m)
+			for i := range next {
+				next[i] = (row[j-1][i]*fac - prevRow[j-1][i]) / (fac - 1)
+				errt := math.Max(math.Abs(next[i]-row[j-1][i]), math.Abs(next[i]-prevRow[j-1][i]))
+				if !done[i] && errt <= errs[i] {
+					errs[i], best[i] = errt, next[i]
+				}
+			}
+			row = append(row, next)
+			fac *= shrink * shrink
+		}
+		allDone := true
+		for i := range done {
+			if math.Abs(row[k][i]-prevRow[k-1][i]) >= safe*errs[i] {
+				done[i] = true
+			}
+			allDone = allDone && done[i]
+		}
+		if allDone {
+			break
+		}
+		prevRow = row
+	}
+	return best
+}
+
+// Estimate the gradient of a scalar function at x by finite differences
+func Grad(f Func, x matrix.NDArray, opts *DiffOptions) matrix.NDArray {
+	d := newDiffer("Grad", func(y []float64) []float64 {
+		return []float64{f(matrix.A1(y...))}
+	}, x, opts)
+	n := len(d.x)
+	g := matrix.Dense(n)
+	for i := 0; i < n; i++ {
+		if dir, oneSided := d.direction([]int{i}); dir[i] != 0 {
+			g.FlatItemSet(d.directional(dir, oneSided)[0]/dir[i], i)
+		}
+	}
+	return g
+}
+
+// Split the columns of a sparsity pattern into groups with no rows in
+// common, greedily in column order. Returns the groups and the nonzero rows
+// of each column.
+func groupColumns(pattern matrix.Matrix) (groups [][]int, rows [][]int) {
+	rows = make([][]int, pattern.Cols())
+	pattern.VisitNonzero(func(pos []int, value float64) bool {
+		rows[pos[1]] = append(rows[pos[1]], pos[0])
+		return true
+	})
+	var used []map[int]bool
+	for col, colRows := range rows {
+		g := 0
+		for ; g < len(groups); g++ {
+			clash := false
+			for _, r := range colRows {
+				if used[g][r] {
+					clash = true
+					break
+				}
+			}
+			if !clash {
+				break
+			}
+		}
+		if g == len(groups) {
+			groups = append(groups, nil)
+			used = append(used, make(map[int]bool))
+		}
+		groups[g] = append(groups[g], col)
+		for _, r := range colRows {
+			used[g][r] = true
+		}
+	}
+	return groups, rows
+}
+
+// Estimate the Jacobian of a vector function at x by finite differences.
+// Entry (i, j) is the derivative of output i by x_j. With a sparsity
+// pattern, the result is a sparse coo matrix with zeros outside the pattern.
+func Jacobian(f VecFunc, x matrix.NDArray, opts *DiffOptions) matrix.Matrix {
+	var m int
+	d := newDiffer("Jacobian", func(y []float64) []float64 {
+		fy := f(matrix.A1(y...))
+		m = fy.Size()
+		values := make([]float64, m)
+		for i := range values {
+			values[i] = fy.FlatItem(i)
+		}
+		return values
+	}, x, opts)
+	n := len(d.x)
+
+	if d.opts.Sparsity == nil {
+		var jac matrix.Matrix
+		for j := 0; j < n; j++ {
+			dir, oneSided := d.direction([]int{j})
+			if dir[j] == 0 {
+				continue
+			}
+			col := d.directional(dir, oneSided)
+			if jac == nil {
+				jac = matrix.Dense(m, n).M()
+			}
+			for i, v := range col {
+				jac.ItemSet(v/dir[j], i, j)
+			}
+		}
+		if jac == nil {
+			jac = matrix.Dense(len(d.value()), n).M()
+		}
+		return jac
+	}
+
+	pattern := d.opts.Sparsity
+	if pattern.Cols() != n {
+		panic(fmt.Sprintf("Jacobian sparsity pattern has shape %v, but there are %d parameters", pattern.Shape(), n))
+	}
+	jac := matrix.SparseCoo(pattern.Rows(), n)
+	groups, rows := groupColumns(pattern)
+	for _, group := range groups {
+		dir, oneSided := d.direction(group)
+		diff := d.directional(dir, oneSided)
+		if len(diff) != pattern.Rows() {
+			panic(fmt.Sprintf("Jacobian sparsity pattern has shape %v, but there are %d outputs", pattern.Shape(), len(diff)))
+		}
+		for _, j := range group {
+			if dir[j] == 0 {
+				continue
+			}
+			for _, i := range rows[j] {
+				jac.ItemSet(diff[i]/dir[j], i, j)
+			}
+		}
+	}
+	return jac
+}
+
+// Estimate the Hessian of a scalar function at x by finite differences. The
+// result is symmetric.
+func Hessian(f Func, x matrix.NDArray, opts *DiffOptions) matrix.Matrix {
+	if opts != nil && opts.Adaptive {
+		panic("Hessian doesn't support adaptive steps")
+	}
+	eval := func(y []float64) []float64 {
+		return []float64{f(matrix.A1(y...))}
+	}
+	o := DiffOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	// Second differences need larger steps than first differences
+	if o.Step == 0 {
+		eps := 2.220446049250313e-16
+		switch o.Method {
+		case DiffForward:
+			o.Step = math.Cbrt(eps)
+		default:
+			o.Step = math.Pow(eps, 0.25)
+		}
+	}
+	d := newDiffer("Hessian", eval, x, &o)
+	n := len(d.x)
+	h := make([]float64, n)
+	for i := range h {
+		h[i] = d.step(i, d.opts.Step)
+	}
+
+	// Evaluate f at x + a h_i e_i + b h_j e_j
+	at := func(i int, a float64, j int, b float64) float64 {
+		y := make([]float64, n)
+		copy(y, d.x)
+		y[i] += a * h[i]
+		y[j] += b * h[j]
+		return d.f(y)[0]
+	}
+	var single []float64
+	if d.opts.Method == DiffForward {
+		d.value()
+		single = make([]float64, n)
+		for i := range single {
+			single[i] = at(i, 1, i, 0)
+		}
+	}
+
+	hess := matrix.Dense(n, n).M()
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			var val float64
+			switch d.opts.Method {
+			case DiffForward:
+				val = (at(i, 1, j, 1) - single[i] - single[j] + d.f0[0]) / (h[i] * h[j])
+			case DiffCentral:
+				val = (at(i, 1, j, 1) - at(i, 1, j, -1) - at(i, -1, j, 1) + at(i, -1, j, -1)) /
+					(4 * h[i] * h[j])
+			case DiffComplexStep:
+				// Take a complex step in x_i and a real central difference
+				// in x_j
+				z := make([]complex128, n)
+				for k := range z {
+					z[k] = complex(d.x[k], 0)
+				}
+				z[i] += complex(0, h[i])
+				z[j] += complex(h[j], 0)
+				fwd := imag(d.opts.Complex(z)[0])
+				z[j] -= complex(2*h[j], 0)
+				back := imag(d.opts.Complex(z)[0])
+				val = (fwd - back) / (2 * h[i] * h[j])
+			}
+			hess.ItemSet(val, i, j)
+			hess.ItemSet(val, j, i)
+		}
+	}
+	return hess
+}
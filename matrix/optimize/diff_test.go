@@ -0,0 +1,163 @@
+package optimize
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestGrad(t *testing.T) {
+	Convey("Given a smooth function", t, func() {
+		// f(x, y) = exp(x) sin(y) + x^2 y
+		f := func(v matrix.NDArray) float64 {
+			x, y := v.FlatItem(0), v.FlatItem(1)
+			return math.Exp(x)*math.Sin(y) + x*x*y
+		}
+		fc := func(z []complex128) []complex128 {
+			return []complex128{cmplx.Exp(z[0])*cmplx.Sin(z[1]) + z[0]*z[0]*z[1]}
+		}
+		x := matrix.A1(0.5, 2)
+		exact := matrix.A1(math.Exp(0.5)*math.Sin(2)+2, math.Exp(0.5)*math.Cos(2)+0.25)
+
+		Convey("Each method approximates the gradient", func() {
+			So(matrix.AllClose(Grad(f, x, nil), exact, 0, 1e-6, false), ShouldBeTrue)
+			So(matrix.AllClose(Grad(f, x, &DiffOptions{Method: DiffCentral}), exact, 0, 1e-9, false), ShouldBeTrue)
+			So(matrix.AllClose(Grad(f, x, &DiffOptions{Method: DiffComplexStep, Complex: fc}), exact, 0, 1e-14, false), ShouldBeTrue)
+			So(matrix.AllClose(Grad(f, x, &DiffOptions{Method: DiffCentral, Adaptive: true}), exact, 0, 1e-11, false), ShouldBeTrue)
+		})
+
+		Convey("Bad options panic", func() {
+			So(func() { Grad(f, x, &DiffOptions{Method: DiffComplexStep}) }, ShouldPanic)
+			So(func() { Grad(f, x, &DiffOptions{Adaptive: true}) }, ShouldPanic)
+			So(func() { Grad(f, x, &DiffOptions{Method: DiffMethod(8)}) }, ShouldPanic)
+			So(func() { Grad(f, matrix.Dense(2, 2), nil) }, ShouldPanic)
+			So(func() { Grad(f, x, &DiffOptions{Method: DiffCentral, Adaptive: true, Lower: x}) }, ShouldPanic)
+			So(func() { Grad(f, x, &DiffOptions{Lower: matrix.A1(0)}) }, ShouldPanic)
+			So(func() { Grad(f, x, &DiffOptions{Upper: matrix.A1(0, 0)}) }, ShouldPanic)
+		})
+
+		Convey("Bounds keep every evaluation inside them", func() {
+			lower, upper := matrix.A1(0.5, 1), matrix.A1(3, 2)
+			inside := func(v matrix.NDArray) float64 {
+				So(v.FlatItem(0), ShouldBeBetweenOrEqual, 0.5, 3)
+				So(v.FlatItem(1), ShouldBeBetweenOrEqual, 1, 2)
+				return f(v)
+			}
+			opts := &DiffOptions{Method: DiffCentral, Lower: lower, Upper: upper}
+			So(matrix.AllClose(Grad(inside, x, opts), exact, 0, 1e-4, false), ShouldBeTrue)
+		})
+
+		Convey("Parameters with equal bounds have zero derivatives", func() {
+			g := Grad(f, x, &DiffOptions{Lower: matrix.A1(0.5, 0), Upper: matrix.A1(0.5, 5)})
+			So(g.FlatItem(0), ShouldEqual, 0)
+			So(g.FlatItem(1), ShouldAlmostEqual, exact.FlatItem(1), 1e-6)
+		})
+
+		Convey("A known value saves an evaluation", func() {
+			evals := 0
+			counted := func(v matrix.NDArray) float64 {
+				evals++
+				return f(v)
+			}
+			Grad(counted, x, &DiffOptions{F0: matrix.A1(f(x))})
+			So(evals, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestJacobian(t *testing.T) {
+	Convey("Given a vector function", t, func() {
+		f := func(v matrix.NDArray) matrix.NDArray {
+			x, y := v.FlatItem(0), v.FlatItem(1)
+			return matrix.A1(x*y, math.Sin(x), y*y*y)
+		}
+		x := matrix.A1(1, 2)
+		exact := matrix.A2([]float64{2, 1}, []float64{math.Cos(1), 0}, []float64{0, 12})
+
+		Convey("Jacobian has one row per output", func() {
+			So(matrix.AllClose(Jacobian(f, x, nil), exact, 0, 1e-6, false), ShouldBeTrue)
+			So(matrix.AllClose(Jacobian(f, x, &DiffOptions{Method: DiffCentral, Adaptive: true}), exact, 0, 1e-10, false), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a function with a banded Jacobian", t, func() {
+		// F_i = x_{i-1} + 2 x_i^2 - x_{i+1}, a common discretization pattern
+		n := 12
+		evals := 0
+		f := func(v matrix.NDArray) matrix.NDArray {
+			evals++
+			out := matrix.Dense(n)
+			for i := 0; i < n; i++ {
+				val := 2 * v.FlatItem(i) * v.FlatItem(i)
+				if i > 0 {
+					val += v.FlatItem(i - 1)
+				}
+				if i < n-1 {
+					val -= v.FlatItem(i + 1)
+				}
+				out.FlatItemSet(val, i)
+			}
+			return out
+		}
+		x := matrix.Linspace(0, 1, n, true)
+		pattern := matrix.SparseCoo(n, n)
+		for i := 0; i < n; i++ {
+			for j := i - 1; j <= i+1; j++ {
+				if j >= 0 && j < n {
+					pattern.ItemSet(1, i, j)
+				}
+			}
+		}
+
+		Convey("Grouping columns needs only three evaluations per method", func() {
+			groups, _ := groupColumns(pattern)
+			So(len(groups), ShouldEqual, 3)
+
+			evals = 0
+			sparse := Jacobian(f, x, &DiffOptions{Method: DiffCentral, Sparsity: pattern})
+			So(evals, ShouldEqual, 6)
+			So(sparse.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+
+			evals = 0
+			dense := Jacobian(f, x, &DiffOptions{Method: DiffCentral})
+			So(evals, ShouldEqual, 2*n)
+			So(matrix.AllClose(sparse, dense, 0, 1e-8, false), ShouldBeTrue)
+			So(sparse.Item(3, 3), ShouldAlmostEqual, 4*x.FlatItem(3), 1e-8)
+			So(sparse.Item(3, 4), ShouldAlmostEqual, -1, 1e-8)
+		})
+
+		Convey("Mismatched patterns panic", func() {
+			So(func() { Jacobian(f, x, &DiffOptions{Sparsity: matrix.SparseCoo(n, n-1)}) }, ShouldPanic)
+			So(func() { Jacobian(f, x, &DiffOptions{Sparsity: matrix.SparseCoo(n+1, n)}) }, ShouldPanic)
+		})
+	})
+}
+
+func TestHessian(t *testing.T) {
+	Convey("Given a smooth function", t, func() {
+		// f(x, y) = x^3 y + exp(y)
+		f := func(v matrix.NDArray) float64 {
+			x, y := v.FlatItem(0), v.FlatItem(1)
+			return x*x*x*y + math.Exp(y)
+		}
+		fc := func(z []complex128) []complex128 {
+			return []complex128{z[0]*z[0]*z[0]*z[1] + cmplx.Exp(z[1])}
+		}
+		x := matrix.A1(1.5, 0.5)
+		exact := matrix.A2([]float64{6 * 1.5 * 0.5, 3 * 1.5 * 1.5}, []float64{3 * 1.5 * 1.5, math.Exp(0.5)})
+
+		Convey("Each method approximates the Hessian", func() {
+			So(matrix.AllClose(Hessian(f, x, nil), exact, 0, 1e-4, false), ShouldBeTrue)
+			So(matrix.AllClose(Hessian(f, x, &DiffOptions{Method: DiffCentral}), exact, 0, 1e-6, false), ShouldBeTrue)
+			h := Hessian(f, x, &DiffOptions{Method: DiffComplexStep, Complex: fc})
+			So(matrix.AllClose(h, exact, 0, 1e-6, false), ShouldBeTrue)
+			So(h.Item(0, 1), ShouldEqual, h.Item(1, 0))
+		})
+
+		Convey("Adaptive steps aren't supported", func() {
+			So(func() { Hessian(f, x, &DiffOptions{Method: DiffCentral, Adaptive: true}) }, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,141 @@
+package optimize
+
+import (
+	"math"
+)
+
+// Minimize with a projected limited-memory BFGS method. Each iteration fixes
+// the parameters held at a bound by the gradient, finds an L-BFGS direction
+// for the rest, and backtracks along its projection onto the bounds.
+func (p *problem) lbfgsb(x []float64) *Result {
+	const (
+		decrease = 1e-4
+		relTol   = 1e7 * 2.220446049250313e-16
+	)
+	n := p.n
+	f := p.eval(x)
+	g := p.gradient(x)
+	var ss, ys [][]float64
+	for ; p.result.Iterations < p.opts.MaxIter; p.result.Iterations++ {
+		projected := make([]float64, n)
+		copy(projected, x)
+		for i := range projected {
+			projected[i] -= g[i]
+		}
+		p.clip(projected)
+		for i := range projected {
+			projected[i] -= x[i]
+		}
+		if maxAbs(projected) <= p.opts.GradTol {
+			return p.finish(x, f, g, true, "Projected gradient is below tolerance")
+		}
+
+		free := make([]float64, n)
+		for i := range free {
+			if !(x[i] <= p.lower[i] && g[i] > 0) && !(x[i] >= p.upper[i] && g[i] < 0) {
+				free[i] = 1
+			}
+		}
+		masked := func(v []float64) []float64 {
+			result := make([]float64, n)
+			for i := range v {
+				result[i] = v[i] * free[i]
+			}
+			return result
+		}
+
+		// Apply the inverse Hessian approximation to the free gradient with
+		// the two-loop recursion
+		q := masked(g)
+		alpha := make([]float64, len(ss))
+		for k := len(ss) - 1; k >= 0; k-- {
+			s, y := masked(ss[k]), masked(ys[k])
+			if sy := dot(s, y); sy > 0 {
+				alpha[k] = dot(s, q) / sy
+				for i := range q {
+					q[i] -= alpha[k] * y[i]
+				}
+			}
+		}
+		if k := len(ss) - 1; k >= 0 {
+			s, y := masked(ss[k]), masked(ys[k])
+			if yy := dot(y, y); yy > 0 && dot(s, y) > 0 {
+				for i := range q {
+					q[i] *= dot(s, y) / yy
+				}
+			}
+		}
+		for k := range ss {
+			s, y := masked(ss[k]), masked(ys[k])
+			if sy := dot(s, y); sy > 0 {
+				beta := dot(y, q) / sy
+				for i := range q {
+					q[i] += s[i] * (alpha[k] - beta)
+				}
+			}
+		}
+		d := make([]float64, n)
+		for i := range d {
+			d[i] = -q[i] * free[i]
+		}
+		if dot(d, g) >= 0 {
+			d = masked(g)
+			for i := range d {
+				d[i] = -d[i]
+			}
+			ss, ys = nil, nil
+		}
+
+		// Backtrack along the projected path until f decreases enough
+		step := 1.0
+		if len(ss) == 0 {
+			step = math.Min(1, 1/maxAbs(d))
+		}
+		var next []float64
+		var fNext float64
+		found := false
+		for tries := 0; tries < 40; tries++ {
+			next = axpy(x, step, d)
+			p.clip(next)
+			fNext = p.eval(next)
+			moved := make([]float64, n)
+			for i := range moved {
+				moved[i] = next[i] - x[i]
+			}
+			if fNext <= f+decrease*dot(g, moved) {
+				found = true
+				break
+			}
+			step /= 2
+		}
+		if !found {
+			if len(ss) > 0 {
+				ss, ys = nil, nil
+				p.result.Iterations--
+				continue
+			}
+			return p.finish(x, f, g, false, "Line search failed")
+		}
+
+		gNext := p.gradient(next)
+		s := make([]float64, n)
+		y := make([]float64, n)
+		for i := range s {
+			s[i] = next[i] - x[i]
+			y[i] = gNext[i] - g[i]
+		}
+		if dot(s, y) > 2.220446049250313e-16*dot(y, y) {
+			ss, ys = append(ss, s), append(ys, y)
+			if len(ss) > p.opts.Memory {
+				ss, ys = ss[1:], ys[1:]
+			}
+		}
+		reduction := (f - fNext) / math.Max(math.Max(math.Abs(f), math.Abs(fNext)), 1)
+		x, f, g = next, fNext, gNext
+		if reduction <= relTol {
+			p.result.Iterations++
+			return p.finish(x, f, g, true, "Relative reduction of f is below tolerance")
+		}
+	}
+	return p.finish(x, f, g, false, "Maximum iterations reached")
+}
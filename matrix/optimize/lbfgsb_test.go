@@ -0,0 +1,78 @@
+package optimize
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestLBFGSB(t *testing.T) {
+	Convey("Given bounds which exclude the minimum", t, func() {
+		lower := matrix.A1(-2, 1.5, math.Inf(-1), -2)
+		upper := matrix.A1(0.5, 3, math.Inf(1), 2)
+
+		Convey("L-BFGS-B finds the constrained minimum", func() {
+			result := Minimize(rosen, matrix.A1(0, 2, 0, 0), LBFGSB, &Options{
+				Gradient: rosenGrad,
+				Lower:    lower,
+				Upper:    upper,
+			})
+			So(result.Converged, ShouldBeTrue)
+			So(result.X.FlatItem(0), ShouldAlmostEqual, 0.5, 1e-6)
+			for i := 0; i < 4; i++ {
+				So(result.X.FlatItem(i), ShouldBeBetweenOrEqual, lower.FlatItem(i), upper.FlatItem(i))
+			}
+
+			// The gradient is zero at free parameters and points outward at
+			// the bounds
+			So(math.Abs(result.Grad.FlatItem(2)), ShouldBeLessThan, 1e-4)
+			So(result.Grad.FlatItem(0), ShouldBeLessThan, 0)
+		})
+
+		Convey("The starting point is moved inside the bounds", func() {
+			f := func(x matrix.NDArray) float64 { return x.FlatItem(0) }
+			result := Minimize(f, matrix.A1(-5), LBFGSB, &Options{Lower: matrix.A1(-1)})
+			So(result.Converged, ShouldBeTrue)
+			So(result.X.FlatItem(0), ShouldEqual, -1)
+		})
+	})
+
+	Convey("Given a parameter fixed by equal bounds", t, func() {
+		f := func(x matrix.NDArray) float64 {
+			a, b := x.FlatItem(0), x.FlatItem(1)
+			return (a-1)*(a-1) + (b-2)*(b-2) + a*b
+		}
+
+		Convey("L-BFGS-B minimizes over the other parameters", func() {
+			result := Minimize(f, matrix.A1(0, 0.5), LBFGSB, &Options{
+				Lower: matrix.A1(math.Inf(-1), 0.5),
+				Upper: matrix.A1(math.Inf(1), 0.5),
+			})
+			So(result.Converged, ShouldBeTrue)
+			So(result.X.FlatItem(0), ShouldAlmostEqual, 0.75, 1e-6)
+			So(result.X.FlatItem(1), ShouldEqual, 0.5)
+			So(result.Grad.FlatItem(1), ShouldEqual, 0)
+		})
+
+		Convey("One-sided differences at a bound give the right gradient", func() {
+			result := Minimize(f, matrix.A1(0, 0.5), LBFGSB, &Options{
+				Lower: matrix.A1(math.Inf(-1), 0.5),
+				Upper: matrix.A1(math.Inf(1), 3),
+			})
+			So(result.Converged, ShouldBeTrue)
+			So(result.X.FlatItem(0), ShouldAlmostEqual, 0, 1e-5)
+			So(result.X.FlatItem(1), ShouldAlmostEqual, 2, 1e-5)
+		})
+	})
+
+	Convey("Given a long Rosenbrock valley", t, func() {
+		x0 := matrix.WithValue(-1, 20)
+
+		Convey("L-BFGS-B solves it with limited memory", func() {
+			result := Minimize(rosen, x0, LBFGSB, &Options{Gradient: rosenGrad, Memory: 5})
+			So(result.Converged, ShouldBeTrue)
+			So(matrix.AllClose(result.X, matrix.WithValue(1, 20), 0, 1e-3, false), ShouldBeTrue)
+		})
+	})
+}
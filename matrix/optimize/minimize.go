@@ -0,0 +1,263 @@
+// The optimize package minimizes functions and finds their roots, loosely
+// following scipy.optimize.
+//
+// To fit the parameters of a model by least squares:
+//     loss := func(p matrix.NDArray) float64 {
+//             return model(p).Sub(observed).Apply(square).Sum()
+//     }
+//     result := optimize.Minimize(loss, guess, optimize.BFGS, nil)
+//     if result.Converged {
+//             fmt.Println(result.X)
+//     }
+package optimize
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// A function to minimize, taking a 1D array of parameters
+type Func func(x matrix.NDArray) float64
+
+// The gradient of a Func, returning a 1D array the size of x
+type GradFunc func(x matrix.NDArray) matrix.NDArray
+
+// A minimization algorithm
+type Method int
+
+const (
+	// The Nelder-Mead simplex method, which needs no gradient. It is robust
+	// to noise, but slow for more than a few parameters.
+	NelderMead Method = iota
+
+	// The BFGS quasi-Newton method, which builds a dense approximation of
+	// the inverse Hessian
+	BFGS
+
+	// The limited-memory BFGS method, which stores only the last few steps
+	// and supports bounds on each parameter
+	LBFGSB
+)
+
+// Get the name of the method
+func (m Method) String() string {
+	switch m {
+	case NelderMead:
+		return "Nelder-Mead"
+	case BFGS:
+		return "BFGS"
+	case LBFGSB:
+		return "L-BFGS-B"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// Options for Minimize. The zero value of each field selects a default.
+type Options struct {
+	// The gradient of the function. By default, it is estimated by central
+	// differences.
+	Gradient GradFunc
+
+	// Lower and upper bounds on each parameter, used by LBFGSB and
+	// NelderMead. Either may be nil, and infinite entries are unbounded.
+	Lower, Upper matrix.NDArray
+
+	// The most iterations to run; the default is 200 times the number of
+	// parameters
+	MaxIter int
+
+	// Gradient methods stop when every component of the (projected)
+	// gradient is at most GradTol in magnitude; the default is 1e-5
+	GradTol float64
+
+	// Nelder-Mead stops when the simplex spans at most XTol in each
+	// parameter and FTol in function value; the defaults are 1e-4
+	XTol, FTol float64
+
+	// The number of steps L-BFGS-B remembers; the default is 10
+	Memory int
+}
+
+// The outcome of a minimization
+type Result struct {
+	// The best parameters found, and the function and gradient there. Grad
+	// is nil for Nelder-Mead.
+	X    matrix.NDArray
+	F    float64
+	Grad matrix.NDArray
+
+	// The number of iterations run, and of function and gradient
+	// evaluations made
+	Iterations, FuncEvals, GradEvals int
+
+	// Whether the convergence criteria were met, and why the method stopped
+	Converged bool
+	Message   string
+}
+
+// The state shared by the minimization methods
+type problem struct {
+	f            Func
+	grad         GradFunc
+	opts         Options
+	n            int
+	lower, upper []float64
+	result       Result
+}
+
+// Set up a problem, filling in default options
+func newProblem(f Func, x0 matrix.NDArray, method Method, opts *Options) *problem {
+	if x0.NDim() != 1 || x0.Size() == 0 {
+		panic(fmt.Sprintf("Minimize needs nonempty 1D initial parameters, but got shape %v", x0.Shape()))
+	}
+	p := &problem{f: f, n: x0.Size()}
+	if opts != nil {
+		p.opts = *opts
+	}
+	p.grad = p.opts.Gradient
+	if p.opts.MaxIter == 0 {
+		p.opts.MaxIter = 200 * p.n
+	}
+	if p.opts.GradTol == 0 {
+		p.opts.GradTol = 1e-5
+	}
+	if p.opts.XTol == 0 {
+		p.opts.XTol = 1e-4
+	}
+	if p.opts.FTol == 0 {
+		p.opts.FTol = 1e-4
+	}
+	if p.opts.Memory == 0 {
+		p.opts.Memory = 10
+	}
+	p.lower = bound(p.opts.Lower, p.n, math.Inf(-1))
+	p.upper = bound(p.opts.Upper, p.n, math.Inf(1))
+	bounded := false
+	for i := range p.lower {
+		if p.lower[i] > p.upper[i] {
+			panic(fmt.Sprintf("Lower bound %v exceeds upper bound %v for parameter %d", p.lower[i], p.upper[i], i))
+		}
+		bounded = bounded || !math.IsInf(p.lower[i], -1) || !math.IsInf(p.upper[i], 1)
+	}
+	if bounded && method == BFGS {
+		panic("BFGS doesn't support bounds; use LBFGSB")
+	}
+	return p
+}
+
+// Get the bounds on each parameter
+func bound(b matrix.NDArray, n int, unbounded float64) []float64 {
+	result := make([]float64, n)
+	if b == nil {
+		for i := range result {
+			result[i] = unbounded
+		}
+		return result
+	}
+	if b.Size() != n {
+		panic(fmt.Sprintf("Got %d bounds for %d parameters", b.Size(), n))
+	}
+	for i := range result {
+		result[i] = b.FlatItem(i)
+	}
+	return result
+}
+
+// Move x inside the bounds
+func (p *problem) clip(x []float64) {
+	for i := range x {
+		x[i] = math.Max(p.lower[i], math.Min(x[i], p.upper[i]))
+	}
+}
+
+// Evaluate the function
+func (p *problem) eval(x []float64) float64 {
+	p.result.FuncEvals++
+	return p.f(matrix.A1(x...))
+}
+
+// Evaluate the gradient, estimating it by central differences with Grad if
+// no gradient was given
+func (p *problem) gradient(x []float64) []float64 {
+	g := make([]float64, p.n)
+	if p.grad != nil {
+		p.result.GradEvals++
+		grad := p.grad(matrix.A1(x...))
+		if grad.Size() != p.n {
+			panic(fmt.Sprintf("Gradient returned %d values for %d parameters", grad.Size(), p.n))
+		}
+		for i := range g {
+			g[i] = grad.FlatItem(i)
+		}
+		return g
+	}
+
+	grad := Grad(func(y matrix.NDArray) float64 {
+		return p.eval(y.Array())
+	}, matrix.A1(x...), &DiffOptions{
+		Method: DiffCentral,
+		Lower:  matrix.A1(p.lower...),
+		Upper:  matrix.A1(p.upper...),
+	})
+	return grad.Array()
+}
+
+// Record the final point
+func (p *problem) finish(x []float64, f float64, g []float64, converged bool, message string) *Result {
+	p.result.X = matrix.A1(x...)
+	p.result.F = f
+	if g != nil {
+		p.result.Grad = matrix.A1(g...)
+	}
+	p.result.Converged = converged
+	p.result.Message = message
+	return &p.result
+}
+
+// Minimize a function of several parameters, starting from x0. Methods
+// other than NelderMead use the gradient given in the options, or estimate
+// it by finite differences. Only NelderMead and LBFGSB support bounds.
+func Minimize(f Func, x0 matrix.NDArray, method Method, opts *Options) *Result {
+	p := newProblem(f, x0, method, opts)
+	x := x0.Dense().Array()
+	p.clip(x)
+	switch method {
+	case NelderMead:
+		return p.nelderMead(x)
+	case BFGS:
+		return p.bfgs(x)
+	case LBFGSB:
+		return p.lbfgsb(x)
+	default:
+		panic(fmt.Sprintf("Unknown minimization method %v", method))
+	}
+}
+
+// Get the dot product of two vectors
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i, v := range a {
+		sum += v * b[i]
+	}
+	return sum
+}
+
+// Get the largest magnitude of any element of a vector
+func maxAbs(a []float64) float64 {
+	var result float64
+	for _, v := range a {
+		result = math.Max(result, math.Abs(v))
+	}
+	return result
+}
+
+// Get x + step*d
+func axpy(x []float64, step float64, d []float64) []float64 {
+	result := make([]float64, len(x))
+	for i := range x {
+		result[i] = x[i] + step*d[i]
+	}
+	return result
+}
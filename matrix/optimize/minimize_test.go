@@ -0,0 +1,88 @@
+package optimize
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"testing"
+)
+
+// The Rosenbrock function, with its minimum of 0 at (1, 1, ..., 1)
+func rosen(x matrix.NDArray) float64 {
+	var sum float64
+	for i := 0; i+1 < x.Size(); i++ {
+		a, b := x.FlatItem(i), x.FlatItem(i+1)
+		sum += 100*(b-a*a)*(b-a*a) + (1-a)*(1-a)
+	}
+	return sum
+}
+
+// The gradient of the Rosenbrock function
+func rosenGrad(x matrix.NDArray) matrix.NDArray {
+	n := x.Size()
+	g := matrix.Dense(n)
+	for i := 0; i+1 < n; i++ {
+		a, b := x.FlatItem(i), x.FlatItem(i+1)
+		g.FlatItemSet(g.FlatItem(i)-400*a*(b-a*a)-2*(1-a), i)
+		g.FlatItemSet(g.FlatItem(i+1)+200*(b-a*a), i+1)
+	}
+	return g
+}
+
+func TestMinimize(t *testing.T) {
+	Convey("Given the Rosenbrock function", t, func() {
+		x0 := matrix.A1(-1.2, 1, -0.5, 0.8)
+		ones := matrix.WithValue(1, 4)
+
+		Convey("Every method finds the minimum", func() {
+			for _, method := range []Method{NelderMead, BFGS, LBFGSB} {
+				opts := &Options{MaxIter: 5000, XTol: 1e-8, FTol: 1e-12}
+				result := Minimize(rosen, x0, method, opts)
+				So(result.Converged, ShouldBeTrue)
+				So(matrix.AllClose(result.X, ones, 0, 1e-3, false), ShouldBeTrue)
+				So(result.F, ShouldBeLessThan, 1e-6)
+				So(result.FuncEvals, ShouldBeGreaterThan, 0)
+			}
+		})
+
+		Convey("Analytic gradients replace finite differences", func() {
+			result := Minimize(rosen, x0, BFGS, &Options{Gradient: rosenGrad})
+			So(result.Converged, ShouldBeTrue)
+			So(result.GradEvals, ShouldBeGreaterThan, 0)
+			So(matrix.AllClose(result.X, ones, 0, 1e-4, false), ShouldBeTrue)
+			So(matrix.AllClose(result.Grad, matrix.Zeros(4), 0, 1e-5, false), ShouldBeTrue)
+
+			numeric := Minimize(rosen, x0, BFGS, nil)
+			So(numeric.GradEvals, ShouldEqual, 0)
+			So(numeric.FuncEvals, ShouldBeGreaterThan, result.FuncEvals)
+		})
+
+		Convey("Hitting MaxIter is reported", func() {
+			result := Minimize(rosen, x0, BFGS, &Options{MaxIter: 3})
+			So(result.Converged, ShouldBeFalse)
+			So(result.Iterations, ShouldEqual, 3)
+			So(result.Message, ShouldEqual, "Maximum iterations reached")
+		})
+
+		Convey("Bad arguments panic", func() {
+			So(func() { Minimize(rosen, matrix.Dense(2, 2), BFGS, nil) }, ShouldPanic)
+			So(func() { Minimize(rosen, x0, Method(7), nil) }, ShouldPanic)
+			So(func() { Minimize(rosen, x0, BFGS, &Options{Lower: matrix.Zeros(4)}) }, ShouldPanic)
+			So(func() { Minimize(rosen, x0, LBFGSB, &Options{Lower: matrix.Zeros(3)}) }, ShouldPanic)
+			So(func() {
+				Minimize(rosen, x0, LBFGSB, &Options{Lower: matrix.WithValue(2, 4), Upper: ones})
+			}, ShouldPanic)
+			So(func() {
+				Minimize(rosen, x0, BFGS, &Options{Gradient: func(x matrix.NDArray) matrix.NDArray {
+					return matrix.Zeros(2)
+				}})
+			}, ShouldPanic)
+		})
+	})
+
+	Convey("Methods have names", t, func() {
+		So(NelderMead.String(), ShouldEqual, "Nelder-Mead")
+		So(BFGS.String(), ShouldEqual, "BFGS")
+		So(LBFGSB.String(), ShouldEqual, "L-BFGS-B")
+		So(Method(9).String(), ShouldEqual, "Method(9)")
+	})
+}
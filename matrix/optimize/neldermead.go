@@ -0,0 +1,130 @@
+package optimize
+
+import (
+	"math"
+	"sort"
+)
+
+// Minimize with the Nelder-Mead simplex method. Points outside the bounds
+// are moved onto them.
+func (p *problem) nelderMead(x0 []float64) *Result {
+	const (
+		reflect  = 1.0
+		expand   = 2.0
+		contract = 0.5
+		shrink   = 0.5
+	)
+	n := p.n
+
+	// Start with a simplex around x0, stretching each parameter by 5%
+	simplex := make([][]float64, n+1)
+	fvals := make([]float64, n+1)
+	simplex[0] = x0
+	for k := 0; k < n; k++ {
+		y := make([]float64, n)
+		copy(y, x0)
+		if y[k] != 0 {
+			y[k] *= 1.05
+		} else {
+			y[k] = 0.00025
+		}
+		p.clip(y)
+		simplex[k+1] = y
+	}
+	for i, y := range simplex {
+		fvals[i] = p.eval(y)
+	}
+
+	// Get the point (1 + t) xbar - t worst
+	along := func(xbar, worst []float64, t float64) ([]float64, float64) {
+		y := make([]float64, n)
+		for i := range y {
+			y[i] = (1+t)*xbar[i] - t*worst[i]
+		}
+		p.clip(y)
+		return y, p.eval(y)
+	}
+
+	converged := false
+	for ; p.result.Iterations < p.opts.MaxIter; p.result.Iterations++ {
+		order := make([]int, n+1)
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return fvals[order[i]] < fvals[order[j]] })
+		sorted, sortedF := make([][]float64, n+1), make([]float64, n+1)
+		for i, j := range order {
+			sorted[i], sortedF[i] = simplex[j], fvals[j]
+		}
+		simplex, fvals = sorted, sortedF
+
+		var xSpread, fSpread float64
+		for i := 1; i <= n; i++ {
+			fSpread = math.Max(fSpread, math.Abs(fvals[i]-fvals[0]))
+			for j := range simplex[i] {
+				xSpread = math.Max(xSpread, math.Abs(simplex[i][j]-simplex[0][j]))
+			}
+		}
+		if xSpread <= p.opts.XTol && fSpread <= p.opts.FTol {
+			converged = true
+			break
+		}
+
+		xbar := make([]float64, n)
+		for _, y := range simplex[:n] {
+			for j, v := range y {
+				xbar[j] += v / float64(n)
+			}
+		}
+		worst := simplex[n]
+		xr, fr := along(xbar, worst, reflect)
+		switch {
+		case fr < fvals[0]:
+			if xe, fe := along(xbar, worst, reflect*expand); fe < fr {
+				simplex[n], fvals[n] = xe, fe
+			} else {
+				simplex[n], fvals[n] = xr, fr
+			}
+		case fr < fvals[n-1]:
+			simplex[n], fvals[n] = xr, fr
+		default:
+			var xc []float64
+			var fc float64
+			if fr < fvals[n] {
+				xc, fc = along(xbar, worst, contract*reflect)
+				if fc > fr {
+					xc = nil
+				}
+			} else {
+				xc, fc = along(xbar, worst, -contract)
+				if fc >= fvals[n] {
+					xc = nil
+				}
+			}
+			if xc != nil {
+				simplex[n], fvals[n] = xc, fc
+				break
+			}
+
+			// Shrink the simplex toward the best point
+			for i := 1; i <= n; i++ {
+				for j := range simplex[i] {
+					simplex[i][j] = simplex[0][j] + shrink*(simplex[i][j]-simplex[0][j])
+				}
+				p.clip(simplex[i])
+				fvals[i] = p.eval(simplex[i])
+			}
+		}
+	}
+
+	best := 0
+	for i := range fvals {
+		if fvals[i] < fvals[best] {
+			best = i
+		}
+	}
+	if converged {
+		return p.finish(simplex[best], fvals[best], nil, true, "Simplex converged")
+	}
+	return p.finish(simplex[best], fvals[best], nil, false, "Maximum iterations reached")
+}
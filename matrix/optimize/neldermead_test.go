@@ -0,0 +1,32 @@
+package optimize
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestNelderMead(t *testing.T) {
+	Convey("Given a function with a kink", t, func() {
+		f := func(x matrix.NDArray) float64 {
+			return math.Abs(x.FlatItem(0)-2) + math.Abs(x.FlatItem(1)+1)
+		}
+
+		Convey("Nelder-Mead needs no gradient", func() {
+			result := Minimize(f, matrix.A1(0, 0), NelderMead, &Options{XTol: 1e-8, FTol: 1e-8})
+			So(result.Converged, ShouldBeTrue)
+			So(result.Grad, ShouldBeNil)
+			So(matrix.AllClose(result.X, matrix.A1(2, -1), 0, 1e-6, false), ShouldBeTrue)
+		})
+
+		Convey("Nelder-Mead respects bounds", func() {
+			result := Minimize(f, matrix.A1(0, 0), NelderMead, &Options{
+				Lower: matrix.A1(math.Inf(-1), 0),
+				Upper: matrix.A1(1, math.Inf(1)),
+			})
+			So(result.Converged, ShouldBeTrue)
+			So(matrix.AllClose(result.X, matrix.A1(1, 0), 0, 1e-3, false), ShouldBeTrue)
+		})
+	})
+}
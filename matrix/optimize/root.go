@@ -0,0 +1,280 @@
+package optimize
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// The outcome of a scalar root search
+type ScalarResult struct {
+	// The root found, and the function value there
+	X, F float64
+
+	// The number of iterations run and function evaluations made
+	Iterations, FuncEvals int
+
+	// Whether the root was located within the tolerance, and why the search
+	// stopped
+	Converged bool
+	Message   string
+}
+
+// The most iterations Brent will run
+var BrentMaxIter = 100
+
+// Find a root of f in [a, b] with Brent's method, which combines bisection
+// with secant steps and inverse quadratic interpolation. f(a) and f(b) must
+// have different signs. The root is located to within tol.
+func Brent(f func(float64) float64, a, b, tol float64) *ScalarResult {
+	result := &ScalarResult{}
+	eval := func(x float64) float64 {
+		result.FuncEvals++
+		return f(x)
+	}
+	fa, fb := eval(a), eval(b)
+	switch {
+	case fa == 0:
+		result.X, result.Converged, result.Message = a, true, "Found an exact root"
+		return result
+	case fb == 0:
+		result.X, result.Converged, result.Message = b, true, "Found an exact root"
+		return result
+	case (fa > 0) == (fb > 0):
+		result.X, result.F = b, fb
+		result.Message = fmt.Sprintf("f(a) = %v and f(b) = %v must have different signs", fa, fb)
+		return result
+	}
+
+	// b is the best guess, a the previous one, and c the other end of the
+	// bracket
+	c, fc := b, fb
+	var d, e float64
+	for ; result.Iterations < BrentMaxIter; result.Iterations++ {
+		if (fb > 0) == (fc > 0) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol1 := 2*2.220446049250313e-16*math.Abs(b) + tol/2
+		mid := (c - b) / 2
+		if math.Abs(mid) <= tol1 || fb == 0 {
+			result.X, result.F, result.Converged = b, fb, true
+			result.Message = "Root is within tolerance"
+			return result
+		}
+
+		if math.Abs(e) >= tol1 && math.Abs(fa) > math.Abs(fb) {
+			// Try interpolating; fall back to bisection if the step is
+			// too large or shrinks too slowly
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				p, q = 2*mid*s, 1-s
+			} else {
+				r := fb / fc
+				q = fa / fc
+				p = s * (2*mid*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			}
+			p = math.Abs(p)
+			if 2*p < math.Min(3*mid*q-math.Abs(tol1*q), math.Abs(e*q)) {
+				e, d = d, p/q
+			} else {
+				d, e = mid, mid
+			}
+		} else {
+			d, e = mid, mid
+		}
+
+		a, fa = b, fb
+		if math.Abs(d) > tol1 {
+			b += d
+		} else {
+			b += math.Copysign(tol1, mid)
+		}
+		fb = eval(b)
+	}
+	result.X, result.F = b, fb
+	result.Message = "Maximum iterations reached"
+	return result
+}
+
+// A system of equations F(x) = 0, taking and returning 1D arrays of the same
+// size
+type VecFunc func(x matrix.NDArray) matrix.NDArray
+
+// The Jacobian of a VecFunc: entry (i, j) is the derivative of F_i by x_j
+type JacFunc func(x matrix.NDArray) matrix.Matrix
+
+// A method for solving systems of equations
+type RootMethod int
+
+const (
+	// Newton's method, which computes the Jacobian at every iteration
+	Newton RootMethod = iota
+
+	// Broyden's method, which computes the Jacobian once and then updates
+	// it from the observed changes in F
+	Broyden
+)
+
+// Options for Root. The zero value of each field selects a default.
+type RootOptions struct {
+	// The Jacobian of the system. By default, it is estimated by forward
+	// differences with the Jacobian function.
+	Jacobian JacFunc
+
+	// The most iterations to run; the default is 100
+	MaxIter int
+
+	// The search stops when every |F_i| is at most Tol; the default is 1e-10
+	Tol float64
+}
+
+// The outcome of a search for a root of a system of equations
+type RootResult struct {
+	// The root found, and the residual F there
+	X, F matrix.NDArray
+
+	// The number of iterations run, and of function and Jacobian
+	// evaluations made
+	Iterations, FuncEvals, JacEvals int
+
+	// Whether the residual is within tolerance, and why the search stopped
+	Converged bool
+	Message   string
+}
+
+// Find a root of a system of equations F(x) = 0, starting from x0. Each step
+// backtracks until it reduces the sum of squared residuals.
+func Root(f VecFunc, x0 matrix.NDArray, method RootMethod, opts *RootOptions) *RootResult {
+	if x0.NDim() != 1 || x0.Size() == 0 {
+		panic(fmt.Sprintf("Root needs nonempty 1D initial values, but got shape %v", x0.Shape()))
+	} else if method != Newton && method != Broyden {
+		panic(fmt.Sprintf("Unknown root finding method %d", method))
+	}
+	var o RootOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxIter == 0 {
+		o.MaxIter = 100
+	}
+	if o.Tol == 0 {
+		o.Tol = 1e-10
+	}
+
+	n := x0.Size()
+	result := &RootResult{}
+	eval := func(x []float64) []float64 {
+		result.FuncEvals++
+		fx := f(matrix.A1(x...))
+		if fx.Size() != n {
+			panic(fmt.Sprintf("Root function returned %d values for %d unknowns", fx.Size(), n))
+		}
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = fx.FlatItem(i)
+		}
+		return values
+	}
+	jacobian := func(x, fx []float64) [][]float64 {
+		result.JacEvals++
+		jac := make([][]float64, n)
+		for i := range jac {
+			jac[i] = make([]float64, n)
+		}
+		if o.Jacobian != nil {
+			m := o.Jacobian(matrix.A1(x...))
+			for i := range jac {
+				for j := range jac[i] {
+					jac[i][j] = m.Item(i, j)
+				}
+			}
+			return jac
+		}
+		m := Jacobian(func(y matrix.NDArray) matrix.NDArray {
+			return matrix.A1(eval(y.Array())...)
+		}, matrix.A1(x...), &DiffOptions{F0: matrix.A1(fx...)})
+		for i := range jac {
+			for j := range jac[i] {
+				jac[i][j] = m.Item(i, j)
+			}
+		}
+		return jac
+	}
+	finish := func(x, fx []float64, converged bool, message string) *RootResult {
+		result.X, result.F = matrix.A1(x...), matrix.A1(fx...)
+		result.Converged, result.Message = converged, message
+		return result
+	}
+
+	x := x0.Dense().Array()
+	fx := eval(x)
+	jac := jacobian(x, fx)
+	fresh := true
+	for ; result.Iterations < o.MaxIter; result.Iterations++ {
+		if maxAbs(fx) <= o.Tol {
+			return finish(x, fx, true, "Residual is below tolerance")
+		}
+		if method == Newton && !fresh {
+			jac = jacobian(x, fx)
+			fresh = true
+		}
+
+		rhs := matrix.Dense(n, 1).M()
+		for i, v := range fx {
+			rhs.ItemSet(-v, i, 0)
+		}
+		dx := matrix.Solve(matrix.A2(jac...).M(), rhs).Array()
+
+		// Halve the step until the residual shrinks
+		found := false
+		var next, fNext []float64
+		if !math.IsNaN(dx[0]) {
+			for step := 1.0; step > 1e-10; step /= 2 {
+				next = axpy(x, step, dx)
+				fNext = eval(next)
+				if dot(fNext, fNext) < dot(fx, fx) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			if fresh {
+				return finish(x, fx, false, "No step reduces the residual")
+			}
+			jac, fresh = jacobian(x, fx), true
+			result.Iterations--
+			continue
+		}
+
+		if method == Broyden {
+			s := make([]float64, n)
+			for i := range s {
+				s[i] = next[i] - x[i]
+			}
+			ss := dot(s, s)
+			for i := range jac {
+				diff := fNext[i] - fx[i] - dot(jac[i], s)
+				for j := range jac[i] {
+					jac[i][j] += diff * s[j] / ss
+				}
+			}
+		}
+		x, fx, fresh = next, fNext, false
+	}
+	if maxAbs(fx) <= o.Tol {
+		return finish(x, fx, true, "Residual is below tolerance")
+	}
+	return finish(x, fx, false, "Maximum iterations reached")
+}
@@ -0,0 +1,82 @@
+package optimize
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestBrent(t *testing.T) {
+	Convey("Given a bracketed root", t, func() {
+		f := func(x float64) float64 { return x*x*x - 2*x - 5 }
+
+		Convey("Brent locates it", func() {
+			result := Brent(f, 2, 3, 1e-12)
+			So(result.Converged, ShouldBeTrue)
+			So(result.X, ShouldAlmostEqual, 2.0945514815423265, 1e-11)
+			So(result.Iterations, ShouldBeLessThan, 15)
+		})
+
+		Convey("Exact roots at the ends are returned", func() {
+			result := Brent(math.Sin, 0, 1, 1e-12)
+			So(result.Converged, ShouldBeTrue)
+			So(result.X, ShouldEqual, 0)
+		})
+
+		Convey("Unbracketed roots are reported", func() {
+			result := Brent(f, 3, 4, 1e-12)
+			So(result.Converged, ShouldBeFalse)
+			So(result.Message, ShouldContainSubstring, "different signs")
+		})
+	})
+}
+
+func TestRoot(t *testing.T) {
+	Convey("Given a nonlinear system", t, func() {
+		// x^2 + y^2 = 4 and e^x + y = 1, with a root near (-1.82, 0.84)
+		f := func(v matrix.NDArray) matrix.NDArray {
+			x, y := v.FlatItem(0), v.FlatItem(1)
+			return matrix.A1(x*x+y*y-4, math.Exp(x)+y-1)
+		}
+		jac := func(v matrix.NDArray) matrix.Matrix {
+			x, y := v.FlatItem(0), v.FlatItem(1)
+			return matrix.A2([]float64{2 * x, 2 * y}, []float64{math.Exp(x), 1}).M()
+		}
+		x0 := matrix.A1(-2, 1)
+
+		Convey("Newton and Broyden find the root", func() {
+			for _, method := range []RootMethod{Newton, Broyden} {
+				result := Root(f, x0, method, nil)
+				So(result.Converged, ShouldBeTrue)
+				So(matrix.AllClose(result.F, matrix.Zeros(2), 0, 1e-10, false), ShouldBeTrue)
+				So(result.X.FlatItem(0), ShouldAlmostEqual, -1.8162640688, 1e-8)
+			}
+		})
+
+		Convey("Broyden evaluates the Jacobian less often", func() {
+			newton := Root(f, x0, Newton, &RootOptions{Jacobian: jac})
+			broyden := Root(f, x0, Broyden, &RootOptions{Jacobian: jac})
+			So(newton.Converged, ShouldBeTrue)
+			So(broyden.Converged, ShouldBeTrue)
+			So(broyden.JacEvals, ShouldBeLessThan, newton.JacEvals)
+		})
+
+		Convey("Bad arguments panic", func() {
+			So(func() { Root(f, matrix.Dense(2, 2), Newton, nil) }, ShouldPanic)
+			So(func() { Root(f, x0, RootMethod(5), nil) }, ShouldPanic)
+			So(func() { Root(f, matrix.A1(1, 2, 3), Newton, nil) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a system without a root", t, func() {
+		f := func(v matrix.NDArray) matrix.NDArray {
+			return matrix.A1(v.FlatItem(0)*v.FlatItem(0) + 1)
+		}
+
+		Convey("The failure is reported", func() {
+			result := Root(f, matrix.A1(1), Newton, nil)
+			So(result.Converged, ShouldBeFalse)
+		})
+	})
+}
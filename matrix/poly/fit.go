@@ -0,0 +1,64 @@
+package poly
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// Fit a polynomial of the given degree to the points (x, y) by least squares.
+// If weights is not nil, the residual at each point is multiplied by its
+// weight; for Gaussian noise, use 1/sigma. The result has NaN coefficients if
+// the least-squares problem can't be solved.
+func Polyfit(x, y matrix.NDArray, deg int, weights matrix.NDArray) Polynomial {
+	n := x.Size()
+	if y.Size() != n {
+		panic(fmt.Sprintf("Polyfit got %d x values but %d y values", n, y.Size()))
+	} else if weights != nil && weights.Size() != n {
+		panic(fmt.Sprintf("Polyfit got %d x values but %d weights", n, weights.Size()))
+	} else if deg < 0 {
+		panic(fmt.Sprintf("Polyfit got negative degree %d", deg))
+	} else if n <= deg {
+		panic(fmt.Sprintf("Polyfit needs more than %d points for degree %d", n, deg))
+	}
+	xs, ys := x.Ravel(), y.Ravel()
+	weight := func(i int) float64 {
+		if weights == nil {
+			return 1
+		}
+		return weights.FlatItem(i)
+	}
+
+	// Solve the weighted Vandermonde system, scaling its columns to unit
+	// norm to improve its conditioning
+	lhs := matrix.Vander(xs, deg+1, true)
+	scale := make([]float64, deg+1)
+	for i := 0; i < n; i++ {
+		w := weight(i)
+		for j := 0; j <= deg; j++ {
+			val := lhs.Item(i, j) * w
+			lhs.ItemSet(val, i, j)
+			scale[j] += val * val
+		}
+	}
+	for j := range scale {
+		scale[j] = math.Sqrt(scale[j])
+		if scale[j] == 0 {
+			scale[j] = 1
+		}
+		for i := 0; i < n; i++ {
+			lhs.ItemSet(lhs.Item(i, j)/scale[j], i, j)
+		}
+	}
+	rhs := matrix.Dense(n, 1).M()
+	for i := 0; i < n; i++ {
+		rhs.ItemSet(ys.FlatItem(i)*weight(i), i, 0)
+	}
+
+	solution := matrix.LDivide(lhs, rhs)
+	coef := make([]float64, deg+1)
+	for j := range coef {
+		coef[j] = solution.Item(j, 0) / scale[j]
+	}
+	return New(coef...)
+}
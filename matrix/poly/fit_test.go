@@ -0,0 +1,52 @@
+package poly
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+func TestPolyfit(t *testing.T) {
+	Convey("Given points on a quadratic", t, func() {
+		x := matrix.Linspace(-2, 3, 11, true)
+		y := New(1, -2, 0.5).Eval(x)
+
+		Convey("Polyfit recovers the coefficients", func() {
+			p := Polyfit(x, y, 2, nil)
+			So(matrix.AllClose(p.Coef, matrix.A1(1, -2, 0.5), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("A higher degree fit has zero extra coefficients", func() {
+			p := Polyfit(x, y, 4, nil)
+			So(matrix.AllClose(p.Coef, matrix.A1(1, -2, 0.5, 0, 0), 0, 1e-8, false), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given noisy points", t, func() {
+		x := matrix.A1(0, 1, 2, 3)
+		y := matrix.A1(1, 3, 2, 5)
+
+		Convey("Polyfit gives the least-squares line", func() {
+			p := Polyfit(x, y, 1, nil)
+			So(matrix.AllClose(p.Coef, matrix.A1(1.1, 1.1), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Zero weights ignore points", func() {
+			p := Polyfit(x, y, 1, matrix.A1(1, 0, 1, 0))
+			So(matrix.AllClose(p.Coef, matrix.A1(1, 0.5), 0, Eps, false), ShouldBeTrue)
+		})
+
+		Convey("Bad arguments panic", func() {
+			So(func() { Polyfit(x, matrix.A1(1, 2), 1, nil) }, ShouldPanic)
+			So(func() { Polyfit(x, y, 1, matrix.A1(1)) }, ShouldPanic)
+			So(func() { Polyfit(x, y, -1, nil) }, ShouldPanic)
+			So(func() { Polyfit(x, y, 4, nil) }, ShouldPanic)
+		})
+
+		Convey("Constant fits give the mean weighted by squared weights", func() {
+			p := Polyfit(x, y, 0, matrix.A1(1, 1, 1, math.Sqrt(2)))
+			So(p.At(0), ShouldAlmostEqual, 3.2, Eps)
+		})
+	})
+}
@@ -0,0 +1,240 @@
+// The poly package provides polynomials in one variable, loosely following
+// numpy.polynomial. Coefficients are stored in increasing order of degree,
+// so the polynomial 1 + 2x + 3x^2 has coefficients [1, 2, 3].
+//
+// To fit a line through some points and evaluate it:
+//     line := poly.Polyfit(x, y, 1, nil)
+//     yHat := line.Eval(x)
+package poly
+
+import (
+	"fmt"
+	"github.com/gonum/matrix/mat64"
+	"github.com/jesand/numgo/matrix"
+	"strings"
+)
+
+// A polynomial c[0] + c[1] x + ... + c[n] x^n
+type Polynomial struct {
+	// The coefficients, in increasing order of degree
+	Coef matrix.NDArray
+}
+
+// Create a polynomial from its coefficients, in increasing order of degree
+func New(coef ...float64) Polynomial {
+	if len(coef) == 0 {
+		coef = []float64{0}
+	}
+	values := make([]float64, len(coef))
+	copy(values, coef)
+	return Polynomial{Coef: matrix.A1(values...)}
+}
+
+// Create a polynomial from a 1D array of coefficients, in increasing order
+// of degree
+func FromCoef(coef matrix.NDArray) Polynomial {
+	if coef.NDim() != 1 {
+		panic(fmt.Sprintf("Polynomial coefficients must be 1D, but got shape %v", coef.Shape()))
+	}
+	return New(coef.Array()...)
+}
+
+// Get a copy of the coefficients with trailing zeros removed
+func (p Polynomial) coef() []float64 {
+	c := p.Coef.Array()
+	n := len(c)
+	for n > 1 && c[n-1] == 0 {
+		n--
+	}
+	result := make([]float64, n)
+	copy(result, c)
+	return result
+}
+
+// Get the degree of the polynomial, ignoring zero high-order coefficients.
+// The zero polynomial has degree 0.
+func (p Polynomial) Degree() int {
+	return len(p.coef()) - 1
+}
+
+// Evaluate the polynomial at a single point
+func (p Polynomial) At(x float64) float64 {
+	c := p.Coef.Array()
+	var result float64
+	for i := len(c) - 1; i >= 0; i-- {
+		result = result*x + c[i]
+	}
+	return result
+}
+
+// Evaluate the polynomial at each element of an array. The result is dense
+// and has the same shape as x.
+func (p Polynomial) Eval(x matrix.NDArray) matrix.NDArray {
+	return x.Dense().Apply(p.At)
+}
+
+// Add another polynomial to this one
+func (p Polynomial) Add(other Polynomial) Polynomial {
+	a, b := p.coef(), other.coef()
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	for i, val := range b {
+		a[i] += val
+	}
+	return New(a...).trim()
+}
+
+// Subtract another polynomial from this one
+func (p Polynomial) Sub(other Polynomial) Polynomial {
+	return p.Add(other.Scale(-1))
+}
+
+// Multiply the polynomial by a constant
+func (p Polynomial) Scale(factor float64) Polynomial {
+	return Polynomial{Coef: p.Coef.ItemProd(factor)}.trim()
+}
+
+// Multiply the polynomial by another one
+func (p Polynomial) Mul(other Polynomial) Polynomial {
+	a, b := p.coef(), other.coef()
+	result := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			result[i+j] += x * y
+		}
+	}
+	return New(result...).trim()
+}
+
+// Divide the polynomial by another one, returning the quotient and
+// remainder. Panics if the divisor is zero.
+func (p Polynomial) Div(other Polynomial) (quotient, remainder Polynomial) {
+	num, den := p.coef(), other.coef()
+	lead := den[len(den)-1]
+	if lead == 0 {
+		panic("Polynomial division by zero")
+	}
+	if len(num) < len(den) {
+		return New(0), New(num...)
+	}
+	quot := make([]float64, len(num)-len(den)+1)
+	for i := len(quot) - 1; i >= 0; i-- {
+		q := num[i+len(den)-1] / lead
+		quot[i] = q
+		for j, d := range den {
+			num[i+j] -= q * d
+		}
+	}
+	return New(quot...).trim(), New(num[:len(den)-1]...).trim()
+}
+
+// Differentiate the polynomial m times
+func (p Polynomial) Derivative(m int) Polynomial {
+	if m < 0 {
+		panic(fmt.Sprintf("Can't take derivative of negative order %d", m))
+	}
+	c := p.coef()
+	for ; m > 0 && len(c) > 1; m-- {
+		for i := 1; i < len(c); i++ {
+			c[i-1] = float64(i) * c[i]
+		}
+		c = c[:len(c)-1]
+	}
+	if m > 0 {
+		c[0] = 0
+	}
+	return New(c...)
+}
+
+// Integrate the polynomial m times. Each integral is chosen to equal k at
+// x = 0.
+func (p Polynomial) Integral(m int, k float64) Polynomial {
+	if m < 0 {
+		panic(fmt.Sprintf("Can't take integral of negative order %d", m))
+	}
+	c := p.coef()
+	for ; m > 0; m-- {
+		next := make([]float64, len(c)+1)
+		next[0] = k
+		for i, val := range c {
+			next[i+1] = val / float64(i+1)
+		}
+		c = next
+	}
+	return New(c...).trim()
+}
+
+// Remove zero high-order coefficients
+func (p Polynomial) trim() Polynomial {
+	c := p.coef()
+	if len(c) == p.Coef.Size() {
+		return p
+	}
+	return New(c...)
+}
+
+// Find the roots of the polynomial, as the eigenvalues of its companion
+// matrix. The roots are returned in no particular order, with repeated roots
+// listed once per multiplicity. Panics for the zero polynomial.
+func (p Polynomial) Roots() []complex128 {
+	c := p.coef()
+	n := len(c) - 1
+	if n == 0 {
+		if c[0] == 0 {
+			panic("The zero polynomial has infinitely many roots")
+		}
+		return []complex128{}
+	}
+
+	// Zero low-order coefficients are roots at zero
+	var roots []complex128
+	for len(c) > 1 && c[0] == 0 {
+		roots = append(roots, 0)
+		c = c[1:]
+		n--
+	}
+	if n == 0 {
+		return roots
+	}
+	if n == 1 {
+		return append(roots, complex(-c[0]/c[1], 0))
+	}
+
+	// The companion matrix has the scaled coefficients in its first row and
+	// ones below the diagonal
+	a := matrix.Dense(n, n).M()
+	for i := 1; i < n; i++ {
+		a.ItemSet(1, i, i-1)
+	}
+	for j := 0; j < n; j++ {
+		a.ItemSet(-c[n-1-j]/c[n], 0, j)
+	}
+	var eigen mat64.Eigen
+	if !eigen.Factorize(matrix.ToMat64(a), false, false) {
+		panic("Eigenvalues failed to converge")
+	}
+	return append(roots, eigen.Values(nil)...)
+}
+
+// Format the polynomial, e.g. "1 + 2x + 3x^2"
+func (p Polynomial) String() string {
+	var terms []string
+	for i, c := range p.Coef.Array() {
+		if c == 0 && p.Coef.Size() > 1 {
+			continue
+		}
+		switch i {
+		case 0:
+			terms = append(terms, fmt.Sprintf("%g", c))
+		case 1:
+			terms = append(terms, fmt.Sprintf("%gx", c))
+		default:
+			terms = append(terms, fmt.Sprintf("%gx^%d", c, i))
+		}
+	}
+	if len(terms) == 0 {
+		return "0"
+	}
+	return strings.Replace(strings.Join(terms, " + "), "+ -", "- ", -1)
+}
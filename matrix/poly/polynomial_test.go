@@ -0,0 +1,118 @@
+package poly
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math/cmplx"
+	"sort"
+	"testing"
+)
+
+const Eps = 1e-9
+
+// Sort complex values by real part, then imaginary part
+func sortComplex(values []complex128) []complex128 {
+	sort.Slice(values, func(i, j int) bool {
+		if real(values[i]) != real(values[j]) {
+			return real(values[i]) < real(values[j])
+		}
+		return imag(values[i]) < imag(values[j])
+	})
+	return values
+}
+
+func TestPolynomial(t *testing.T) {
+	Convey("Given a polynomial", t, func() {
+		p := New(1, 2, 3)
+
+		Convey("It evaluates at points and over arrays", func() {
+			So(p.At(2), ShouldEqual, 17)
+			So(p.Degree(), ShouldEqual, 2)
+			y := p.Eval(matrix.A2([]float64{0, 1}, []float64{-1, 2}))
+			So(y.Equal(matrix.A2([]float64{1, 6}, []float64{2, 17})), ShouldBeTrue)
+			So(p.Eval(matrix.SparseCoo(2, 2)).Equal(matrix.WithValue(1, 2, 2)), ShouldBeTrue)
+		})
+
+		Convey("FromCoef copies a 1D array", func() {
+			coef := matrix.A1(1, 2, 3)
+			q := FromCoef(coef)
+			coef.FlatItemSet(5, 0)
+			So(q.Coef.Equal(p.Coef), ShouldBeTrue)
+			So(func() { FromCoef(matrix.Dense(2, 2)) }, ShouldPanic)
+		})
+
+		Convey("Arithmetic gives the expected coefficients", func() {
+			So(p.Add(New(1, 1)).Coef.Equal(matrix.A1(2, 3, 3)), ShouldBeTrue)
+			So(p.Sub(New(1, 2, 3)).Coef.Equal(matrix.A1(0)), ShouldBeTrue)
+			So(p.Scale(2).Coef.Equal(matrix.A1(2, 4, 6)), ShouldBeTrue)
+			So(p.Mul(New(-1, 1)).Coef.Equal(matrix.A1(-1, -1, -1, 3)), ShouldBeTrue)
+		})
+
+		Convey("Div undoes Mul, leaving the remainder", func() {
+			q, r := p.Mul(New(-1, 1)).Add(New(4)).Div(New(-1, 1))
+			So(q.Coef.Equal(p.Coef), ShouldBeTrue)
+			So(r.Coef.Equal(matrix.A1(4)), ShouldBeTrue)
+
+			q, r = New(1, 1).Div(p)
+			So(q.Coef.Equal(matrix.A1(0)), ShouldBeTrue)
+			So(r.Coef.Equal(matrix.A1(1, 1)), ShouldBeTrue)
+			So(func() { p.Div(New(0, 0)) }, ShouldPanic)
+		})
+
+		Convey("Derivative and Integral are inverses", func() {
+			So(p.Derivative(1).Coef.Equal(matrix.A1(2, 6)), ShouldBeTrue)
+			So(p.Derivative(2).Coef.Equal(matrix.A1(6)), ShouldBeTrue)
+			So(p.Derivative(5).Coef.Equal(matrix.A1(0)), ShouldBeTrue)
+			So(p.Integral(1, 4).Coef.Equal(matrix.A1(4, 1, 1, 1)), ShouldBeTrue)
+			So(p.Integral(2, 0).Derivative(2).Coef.Equal(p.Coef), ShouldBeTrue)
+		})
+
+		Convey("String formats the terms", func() {
+			So(p.String(), ShouldEqual, "1 + 2x + 3x^2")
+			So(New(0, -1).String(), ShouldEqual, "-1x")
+			So(New().String(), ShouldEqual, "0")
+		})
+	})
+
+	Convey("Given polynomials with known roots", t, func() {
+		Convey("Real roots are found", func() {
+			roots := sortComplex(New(-6, 11, -6, 1).Roots())
+			So(len(roots), ShouldEqual, 3)
+			for i, root := range roots {
+				So(cmplx.Abs(root-complex(float64(i+1), 0)), ShouldBeLessThan, 1e-9)
+			}
+		})
+
+		Convey("Complex roots come in conjugate pairs", func() {
+			roots := sortComplex(New(1, 0, 1).Roots())
+			So(cmplx.Abs(roots[0]-complex(0, -1)), ShouldBeLessThan, Eps)
+			So(cmplx.Abs(roots[1]-complex(0, 1)), ShouldBeLessThan, Eps)
+		})
+
+		Convey("Zero and low-degree roots are handled", func() {
+			So(New(0, 0, 2).Roots(), ShouldResemble, []complex128{0, 0})
+			So(New(3, 2).Roots(), ShouldResemble, []complex128{-1.5})
+			So(New(0, 3, 2).Roots(), ShouldResemble, []complex128{0, -1.5})
+			So(New(5).Roots(), ShouldBeEmpty)
+			So(func() { New(0).Roots() }, ShouldPanic)
+		})
+
+		Convey("Roots of a higher degree polynomial evaluate to zero", func() {
+			p := New(1)
+			for _, root := range []float64{-3, -1.5, 0.25, 1, 2, 4, 7} {
+				p = p.Mul(New(-root, 1))
+			}
+			p = p.Mul(New(2, 2, 1))
+			roots := p.Roots()
+			So(len(roots), ShouldEqual, 9)
+			for _, root := range roots {
+				var val complex128
+				coef := p.Coef.Array()
+				for i := len(coef) - 1; i >= 0; i-- {
+					val = val*root + complex(coef[i], 0)
+				}
+				So(cmplx.Abs(val), ShouldBeLessThan, 1e-6)
+			}
+		})
+	})
+}
@@ -0,0 +1,301 @@
+package random
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+)
+
+// Create a dense NDArray of values uniformly distributed in [low, high)
+func (g *Generator) Uniform(low, high float64, size ...int) matrix.NDArray {
+	if high < low {
+		panic(fmt.Sprintf("Uniform: high %f must not be less than low %f", high, low))
+	}
+	return g.fill(func() float64 {
+		return low + (high-low)*g.rng.Float64()
+	}, size...)
+}
+
+// Create a dense NDArray of integer values uniformly distributed in
+// [low, high)
+func (g *Generator) Integers(low, high int, size ...int) matrix.NDArray {
+	if high <= low {
+		panic(fmt.Sprintf("Integers: high %d must be greater than low %d", high, low))
+	}
+	return g.fill(func() float64 {
+		return float64(low + g.rng.Intn(high-low))
+	}, size...)
+}
+
+// Create a dense NDArray of values drawn from the Normal distribution with
+// the given mean and standard deviation
+func (g *Generator) Normal(mean, stddev float64, size ...int) matrix.NDArray {
+	if stddev < 0 {
+		panic(fmt.Sprintf("Normal: stddev %f must be nonnegative", stddev))
+	}
+	return g.fill(func() float64 {
+		return mean + stddev*g.rng.NormFloat64()
+	}, size...)
+}
+
+// Create a dense NDArray of values drawn from the Binomial distribution: the
+// number of successes in n trials with success probability p
+func (g *Generator) Binomial(n int, p float64, size ...int) matrix.NDArray {
+	if n < 0 || p < 0 || p > 1 {
+		panic(fmt.Sprintf("Binomial: invalid parameters n=%d, p=%f", n, p))
+	}
+	return g.fill(func() float64 {
+		return float64(g.binomial(n, p))
+	}, size...)
+}
+
+// Draw a single Binomial value. Large n is reduced recursively using the
+// order statistics of a Beta draw, as described by Knuth (TAOCP 3.4.1).
+func (g *Generator) binomial(n int, p float64) int {
+	count := 0
+	for n > 40 {
+		a := 1 + n/2
+		b := n + 1 - a
+		x := g.beta(float64(a), float64(b))
+		if x >= p {
+			n = a - 1
+			p /= x
+		} else {
+			count += a
+			n = b - 1
+			p = (p - x) / (1 - x)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if g.rng.Float64() < p {
+			count++
+		}
+	}
+	return count
+}
+
+// Create a dense NDArray of values drawn from the Poisson distribution with
+// rate lambda
+func (g *Generator) Poisson(lambda float64, size ...int) matrix.NDArray {
+	if lambda < 0 {
+		panic(fmt.Sprintf("Poisson: lambda %f must be nonnegative", lambda))
+	}
+	return g.fill(func() float64 {
+		return float64(g.poisson(lambda))
+	}, size...)
+}
+
+// Draw a single Poisson value. Small rates use Knuth's multiplication
+// method, and large rates use Hörmann's transformed rejection (PTRS).
+func (g *Generator) poisson(lambda float64) int {
+	if lambda < 10 {
+		limit, prod, k := math.Exp(-lambda), g.rng.Float64(), 0
+		for prod > limit {
+			prod *= g.rng.Float64()
+			k++
+		}
+		return k
+	}
+
+	slam := math.Sqrt(lambda)
+	loglam := math.Log(lambda)
+	b := 0.931 + 2.53*slam
+	a := -0.059 + 0.02483*b
+	invalpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2)
+	for {
+		u := g.rng.Float64() - 0.5
+		v := g.rng.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return int(k)
+		}
+		if k < 0 || (us < 0.013 && v > us) {
+			continue
+		}
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invalpha)-math.Log(a/(us*us)+b) <= -lambda+k*loglam-lg {
+			return int(k)
+		}
+	}
+}
+
+// Create a dense NDArray of values drawn from the Exponential distribution
+// with the given scale (the inverse of the rate)
+func (g *Generator) Exponential(scale float64, size ...int) matrix.NDArray {
+	if scale < 0 {
+		panic(fmt.Sprintf("Exponential: scale %f must be nonnegative", scale))
+	}
+	return g.fill(func() float64 {
+		return scale * g.rng.ExpFloat64()
+	}, size...)
+}
+
+// Create a dense NDArray of values drawn from the Gamma distribution with the
+// given shape and scale
+func (g *Generator) Gamma(shape, scale float64, size ...int) matrix.NDArray {
+	if shape <= 0 || scale <= 0 {
+		panic(fmt.Sprintf("Gamma: invalid parameters shape=%f, scale=%f", shape, scale))
+	}
+	return g.fill(func() float64 {
+		return scale * g.gamma(shape)
+	}, size...)
+}
+
+// Draw a single Gamma value with unit scale, using the method of Marsaglia
+// and Tsang
+func (g *Generator) gamma(shape float64) float64 {
+	if shape < 1 {
+		return g.gamma(shape+1) * math.Pow(g.rng.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := g.rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := g.rng.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// Create a dense NDArray of values drawn from the Beta distribution
+func (g *Generator) Beta(a, b float64, size ...int) matrix.NDArray {
+	if a <= 0 || b <= 0 {
+		panic(fmt.Sprintf("Beta: invalid parameters a=%f, b=%f", a, b))
+	}
+	return g.fill(func() float64 {
+		return g.beta(a, b)
+	}, size...)
+}
+
+// Draw a single Beta value as a ratio of Gamma values
+func (g *Generator) beta(a, b float64) float64 {
+	x := g.gamma(a)
+	y := g.gamma(b)
+	return x / (x + y)
+}
+
+// Create a matrix with n rows, each drawn from the Dirichlet distribution
+// with the given concentration parameters. Each row sums to 1.
+func (g *Generator) Dirichlet(alpha matrix.NDArray, n int) matrix.Matrix {
+	if !alpha.AllF(func(v float64) bool { return v > 0 }) {
+		panic(fmt.Sprintf("Dirichlet: concentration %v must be positive", alpha))
+	}
+	a := alpha.Ravel().Array()
+	result := matrix.Dense(n, len(a)).M()
+	row := make([]float64, len(a))
+	for i := 0; i < n; i++ {
+		sum := 0.0
+		for j := range a {
+			row[j] = g.gamma(a[j])
+			sum += row[j]
+		}
+		for j := range row {
+			row[j] /= sum
+		}
+		result.RowSet(i, row)
+	}
+	return result
+}
+
+// Create a matrix with n rows, each giving the number of times each outcome
+// occurred in trials draws from the categorical distribution pvals. The
+// probabilities must sum to 1 (within rounding error); a vector can be
+// prepared with Normalize().
+func (g *Generator) Multinomial(trials int, pvals matrix.NDArray, n int) matrix.Matrix {
+	p := pvals.Ravel().Array()
+	if trials < 0 || !pvals.AllF(func(v float64) bool { return v >= 0 }) || math.Abs(pvals.Sum()-1) > 1e-8 {
+		panic(fmt.Sprintf("Multinomial: invalid parameters trials=%d, pvals=%v", trials, pvals))
+	}
+	result := matrix.Dense(n, len(p)).M()
+	row := make([]float64, len(p))
+	for i := 0; i < n; i++ {
+		// Draw each count conditioned on the counts before it
+		remaining, rest := trials, 1.0
+		for j := range p {
+			if j == len(p)-1 {
+				row[j] = float64(remaining)
+				break
+			}
+			k := 0
+			if remaining > 0 && rest > 0 {
+				k = g.binomial(remaining, math.Min(1, p[j]/rest))
+			}
+			row[j] = float64(k)
+			remaining -= k
+			rest -= p[j]
+		}
+		result.RowSet(i, row)
+	}
+	return result
+}
+
+// Create a matrix with n rows, each drawn from the multivariate Normal
+// distribution with the given mean vector and covariance matrix. The
+// covariance matrix must be symmetric and positive semi-definite.
+func (g *Generator) MultivariateNormal(mean matrix.NDArray, cov matrix.Matrix, n int) matrix.Matrix {
+	d := mean.Size()
+	if cov.Rows() != d || cov.Cols() != d {
+		panic(fmt.Sprintf("MultivariateNormal: covariance shape %v doesn't match mean of size %d", cov.Shape(), d))
+	}
+	l := cholesky(cov)
+	mu := mean.Ravel().Array()
+	result := matrix.Dense(n, d).M()
+	z := make([]float64, d)
+	row := make([]float64, d)
+	for i := 0; i < n; i++ {
+		for j := range z {
+			z[j] = g.rng.NormFloat64()
+		}
+		for j := 0; j < d; j++ {
+			v := mu[j]
+			for k := 0; k <= j; k++ {
+				v += l[j*d+k] * z[k]
+			}
+			row[j] = v
+		}
+		result.RowSet(i, row)
+	}
+	return result
+}
+
+// Get the lower triangular Cholesky factor of a symmetric positive
+// semi-definite matrix, as a flat row-major slice. Panics if the matrix is
+// not positive semi-definite.
+func cholesky(m matrix.Matrix) []float64 {
+	d := m.Rows()
+	a := m.Array()
+	l := make([]float64, d*d)
+	tol := 1e-10 * math.Max(1, m.Max())
+	for j := 0; j < d; j++ {
+		sum := a[j*d+j]
+		for k := 0; k < j; k++ {
+			sum -= l[j*d+k] * l[j*d+k]
+		}
+		if sum < -tol {
+			panic("MultivariateNormal: covariance matrix is not positive semi-definite")
+		} else if sum < 0 {
+			sum = 0
+		}
+		l[j*d+j] = math.Sqrt(sum)
+		for i := j + 1; i < d; i++ {
+			v := a[i*d+j]
+			for k := 0; k < j; k++ {
+				v -= l[i*d+k] * l[j*d+k]
+			}
+			if l[j*d+j] > 0 {
+				l[i*d+j] = v / l[j*d+j]
+			} else if math.Abs(v) > tol {
+				panic("MultivariateNormal: covariance matrix is not positive semi-definite")
+			}
+		}
+	}
+	return l
+}
@@ -0,0 +1,149 @@
+package random
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+// The number of draws used to check sample statistics
+const samples = 20000
+
+// Get the sample mean and variance of an array
+func meanVar(array matrix.NDArray) (float64, float64) {
+	mean := array.Sum() / float64(array.Size())
+	variance := array.ItemSub(mean).Apply(func(v float64) float64 {
+		return v * v
+	}).Sum() / float64(array.Size()-1)
+	return mean, variance
+}
+
+// Assert that sample moments are close to the expected moments, relative to
+// the standard error of the mean
+func shouldHaveMoments(array matrix.NDArray, mean, variance float64) {
+	m, v := meanVar(array)
+	se := math.Sqrt(variance / float64(array.Size()))
+	So(m, ShouldAlmostEqual, mean, 5*se)
+	So(v, ShouldAlmostEqual, variance, 0.1*variance)
+}
+
+func TestScalarDistributions(t *testing.T) {
+	Convey("Given a seeded generator", t, func() {
+		g := NewSeeded(11)
+
+		Convey("Uniform has the right range and moments", func() {
+			a := g.Uniform(-2, 6, samples)
+			So(a.Min(), ShouldBeGreaterThanOrEqualTo, -2)
+			So(a.Max(), ShouldBeLessThan, 6)
+			shouldHaveMoments(a, 2, 64.0/12)
+			So(func() { g.Uniform(1, 0, 3) }, ShouldPanic)
+		})
+
+		Convey("Integers are whole numbers in range", func() {
+			a := g.Integers(3, 7, 2, 500)
+			So(a.Shape(), ShouldResemble, []int{2, 500})
+			So(a.AllF(func(v float64) bool {
+				return v == math.Floor(v) && v >= 3 && v < 7
+			}), ShouldBeTrue)
+			So(a.Min(), ShouldEqual, 3)
+			So(a.Max(), ShouldEqual, 6)
+			So(func() { g.Integers(3, 3, 1) }, ShouldPanic)
+		})
+
+		Convey("Normal has the right moments", func() {
+			shouldHaveMoments(g.Normal(5, 2, samples), 5, 4)
+		})
+
+		Convey("Binomial has the right moments for small and large n", func() {
+			shouldHaveMoments(g.Binomial(10, 0.3, samples), 3, 2.1)
+			shouldHaveMoments(g.Binomial(1000, 0.2, samples), 200, 160)
+			So(g.Binomial(50, 0, 10).Any(), ShouldBeFalse)
+			So(func() { g.Binomial(5, 1.5, 1) }, ShouldPanic)
+		})
+
+		Convey("Poisson has the right moments for small and large rates", func() {
+			shouldHaveMoments(g.Poisson(3, samples), 3, 3)
+			shouldHaveMoments(g.Poisson(250, samples), 250, 250)
+			So(func() { g.Poisson(-1, 1) }, ShouldPanic)
+		})
+
+		Convey("Exponential has the right moments", func() {
+			a := g.Exponential(2, samples)
+			So(a.Min(), ShouldBeGreaterThanOrEqualTo, 0)
+			shouldHaveMoments(a, 2, 4)
+		})
+
+		Convey("Gamma has the right moments for small and large shapes", func() {
+			shouldHaveMoments(g.Gamma(0.5, 2, samples), 1, 2)
+			shouldHaveMoments(g.Gamma(9, 0.5, samples), 4.5, 2.25)
+			So(func() { g.Gamma(0, 1, 1) }, ShouldPanic)
+		})
+
+		Convey("Beta has the right moments", func() {
+			a := g.Beta(2, 5, samples)
+			So(a.Min(), ShouldBeGreaterThanOrEqualTo, 0)
+			So(a.Max(), ShouldBeLessThanOrEqualTo, 1)
+			shouldHaveMoments(a, 2.0/7, 10.0/(49*8))
+		})
+	})
+}
+
+func TestVectorDistributions(t *testing.T) {
+	Convey("Given a seeded generator", t, func() {
+		g := NewSeeded(12)
+
+		Convey("Dirichlet rows sum to one", func() {
+			m := g.Dirichlet(matrix.A1(1, 2, 3), 1000)
+			So(m.Shape(), ShouldResemble, []int{1000, 3})
+			for i := 0; i < m.Rows(); i++ {
+				sum := 0.0
+				for _, v := range m.Row(i) {
+					sum += v
+				}
+				So(sum, ShouldAlmostEqual, 1, 1e-12)
+			}
+			mean := matrix.Ones(1, 1000).M().MProd(m).ItemDiv(1000)
+			So(mean.FlatItem(0), ShouldAlmostEqual, 1.0/6, 0.01)
+			So(mean.FlatItem(2), ShouldAlmostEqual, 0.5, 0.01)
+			So(func() { g.Dirichlet(matrix.A1(1, 0), 1) }, ShouldPanic)
+		})
+
+		Convey("Multinomial rows sum to the number of trials", func() {
+			m := g.Multinomial(20, matrix.A1(1, 1, 2).Normalize(), 2000)
+			So(m.Shape(), ShouldResemble, []int{2000, 3})
+			for i := 0; i < m.Rows(); i++ {
+				sum := 0.0
+				for _, v := range m.Row(i) {
+					sum += v
+				}
+				So(sum, ShouldEqual, 20)
+			}
+			mean := matrix.Ones(1, 2000).M().MProd(m).ItemDiv(2000)
+			So(mean.FlatItem(0), ShouldAlmostEqual, 5, 0.2)
+			So(mean.FlatItem(2), ShouldAlmostEqual, 10, 0.2)
+			So(func() { g.Multinomial(5, matrix.A1(0.5, 0.6), 1) }, ShouldPanic)
+		})
+
+		Convey("MultivariateNormal has the right mean and covariance", func() {
+			cov := matrix.M(2, 2,
+				2, 0.8,
+				0.8, 1)
+			m := g.MultivariateNormal(matrix.A1(1, -1), cov, samples)
+			So(m.Shape(), ShouldResemble, []int{samples, 2})
+			x := matrix.A1(m.Col(0)...)
+			y := matrix.A1(m.Col(1)...)
+			shouldHaveMoments(x, 1, 2)
+			shouldHaveMoments(y, -1, 1)
+			mx, _ := meanVar(x)
+			my, _ := meanVar(y)
+			c := x.ItemSub(mx).Prod(y.ItemSub(my)).Sum() / (samples - 1)
+			So(c, ShouldAlmostEqual, 0.8, 0.05)
+		})
+
+		Convey("MultivariateNormal rejects invalid covariance matrices", func() {
+			So(func() { g.MultivariateNormal(matrix.A1(0, 0), matrix.Eye(3), 1) }, ShouldPanic)
+			So(func() { g.MultivariateNormal(matrix.A1(0, 0), matrix.M(2, 2, 1, 2, 2, 1), 1) }, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package random
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math/rand"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	Convey("Generators with the same seed produce the same arrays", t, func() {
+		g1 := NewSeeded(42)
+		g2 := New(rand.NewSource(42))
+		So(g1.Rand(3, 4).Equal(g2.Rand(3, 4)), ShouldBeTrue)
+		So(g1.RandN(5).Equal(g2.RandN(5)), ShouldBeTrue)
+		So(g1.SparseRand(10, 10, 0.3).Equal(g2.SparseRand(10, 10, 0.3)), ShouldBeTrue)
+	})
+
+	Convey("Generators with different seeds produce different arrays", t, func() {
+		So(NewSeeded(1).Rand(10).Equal(NewSeeded(2).Rand(10)), ShouldBeFalse)
+	})
+
+	Convey("Rng exposes the underlying source", t, func() {
+		g1, g2 := NewSeeded(7), NewSeeded(7)
+		So(g1.Rng().Float64(), ShouldEqual, g2.Rand(1).FlatItem(0))
+	})
+}
+
+func TestGeneratorRand(t *testing.T) {
+	Convey("Given a random array with shape 5, 3", t, func() {
+		array := NewSeeded(1).Rand(5, 3)
+
+		Convey("Shape() is (5, 3)", func() {
+			So(array.Shape(), ShouldResemble, []int{5, 3})
+		})
+
+		Convey("All values are in [0, 1)", func() {
+			for i := 0; i < array.Size(); i++ {
+				So(array.FlatItem(i), ShouldBeBetweenOrEqual, 0, 1)
+				So(array.FlatItem(i), ShouldBeLessThan, 1)
+			}
+		})
+	})
+
+	Convey("Given a random normal array with shape 5, 3", t, func() {
+		array := NewSeeded(1).RandN(5, 3)
+
+		Convey("Shape() is (5, 3)", func() {
+			So(array.Shape(), ShouldResemble, []int{5, 3})
+		})
+
+		Convey("All values are nonzero", func() {
+			So(array.All(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGeneratorSparseRand(t *testing.T) {
+	Convey("Given a generator", t, func() {
+		g := NewSeeded(3)
+
+		Convey("SparseRand fills the expected number of cells", func() {
+			m := g.SparseRand(20, 30, 0.25)
+			So(m.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(m.Shape(), ShouldResemble, []int{20, 30})
+			So(m.CountNonzero(), ShouldEqual, 150)
+			So(m.Max(), ShouldBeLessThan, 1)
+			So(m.Min(), ShouldBeGreaterThanOrEqualTo, 0)
+		})
+
+		Convey("SparseRandN can fill every cell", func() {
+			m := g.SparseRandN(7, 9, 1)
+			So(m.CountNonzero(), ShouldEqual, 63)
+		})
+
+		Convey("SparseRandOpts draws from the generator", func() {
+			opts := matrix.SparseRandOptions{
+				Pattern:   matrix.SparsePatternBanded,
+				Bandwidth: 2,
+			}
+			m1 := NewSeeded(5).SparseRandOpts(10, 10, 0.5, opts)
+			m2 := NewSeeded(5).SparseRandOpts(10, 10, 0.5, opts)
+			So(m1.Equal(m2), ShouldBeTrue)
+			So(m1.CountNonzero(), ShouldEqual, 22)
+		})
+
+		Convey("SparseRand panics given an invalid density", func() {
+			So(func() { g.SparseRand(3, 3, -0.1) }, ShouldPanic)
+			So(func() { g.SparseRand(3, 3, 1.1) }, ShouldPanic)
+		})
+	})
+}
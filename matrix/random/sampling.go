@@ -0,0 +1,261 @@
+package random
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"sort"
+)
+
+// Get a random permutation of the integers [0, n)
+func (g *Generator) Permutation(n int) []int {
+	return g.rng.Perm(n)
+}
+
+// Shuffle an array in place along the given axis, so that the slices along
+// that axis are reordered but each slice's contents are unchanged. For
+// instance, axis 0 shuffles the rows of a matrix. Dense and sparse coo arrays
+// are supported; sparse diagonal matrices can't hold a shuffled matrix and
+// will panic.
+func (g *Generator) Shuffle(array matrix.NDArray, axis int) {
+	shape := array.Shape()
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Shuffle: axis %d is invalid for shape %v", axis, shape))
+	}
+	switch array.Sparsity() {
+	case matrix.DenseArray:
+		outer, inner := 1, 1
+		for _, sz := range shape[:axis] {
+			outer *= sz
+		}
+		for _, sz := range shape[axis+1:] {
+			inner *= sz
+		}
+		n := shape[axis]
+		for i := n - 1; i > 0; i-- {
+			j := g.rng.Intn(i + 1)
+			if i == j {
+				continue
+			}
+			for o := 0; o < outer; o++ {
+				for k := 0; k < inner; k++ {
+					a := (o*n+i)*inner + k
+					b := (o*n+j)*inner + k
+					va, vb := array.FlatItem(a), array.FlatItem(b)
+					array.FlatItemSet(vb, a)
+					array.FlatItemSet(va, b)
+				}
+			}
+		}
+
+	case matrix.SparseCooMatrix:
+		type entry struct {
+			pos   [2]int
+			value float64
+		}
+		var entries []entry
+		array.VisitNonzero(func(pos []int, value float64) bool {
+			entries = append(entries, entry{[2]int{pos[0], pos[1]}, value})
+			return true
+		})
+		for _, e := range entries {
+			array.ItemSet(0, e.pos[0], e.pos[1])
+		}
+		perm := g.Permutation(shape[axis])
+		for _, e := range entries {
+			e.pos[axis] = perm[e.pos[axis]]
+			array.ItemSet(e.value, e.pos[0], e.pos[1])
+		}
+
+	default:
+		panic("Shuffle: can't shuffle a sparse diagonal matrix")
+	}
+}
+
+// Draw size indices from [0, n). If p is nil, indices are equally likely;
+// otherwise p gives the probability of each index and must sum to 1, as
+// Normalize() ensures. Weighted draws with replacement use Walker's alias
+// method, so each draw takes constant time. Without replacement, no index
+// is drawn twice, and size can't exceed the number of possible indices.
+func (g *Generator) Choice(n, size int, replace bool, p matrix.NDArray) []int {
+	if n < 0 || size < 0 {
+		panic(fmt.Sprintf("Choice: invalid parameters n=%d, size=%d", n, size))
+	}
+	var weights []float64
+	if p != nil {
+		weights = p.Ravel().Array()
+		if len(weights) != n || !p.AllF(func(v float64) bool { return v >= 0 }) || math.Abs(p.Sum()-1) > 1e-8 {
+			panic(fmt.Sprintf("Choice: p=%v is not a distribution over %d items", p, n))
+		}
+	}
+	result := make([]int, size)
+	switch {
+	case replace && p == nil:
+		if n == 0 && size > 0 {
+			panic("Choice: can't choose from zero items")
+		}
+		for i := range result {
+			result[i] = g.rng.Intn(n)
+		}
+
+	case replace:
+		table := newAliasTable(weights)
+		for i := range result {
+			result[i] = table.draw(g)
+		}
+
+	case p == nil:
+		if size > n {
+			panic(fmt.Sprintf("Choice: can't choose %d of %d items without replacement", size, n))
+		}
+		// A partial Fisher-Yates shuffle, storing only the swapped items
+		swapped := make(map[int]int, size)
+		for i := range result {
+			j := i + g.rng.Intn(n-i)
+			vi, ok := swapped[i]
+			if !ok {
+				vi = i
+			}
+			vj, ok := swapped[j]
+			if !ok {
+				vj = j
+			}
+			result[i] = vj
+			swapped[j] = vi
+		}
+
+	default:
+		// Efraimidis-Spirakis: keep the items with the largest keys u^(1/w)
+		type keyed struct {
+			key   float64
+			index int
+		}
+		var keys []keyed
+		for i, w := range weights {
+			if w > 0 {
+				keys = append(keys, keyed{math.Log(g.rng.Float64()) / w, i})
+			}
+		}
+		if size > len(keys) {
+			panic(fmt.Sprintf("Choice: can't choose %d of %d possible items without replacement", size, len(keys)))
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+		for i := range result {
+			result[i] = keys[i].index
+		}
+	}
+	return result
+}
+
+// Draw size values from the elements of array, as Choice does for indices.
+// The array is read in flattened 'C' order, and the result is a 1D array.
+func (g *Generator) ChoiceFrom(array matrix.NDArray, size int, replace bool, p matrix.NDArray) matrix.NDArray {
+	idx := g.Choice(array.Size(), size, replace, p)
+	result := matrix.Dense(size)
+	for i, j := range idx {
+		result.FlatItemSet(array.FlatItem(j), i)
+	}
+	return result
+}
+
+// Randomly partition the rows of a matrix into a training set and a test set
+// holding the fraction testFraction of the rows, rounded up. Also returns the
+// row indices of m which were placed in each set, in the order they appear,
+// so that labels can be split to match. Dense matrices produce dense sets,
+// and sparse matrices produce sparse coo sets.
+func (g *Generator) TrainTestSplit(m matrix.Matrix, testFraction float64) (train, test matrix.Matrix, trainRows, testRows []int) {
+	if testFraction < 0 || testFraction > 1 {
+		panic(fmt.Sprintf("TrainTestSplit: testFraction %f should be in [0, 1]", testFraction))
+	}
+	perm := g.Permutation(m.Rows())
+	numTest := int(math.Ceil(testFraction * float64(m.Rows())))
+	testRows, trainRows = perm[:numTest], perm[numTest:]
+	return TakeRows(m, trainRows), TakeRows(m, testRows), trainRows, testRows
+}
+
+// Create a matrix whose ith row is row rows[i] of m. Rows may be repeated,
+// so this can build bootstrap resamples from the indices drawn by Choice.
+// Dense matrices produce a dense result, and sparse matrices produce a sparse
+// coo result.
+func TakeRows(m matrix.Matrix, rows []int) matrix.Matrix {
+	cols := m.Cols()
+	for _, r := range rows {
+		if r < 0 || r >= m.Rows() {
+			panic(fmt.Sprintf("TakeRows: row %d is invalid for shape %v", r, m.Shape()))
+		}
+	}
+	if m.Sparsity() == matrix.DenseArray {
+		result := matrix.Dense(len(rows), cols)
+		for i, r := range rows {
+			for j := 0; j < cols; j++ {
+				result.FlatItemSet(m.FlatItem(r*cols+j), i*cols+j)
+			}
+		}
+		return result.M()
+	}
+
+	dest := make(map[int][]int, len(rows))
+	for i, r := range rows {
+		dest[r] = append(dest[r], i)
+	}
+	result := matrix.SparseCoo(len(rows), cols)
+	m.VisitNonzero(func(pos []int, value float64) bool {
+		for _, i := range dest[pos[0]] {
+			result.ItemSet(value, i, pos[1])
+		}
+		return true
+	})
+	return result
+}
+
+// A table for drawing from a discrete distribution in constant time, built
+// with Vose's variant of Walker's alias method
+type aliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+// Build an alias table for the given probabilities, which sum to 1
+func newAliasTable(p []float64) *aliasTable {
+	n := len(p)
+	table := &aliasTable{
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, v := range p {
+		scaled[i] = v * float64(n)
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		table.prob[s] = scaled[s]
+		table.alias[s] = l
+		scaled[l] += scaled[s] - 1
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// Anything left over has probability 1, up to rounding error
+	for _, i := range append(small, large...) {
+		table.prob[i] = 1
+		table.alias[i] = i
+	}
+	return table
+}
+
+// Draw an index from the table
+func (table *aliasTable) draw(g *Generator) int {
+	i := g.rng.Intn(len(table.prob))
+	if g.rng.Float64() < table.prob[i] {
+		return i
+	}
+	return table.alias[i]
+}
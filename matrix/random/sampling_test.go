@@ -0,0 +1,234 @@
+package random
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"sort"
+	"testing"
+)
+
+func TestPermutation(t *testing.T) {
+	Convey("Permutation contains each index once", t, func() {
+		perm := NewSeeded(1).Permutation(10)
+		sorted := append([]int{}, perm...)
+		sort.Ints(sorted)
+		So(sorted, ShouldResemble, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	})
+}
+
+func TestShuffle(t *testing.T) {
+	Convey("Given a seeded generator", t, func() {
+		g := NewSeeded(2)
+
+		Convey("Shuffling the rows of a dense matrix keeps each row intact", func() {
+			m := matrix.M(4, 3,
+				0, 1, 2,
+				10, 11, 12,
+				20, 21, 22,
+				30, 31, 32)
+			g.Shuffle(m, 0)
+			So(m.Sum(), ShouldEqual, 192)
+			seen := make(map[float64]bool)
+			for i := 0; i < 4; i++ {
+				base := m.Item(i, 0)
+				So(m.Item(i, 1), ShouldEqual, base+1)
+				So(m.Item(i, 2), ShouldEqual, base+2)
+				seen[base] = true
+			}
+			So(len(seen), ShouldEqual, 4)
+		})
+
+		Convey("Shuffling the columns of a transposed matrix keeps each column intact", func() {
+			m := matrix.M(3, 5,
+				0, 1, 2, 3, 4,
+				10, 11, 12, 13, 14,
+				20, 21, 22, 23, 24).T()
+			g.Shuffle(m, 1)
+			for j := 0; j < 3; j++ {
+				base := m.Item(0, j)
+				So(base, ShouldBeIn, 0.0, 10.0, 20.0)
+				for i := 1; i < 5; i++ {
+					So(m.Item(i, j), ShouldEqual, base+float64(i))
+				}
+			}
+		})
+
+		Convey("Shuffling the middle axis of a 3D array moves whole slices", func() {
+			a := matrix.Dense(2, 20, 2)
+			for i := 0; i < a.Size(); i++ {
+				a.FlatItemSet(float64(i), i)
+			}
+			orig := a.Copy()
+			g.Shuffle(a, 1)
+			So(a.Equal(orig), ShouldBeFalse)
+			for i := 0; i < 2; i++ {
+				for j := 0; j < 20; j++ {
+					So(a.Item(i, j, 1), ShouldEqual, a.Item(i, j, 0)+1)
+					So(a.Item(1, j, 0), ShouldEqual, a.Item(0, j, 0)+40)
+				}
+			}
+		})
+
+		Convey("Shuffling a sparse coo matrix keeps it sparse", func() {
+			m := matrix.SparseCoo(20, 3)
+			for i := 0; i < 20; i++ {
+				m.ItemSet(float64(i+1), i, i%3)
+			}
+			g.Shuffle(m, 0)
+			So(m.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(m.CountNonzero(), ShouldEqual, 20)
+			So(m.Sum(), ShouldEqual, 210)
+			moved := false
+			m.VisitNonzero(func(pos []int, value float64) bool {
+				So(pos[1], ShouldEqual, (int(value)-1)%3)
+				if pos[0] != int(value)-1 {
+					moved = true
+				}
+				return true
+			})
+			So(moved, ShouldBeTrue)
+		})
+
+		Convey("Invalid axes and sparse diagonal matrices panic", func() {
+			So(func() { g.Shuffle(matrix.Dense(3, 3), 2) }, ShouldPanic)
+			So(func() { g.Shuffle(matrix.Eye(3), 0) }, ShouldPanic)
+		})
+	})
+}
+
+func TestChoice(t *testing.T) {
+	Convey("Given a seeded generator", t, func() {
+		g := NewSeeded(3)
+
+		Convey("Uniform draws with replacement cover the range", func() {
+			counts := make([]int, 5)
+			for _, i := range g.Choice(5, samples, true, nil) {
+				counts[i]++
+			}
+			for _, c := range counts {
+				So(float64(c)/samples, ShouldAlmostEqual, 0.2, 0.02)
+			}
+		})
+
+		Convey("Weighted draws with replacement follow the weights", func() {
+			p := matrix.A1(1, 0, 3, 6).Normalize()
+			counts := make([]int, 4)
+			for _, i := range g.Choice(4, samples, true, p) {
+				counts[i]++
+			}
+			So(counts[1], ShouldEqual, 0)
+			So(float64(counts[0])/samples, ShouldAlmostEqual, 0.1, 0.02)
+			So(float64(counts[2])/samples, ShouldAlmostEqual, 0.3, 0.02)
+			So(float64(counts[3])/samples, ShouldAlmostEqual, 0.6, 0.02)
+		})
+
+		Convey("Draws without replacement are distinct", func() {
+			idx := g.Choice(1000, 100, false, nil)
+			seen := make(map[int]bool)
+			for _, i := range idx {
+				So(i, ShouldBeBetweenOrEqual, 0, 999)
+				seen[i] = true
+			}
+			So(len(seen), ShouldEqual, 100)
+
+			all := g.Choice(6, 6, false, nil)
+			sort.Ints(all)
+			So(all, ShouldResemble, []int{0, 1, 2, 3, 4, 5})
+		})
+
+		Convey("Weighted draws without replacement are distinct and skip zero weights", func() {
+			p := matrix.A1(0.5, 0, 0.3, 0.2)
+			for trial := 0; trial < 20; trial++ {
+				idx := g.Choice(4, 3, false, p)
+				sort.Ints(idx)
+				So(idx, ShouldResemble, []int{0, 2, 3})
+			}
+			first := 0
+			for trial := 0; trial < 2000; trial++ {
+				if g.Choice(4, 1, false, p)[0] == 0 {
+					first++
+				}
+			}
+			So(float64(first)/2000, ShouldAlmostEqual, 0.5, 0.05)
+		})
+
+		Convey("ChoiceFrom draws array values", func() {
+			a := matrix.A2([]float64{2, 4}, []float64{6, 8})
+			v := g.ChoiceFrom(a, 50, true, nil)
+			So(v.Shape(), ShouldResemble, []int{50})
+			So(v.AllF(func(x float64) bool {
+				return x == 2 || x == 4 || x == 6 || x == 8
+			}), ShouldBeTrue)
+			So(g.ChoiceFrom(a, 4, false, nil).Sum(), ShouldEqual, 20)
+		})
+
+		Convey("Invalid parameters panic", func() {
+			So(func() { g.Choice(3, 4, false, nil) }, ShouldPanic)
+			So(func() { g.Choice(3, 3, false, matrix.A1(0.5, 0.5, 0)) }, ShouldPanic)
+			So(func() { g.Choice(3, 1, true, matrix.A1(0.5, 0.5)) }, ShouldPanic)
+			So(func() { g.Choice(2, 1, true, matrix.A1(0.5, 0.6)) }, ShouldPanic)
+			So(func() { g.Choice(0, 1, true, nil) }, ShouldPanic)
+		})
+	})
+}
+
+func TestTrainTestSplit(t *testing.T) {
+	Convey("Given a seeded generator", t, func() {
+		g := NewSeeded(4)
+
+		Convey("Dense matrices split into dense row sets", func() {
+			m := matrix.Dense(10, 2).M()
+			for i := 0; i < 10; i++ {
+				m.ItemSet(float64(i), i, 0)
+				m.ItemSet(float64(i*i), i, 1)
+			}
+			train, test, trainRows, testRows := g.TrainTestSplit(m, 0.25)
+			So(train.Shape(), ShouldResemble, []int{7, 2})
+			So(test.Shape(), ShouldResemble, []int{3, 2})
+			So(train.Sparsity(), ShouldEqual, matrix.DenseArray)
+			all := append(append([]int{}, trainRows...), testRows...)
+			sort.Ints(all)
+			So(all, ShouldResemble, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+			for i, r := range testRows {
+				So(test.Item(i, 0), ShouldEqual, r)
+				So(test.Item(i, 1), ShouldEqual, r*r)
+			}
+			for i, r := range trainRows {
+				So(train.Item(i, 0), ShouldEqual, r)
+			}
+		})
+
+		Convey("Sparse matrices split into sparse coo row sets", func() {
+			m := matrix.SparseCoo(8, 8)
+			for i := 0; i < 8; i++ {
+				m.ItemSet(float64(i+1), i, 7-i)
+			}
+			train, test, trainRows, testRows := g.TrainTestSplit(m, 0.5)
+			So(train.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(test.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+			So(train.CountNonzero()+test.CountNonzero(), ShouldEqual, 8)
+			for i, r := range trainRows {
+				So(train.Item(i, 7-r), ShouldEqual, r+1)
+			}
+			for i, r := range testRows {
+				So(test.Item(i, 7-r), ShouldEqual, r+1)
+			}
+		})
+
+		Convey("Invalid fractions panic", func() {
+			So(func() { g.TrainTestSplit(matrix.Dense(2, 2).M(), 1.5) }, ShouldPanic)
+		})
+	})
+}
+
+func TestTakeRows(t *testing.T) {
+	Convey("TakeRows can repeat rows for a bootstrap resample", t, func() {
+		m := matrix.M(3, 2, 1, 2, 3, 4, 5, 6)
+		So(TakeRows(m, []int{2, 0, 2}).Equal(matrix.M(3, 2, 5, 6, 1, 2, 5, 6)), ShouldBeTrue)
+		So(TakeRows(m.T(), []int{1}).Equal(matrix.M(1, 3, 2, 4, 6)), ShouldBeTrue)
+		d := TakeRows(matrix.Diag(1, 2, 3), []int{1, 1})
+		So(d.Sparsity(), ShouldEqual, matrix.SparseCooMatrix)
+		So(d.Equal(matrix.M(2, 3, 0, 2, 0, 0, 2, 0)), ShouldBeTrue)
+		So(func() { TakeRows(m, []int{3}) }, ShouldPanic)
+	})
+}
@@ -0,0 +1,195 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+)
+
+// How Meshgrid orders the axes of its outputs
+type MeshIndexing int
+
+const (
+	// Cartesian indexing, as in NumPy's default: for inputs of lengths n0 and
+	// n1, the outputs have shape (n1, n0, ...)
+	MeshXY MeshIndexing = iota
+
+	// Matrix indexing: for inputs of lengths n0 and n1, the outputs have shape
+	// (n0, n1, ...)
+	MeshIJ
+)
+
+// Create a 1D array of evenly spaced values in [start, stop), each step
+// apart. The step may be negative, but not zero.
+func Arange(start, stop, step float64) NDArray {
+	if step == 0 {
+		panic("Arange step must be nonzero")
+	}
+	n := int(math.Ceil((stop - start) / step))
+	if n < 0 {
+		n = 0
+	}
+	array := Dense(n)
+	for i := 0; i < n; i++ {
+		array.FlatItemSet(start+float64(i)*step, i)
+	}
+	return array
+}
+
+// Create a 1D array of num evenly spaced values from start to stop. If
+// endpoint is false, stop itself is excluded.
+func Linspace(start, stop float64, num int, endpoint bool) NDArray {
+	if num < 0 {
+		panic(fmt.Sprintf("Linspace can't create %d values", num))
+	}
+	div := num
+	if endpoint {
+		div = num - 1
+	}
+	array := Dense(num)
+	for i := 0; i < num; i++ {
+		if div > 0 {
+			array.FlatItemSet(start+(stop-start)*float64(i)/float64(div), i)
+		} else {
+			array.FlatItemSet(start, i)
+		}
+	}
+	if endpoint && num > 1 {
+		array.FlatItemSet(stop, num-1)
+	}
+	return array
+}
+
+// Create a 1D array of num values evenly spaced on a log scale, from
+// base^start to base^stop. If endpoint is false, base^stop is excluded.
+func Logspace(start, stop float64, num int, endpoint bool, base float64) NDArray {
+	return Linspace(start, stop, num, endpoint).Apply(func(v float64) float64 {
+		return math.Pow(base, v)
+	})
+}
+
+// Create coordinate arrays from 1D coordinate vectors. With n vectors, each
+// output has n dimensions, and output i varies along the axis of vector i;
+// see MeshIndexing for the order of the axes.
+func Meshgrid(indexing MeshIndexing, xs ...NDArray) []NDArray {
+	shape := make([]int, len(xs))
+	axes := make([]int, len(xs))
+	for i, x := range xs {
+		shape[i] = x.Size()
+		axes[i] = i
+	}
+	if indexing == MeshXY && len(xs) > 1 {
+		shape[0], shape[1] = shape[1], shape[0]
+		axes[0], axes[1] = 1, 0
+	}
+	result := make([]NDArray, len(xs))
+	for i, x := range xs {
+		values := x.Ravel()
+		axis := axes[i]
+		result[i] = FromFunction(shape, func(index []int) float64 {
+			return values.FlatItem(index[axis])
+		})
+	}
+	return result
+}
+
+// Create a dense array whose value at each index is f(index). The index
+// slice is reused between calls, so f must not retain it.
+func FromFunction(shape []int, f func(index []int) float64) NDArray {
+	array := Dense(shape...)
+	size := array.Size()
+	index := make([]int, len(shape))
+	for i := 0; i < size; i++ {
+		array.FlatItemSet(f(index), i)
+		for axis := len(index) - 1; axis >= 0; axis-- {
+			index[axis]++
+			if index[axis] < shape[axis] {
+				break
+			}
+			index[axis] = 0
+		}
+	}
+	return array
+}
+
+// Create a dense n x m matrix with ones at and below the k-th diagonal and
+// zeros elsewhere. k = 0 is the main diagonal, k > 0 is above it, and k < 0
+// is below it.
+func Tri(n, m, k int) Matrix {
+	return FromFunction([]int{n, m}, func(index []int) float64 {
+		if index[1] <= index[0]+k {
+			return 1
+		}
+		return 0
+	}).M()
+}
+
+// Keep the elements of m for which keep(row, col) is true. Dense matrices
+// produce a dense result, and sparse matrices a sparse coo result.
+func maskMatrix(m Matrix, keep func(row, col int) bool) Matrix {
+	var result Matrix
+	if m.Sparsity() == DenseArray {
+		result = Dense(m.Rows(), m.Cols()).M()
+	} else {
+		result = SparseCoo(m.Rows(), m.Cols())
+	}
+	m.VisitNonzero(func(pos []int, value float64) bool {
+		if keep(pos[0], pos[1]) {
+			result.ItemSet(value, pos[0], pos[1])
+		}
+		return true
+	})
+	return result
+}
+
+// Get a copy of a matrix with the elements below the k-th diagonal set to
+// zero. Sparse matrices produce a sparse coo result.
+func Triu(m Matrix, k int) Matrix {
+	return maskMatrix(m, func(row, col int) bool {
+		return col >= row+k
+	})
+}
+
+// Get a copy of a matrix with the elements above the k-th diagonal set to
+// zero. Sparse matrices produce a sparse coo result.
+func Tril(m Matrix, k int) Matrix {
+	return maskMatrix(m, func(row, col int) bool {
+		return col <= row+k
+	})
+}
+
+// Create a rows x cols matrix with ones on the k-th diagonal and zeros
+// elsewhere. The main diagonal (k = 0) produces a sparse diagonal matrix,
+// and other diagonals a sparse coo matrix.
+func Identity(rows, cols, k int) Matrix {
+	if k == 0 {
+		size := rows
+		if cols < size {
+			size = cols
+		}
+		diag := make([]float64, size)
+		for i := range diag {
+			diag[i] = 1
+		}
+		return SparseDiag(rows, cols, diag...)
+	}
+	result := SparseCoo(rows, cols)
+	for row := 0; row < rows; row++ {
+		if col := row + k; col >= 0 && col < cols {
+			result.ItemSet(1, row, col)
+		}
+	}
+	return result
+}
+
+// Create a Vandermonde matrix with n columns from the values of x. Column j
+// holds x^(n-1-j), or x^j if increasing is true.
+func Vander(x NDArray, n int, increasing bool) Matrix {
+	values := x.Ravel()
+	return FromFunction([]int{values.Size(), n}, func(index []int) float64 {
+		power := index[1]
+		if !increasing {
+			power = n - 1 - index[1]
+		}
+		return math.Pow(values.FlatItem(index[0]), float64(power))
+	}).M()
+}
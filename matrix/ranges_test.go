@@ -0,0 +1,140 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestArange(t *testing.T) {
+	Convey("Arange excludes the stop value", t, func() {
+		So(Arange(0, 1, 0.25).Equal(A1(0, 0.25, 0.5, 0.75)), ShouldBeTrue)
+		So(Arange(0, 5, 2).Equal(A1(0, 2, 4)), ShouldBeTrue)
+	})
+
+	Convey("Arange supports negative steps", t, func() {
+		So(Arange(3, 0, -1).Equal(A1(3, 2, 1)), ShouldBeTrue)
+	})
+
+	Convey("Empty ranges have size zero", t, func() {
+		So(Arange(3, 0, 1).Size(), ShouldEqual, 0)
+	})
+
+	Convey("A zero step panics", t, func() {
+		So(func() { Arange(0, 1, 0) }, ShouldPanic)
+	})
+}
+
+func TestLinspace(t *testing.T) {
+	Convey("Linspace includes the endpoint when asked", t, func() {
+		So(Linspace(0, 1, 5, true).Equal(A1(0, 0.25, 0.5, 0.75, 1)), ShouldBeTrue)
+		So(Linspace(0, 1, 4, false).Equal(A1(0, 0.25, 0.5, 0.75)), ShouldBeTrue)
+		So(Linspace(0.1, 0.7, 7, true).FlatItem(6), ShouldEqual, 0.7)
+	})
+
+	Convey("Small sizes are handled", t, func() {
+		So(Linspace(2, 3, 1, true).Equal(A1(2)), ShouldBeTrue)
+		So(Linspace(2, 3, 0, true).Size(), ShouldEqual, 0)
+		So(func() { Linspace(0, 1, -1, true) }, ShouldPanic)
+	})
+
+	Convey("Logspace spaces values on a log scale", t, func() {
+		a := Logspace(0, 3, 4, true, 10)
+		So(a.AllF2(func(v1, v2 float64) bool { return math.Abs(v1-v2) < Eps }, A1(1, 10, 100, 1000)), ShouldBeTrue)
+		So(Logspace(0, 3, 3, false, 2).Equal(A1(1, 2, 4)), ShouldBeTrue)
+	})
+}
+
+func TestMeshgrid(t *testing.T) {
+	Convey("Given two coordinate vectors", t, func() {
+		x := A1(1, 2, 3)
+		y := A1(4, 5)
+
+		Convey("xy indexing puts x along the columns", func() {
+			g := Meshgrid(MeshXY, x, y)
+			So(g[0].Equal(M(2, 3, 1, 2, 3, 1, 2, 3)), ShouldBeTrue)
+			So(g[1].Equal(M(2, 3, 4, 4, 4, 5, 5, 5)), ShouldBeTrue)
+		})
+
+		Convey("ij indexing puts x along the rows", func() {
+			g := Meshgrid(MeshIJ, x, y)
+			So(g[0].Equal(M(3, 2, 1, 1, 2, 2, 3, 3)), ShouldBeTrue)
+			So(g[1].Equal(M(3, 2, 4, 5, 4, 5, 4, 5)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given three coordinate vectors", t, func() {
+		g := Meshgrid(MeshXY, A1(1, 2), A1(3, 4, 5), A1(6, 7, 8, 9))
+		So(g[0].Shape(), ShouldResemble, []int{3, 2, 4})
+		So(g[0].Item(2, 1, 3), ShouldEqual, 2)
+		So(g[1].Item(2, 1, 3), ShouldEqual, 5)
+		So(g[2].Item(2, 1, 3), ShouldEqual, 9)
+	})
+}
+
+func TestFromFunction(t *testing.T) {
+	Convey("FromFunction calls f with each index", t, func() {
+		a := FromFunction([]int{2, 3}, func(index []int) float64 {
+			return float64(10*index[0] + index[1])
+		})
+		So(a.Equal(M(2, 3, 0, 1, 2, 10, 11, 12)), ShouldBeTrue)
+	})
+}
+
+func TestTri(t *testing.T) {
+	Convey("Tri fills at and below the k-th diagonal", t, func() {
+		So(Tri(3, 3, 0).Equal(M(3, 3, 1, 0, 0, 1, 1, 0, 1, 1, 1)), ShouldBeTrue)
+		So(Tri(2, 3, 1).Equal(M(2, 3, 1, 1, 0, 1, 1, 1)), ShouldBeTrue)
+		So(Tri(3, 2, -1).Equal(M(3, 2, 0, 0, 1, 0, 1, 1)), ShouldBeTrue)
+	})
+
+	Convey("Given a dense matrix", t, func() {
+		m := M(3, 3,
+			1, 2, 3,
+			4, 5, 6,
+			7, 8, 9)
+
+		Convey("Triu and Tril keep the right triangles", func() {
+			So(Triu(m, 0).Equal(M(3, 3, 1, 2, 3, 0, 5, 6, 0, 0, 9)), ShouldBeTrue)
+			So(Triu(m, 1).Equal(M(3, 3, 0, 2, 3, 0, 0, 6, 0, 0, 0)), ShouldBeTrue)
+			So(Tril(m, 0).Equal(M(3, 3, 1, 0, 0, 4, 5, 0, 7, 8, 9)), ShouldBeTrue)
+			So(Tril(m, -1).Equal(M(3, 3, 0, 0, 0, 4, 0, 0, 7, 8, 0)), ShouldBeTrue)
+			So(Tril(m, 0).Sparsity(), ShouldEqual, DenseArray)
+		})
+
+		Convey("Transposed matrices use logical positions", func() {
+			So(Triu(m.T(), 0).Equal(M(3, 3, 1, 4, 7, 0, 5, 8, 0, 0, 9)), ShouldBeTrue)
+		})
+
+		Convey("Sparse matrices stay sparse", func() {
+			u := Triu(m.SparseCoo(), 0)
+			So(u.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(u.Equal(Triu(m, 0)), ShouldBeTrue)
+			So(Tril(Diag(1, 2), -1).CountNonzero(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestIdentity(t *testing.T) {
+	Convey("Identity with k = 0 is sparse diagonal", t, func() {
+		i := Identity(2, 3, 0)
+		So(i.Sparsity(), ShouldEqual, SparseDiagMatrix)
+		So(i.Equal(M(2, 3, 1, 0, 0, 0, 1, 0)), ShouldBeTrue)
+	})
+
+	Convey("Identity with offset diagonals is sparse coo", t, func() {
+		i := Identity(3, 3, 1)
+		So(i.Sparsity(), ShouldEqual, SparseCooMatrix)
+		So(i.Equal(M(3, 3, 0, 1, 0, 0, 0, 1, 0, 0, 0)), ShouldBeTrue)
+		So(Identity(3, 2, -1).Equal(M(3, 2, 0, 0, 1, 0, 0, 1)), ShouldBeTrue)
+		So(Identity(2, 2, 5).CountNonzero(), ShouldEqual, 0)
+	})
+}
+
+func TestVander(t *testing.T) {
+	Convey("Vander creates decreasing powers by default", t, func() {
+		x := A1(1, 2, 3)
+		So(Vander(x, 3, false).Equal(M(3, 3, 1, 1, 1, 4, 2, 1, 9, 3, 1)), ShouldBeTrue)
+		So(Vander(x, 2, true).Equal(M(3, 2, 1, 1, 1, 2, 1, 3)), ShouldBeTrue)
+	})
+}
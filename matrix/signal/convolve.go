@@ -0,0 +1,281 @@
+// The signal package filters signals and images stored as arrays, loosely
+// following scipy.signal. Long convolutions are computed with the fft
+// package.
+//
+// To smooth a signal with a moving average, keeping its length:
+//     smooth := signal.Convolve(x, matrix.WithValue(0.2, 5), signal.ModeSame)
+//
+// To blur an image, reflecting it at the edges:
+//     blurred := signal.Convolve2D(image, kernel, signal.ModeSame,
+//             signal.BoundarySymmetric, 0)
+package signal
+
+import (
+	"fmt"
+	"github.com/jesand/numgo/matrix"
+	"github.com/jesand/numgo/matrix/fft"
+)
+
+// Which part of a convolution to return
+type Mode int
+
+const (
+	// The full convolution, at every point where the inputs overlap
+	ModeFull Mode = iota
+
+	// The central part of the full convolution, the same size as the first
+	// input (for 1D convolution, the longer input)
+	ModeSame
+
+	// Only the points which don't depend on padding or boundary values
+	ModeValid
+)
+
+// How Convolve2D treats pixels outside the image
+type Boundary int
+
+const (
+	// Pixels outside the image have a fixed fill value
+	BoundaryFill Boundary = iota
+
+	// The image repeats periodically
+	BoundaryWrap
+
+	// The image is mirrored at its edges, repeating the edge pixels
+	BoundarySymmetric
+)
+
+// Convolutions with kernels of at least this many elements are computed with
+// the FFT, which takes O(n log n) time instead of O(n * kernel size)
+var FFTThreshold = 64
+
+// Get the values of a 1D array
+func values1D(name string, a matrix.NDArray) []float64 {
+	if a.NDim() != 1 || a.Size() == 0 {
+		panic(fmt.Sprintf("%s needs nonempty 1D arrays, but got shape %v", name, a.Shape()))
+	}
+	return a.Array()
+}
+
+// Get the full linear convolution of two sequences
+func convolveFull(a, v []float64) []float64 {
+	n, m := len(a), len(v)
+	if n < FFTThreshold || m < FFTThreshold {
+		result := make([]float64, n+m-1)
+		for i, x := range a {
+			for j, y := range v {
+				result[i+j] += x * y
+			}
+		}
+		return result
+	}
+
+	size := 1
+	for size < n+m-1 {
+		size <<= 1
+	}
+	fa := make([]complex128, size)
+	fv := make([]complex128, size)
+	for i, x := range a {
+		fa[i] = complex(x, 0)
+	}
+	for i, y := range v {
+		fv[i] = complex(y, 0)
+	}
+	fft.Transform(fa, false)
+	fft.Transform(fv, false)
+	for i := range fa {
+		fa[i] *= fv[i]
+	}
+	fft.Transform(fa, true)
+	result := make([]float64, n+m-1)
+	for i := range result {
+		result[i] = real(fa[i])
+	}
+	return result
+}
+
+// Get the discrete linear convolution of two 1D arrays. With ModeSame, the
+// result has the length of the longer input, and with ModeValid, it has
+// length |len(a) - len(v)| + 1.
+func Convolve(a, v matrix.NDArray, mode Mode) matrix.NDArray {
+	x, y := values1D("Convolve", a), values1D("Convolve", v)
+	full := convolveFull(x, y)
+	long, short := len(x), len(y)
+	if short > long {
+		long, short = short, long
+	}
+	switch mode {
+	case ModeFull:
+		return matrix.A1(full...)
+	case ModeSame:
+		start := (short - 1) / 2
+		return matrix.A1(full[start : start+long]...)
+	case ModeValid:
+		return matrix.A1(full[short-1 : long]...)
+	default:
+		panic(fmt.Sprintf("Unknown convolution mode %d", mode))
+	}
+}
+
+// Get the cross-correlation of two 1D arrays: c[k] = sum_n a[n+k] * v[n].
+// This is the convolution of a with v reversed; see Convolve for the modes.
+func Correlate(a, v matrix.NDArray, mode Mode) matrix.NDArray {
+	y := values1D("Correlate", v)
+	reversed := make([]float64, len(y))
+	for i, val := range y {
+		reversed[len(y)-1-i] = val
+	}
+	return Convolve(a, matrix.A1(reversed...), mode)
+}
+
+// Map an index outside [0, n) back into the image. Returns false if the
+// boundary supplies a fill value instead.
+func boundaryIndex(i, n int, boundary Boundary) (int, bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+	switch boundary {
+	case BoundaryFill:
+		return 0, false
+	case BoundaryWrap:
+		return ((i % n) + n) % n, true
+	case BoundarySymmetric:
+		i = ((i % (2 * n)) + 2*n) % (2 * n)
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i, true
+	default:
+		panic(fmt.Sprintf("Unknown boundary %d", boundary))
+	}
+}
+
+// Get the 2D convolution of an image with a kernel. ModeSame produces an
+// output the size of the image, centered on the full convolution. ModeValid
+// requires the image to be at least as large as the kernel. Pixels outside
+// the image are given by the boundary; fillValue is used by BoundaryFill.
+func Convolve2D(image, kernel matrix.Matrix, mode Mode, boundary Boundary, fillValue float64) matrix.Matrix {
+	rows, cols := image.Rows(), image.Cols()
+	kr, kc := kernel.Rows(), kernel.Cols()
+	if rows == 0 || cols == 0 || kr == 0 || kc == 0 {
+		panic(fmt.Sprintf("Convolve2D needs nonempty inputs, but got shapes %v and %v", image.Shape(), kernel.Shape()))
+	}
+
+	// The output's size and its offset into the full convolution
+	var outR, outC, offR, offC int
+	switch mode {
+	case ModeFull:
+		outR, outC = rows+kr-1, cols+kc-1
+	case ModeSame:
+		outR, outC = rows, cols
+		offR, offC = (kr-1)/2, (kc-1)/2
+	case ModeValid:
+		if kr > rows || kc > cols {
+			panic(fmt.Sprintf("Convolve2D can't fit kernel of shape %v in image of shape %v", kernel.Shape(), image.Shape()))
+		}
+		outR, outC = rows-kr+1, cols-kc+1
+		offR, offC = kr-1, kc-1
+	default:
+		panic(fmt.Sprintf("Unknown convolution mode %d", mode))
+	}
+
+	// Pad the image with boundary values, so that the output is the valid
+	// convolution of the padded image
+	pr, pc := outR+kr-1, outC+kc-1
+	img := image.Dense().Array()
+	padded := make([]float64, pr*pc)
+	for i := 0; i < pr; i++ {
+		r, rok := boundaryIndex(offR-(kr-1)+i, rows, boundary)
+		for j := 0; j < pc; j++ {
+			c, cok := boundaryIndex(offC-(kc-1)+j, cols, boundary)
+			if rok && cok {
+				padded[i*pc+j] = img[r*cols+c]
+			} else {
+				padded[i*pc+j] = fillValue
+			}
+		}
+	}
+	k := kernel.Dense().Array()
+
+	result := matrix.Dense(outR, outC).M()
+	if kr*kc < FFTThreshold {
+		for i := 0; i < outR; i++ {
+			for j := 0; j < outC; j++ {
+				var sum float64
+				for p := 0; p < kr; p++ {
+					row := (i + kr - 1 - p) * pc
+					for q := 0; q < kc; q++ {
+						sum += k[p*kc+q] * padded[row+j+kc-1-q]
+					}
+				}
+				result.ItemSet(sum, i, j)
+			}
+		}
+		return result
+	}
+
+	full := fftConvolve2D(padded, pr, pc, k, kr, kc)
+	fc := pc + kc - 1
+	for i := 0; i < outR; i++ {
+		for j := 0; j < outC; j++ {
+			result.ItemSet(full[(i+kr-1)*fc+j+kc-1], i, j)
+		}
+	}
+	return result
+}
+
+// Get the full 2D convolution of two row-major arrays using the FFT
+func fftConvolve2D(a []float64, ar, ac int, b []float64, br, bc int) []float64 {
+	fr, fc := ar+br-1, ac+bc-1
+	fa := make([]complex128, fr*fc)
+	fb := make([]complex128, fr*fc)
+	for i := 0; i < ar; i++ {
+		for j := 0; j < ac; j++ {
+			fa[i*fc+j] = complex(a[i*ac+j], 0)
+		}
+	}
+	for i := 0; i < br; i++ {
+		for j := 0; j < bc; j++ {
+			fb[i*fc+j] = complex(b[i*bc+j], 0)
+		}
+	}
+	transform2D(fa, fr, fc, false)
+	transform2D(fb, fr, fc, false)
+	for i := range fa {
+		fa[i] *= fb[i]
+	}
+	transform2D(fa, fr, fc, true)
+	result := make([]float64, fr*fc)
+	for i := range result {
+		result[i] = real(fa[i])
+	}
+	return result
+}
+
+// Transform a row-major array along both axes in place
+func transform2D(data []complex128, rows, cols int, inverse bool) {
+	for i := 0; i < rows; i++ {
+		fft.Transform(data[i*cols:(i+1)*cols], inverse)
+	}
+	col := make([]complex128, rows)
+	for j := 0; j < cols; j++ {
+		for i := range col {
+			col[i] = data[i*cols+j]
+		}
+		fft.Transform(col, inverse)
+		for i := range col {
+			data[i*cols+j] = col[i]
+		}
+	}
+}
+
+// Get the 2D cross-correlation of an image with a kernel: the convolution
+// with the kernel flipped along both axes. See Convolve2D.
+func Correlate2D(image, kernel matrix.Matrix, mode Mode, boundary Boundary, fillValue float64) matrix.Matrix {
+	kr, kc := kernel.Rows(), kernel.Cols()
+	flipped := matrix.FromFunction([]int{kr, kc}, func(index []int) float64 {
+		return kernel.Item(kr-1-index[0], kc-1-index[1])
+	}).M()
+	return Convolve2D(image, flipped, mode, boundary, fillValue)
+}
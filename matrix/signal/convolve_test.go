@@ -0,0 +1,117 @@
+package signal
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/jesand/numgo/matrix"
+	"math"
+	"testing"
+)
+
+// Run f with the FFT disabled
+func direct(f func()) {
+	old := FFTThreshold
+	FFTThreshold = math.MaxInt32
+	defer func() { FFTThreshold = old }()
+	f()
+}
+
+func TestConvolve(t *testing.T) {
+	Convey("Given two short signals", t, func() {
+		a := matrix.A1(1, 2, 3)
+		v := matrix.A1(0, 1, 0.5)
+
+		Convey("Convolve supports each mode", func() {
+			So(Convolve(a, v, ModeFull).Equal(matrix.A1(0, 1, 2.5, 4, 1.5)), ShouldBeTrue)
+			So(Convolve(a, v, ModeSame).Equal(matrix.A1(1, 2.5, 4)), ShouldBeTrue)
+			So(Convolve(a, v, ModeValid).Equal(matrix.A1(2.5)), ShouldBeTrue)
+		})
+
+		Convey("Convolve is symmetric in its inputs", func() {
+			long := matrix.A1(1, 2, 3, 4, 5)
+			short := matrix.A1(1, -1)
+			So(Convolve(short, long, ModeValid).Equal(Convolve(long, short, ModeValid)), ShouldBeTrue)
+			So(Convolve(short, long, ModeSame).Size(), ShouldEqual, 5)
+		})
+
+		Convey("Correlate reverses the second input", func() {
+			So(Correlate(a, v, ModeFull).Equal(matrix.A1(0.5, 2, 3.5, 3, 0)), ShouldBeTrue)
+			So(Correlate(a, v, ModeSame).Equal(matrix.A1(2, 3.5, 3)), ShouldBeTrue)
+			So(Correlate(a, v, ModeValid).Equal(matrix.A1(3.5)), ShouldBeTrue)
+		})
+
+		Convey("Bad inputs panic", func() {
+			So(func() { Convolve(matrix.Dense(2, 2), v, ModeFull) }, ShouldPanic)
+			So(func() { Convolve(a, matrix.Dense(0), ModeFull) }, ShouldPanic)
+			So(func() { Convolve(a, v, Mode(7)) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given long signals", t, func() {
+		a := matrix.RandN(300)
+		v := matrix.RandN(100)
+
+		Convey("The FFT path matches direct convolution", func() {
+			fast := Convolve(a, v, ModeFull)
+			var slow matrix.NDArray
+			direct(func() { slow = Convolve(a, v, ModeFull) })
+			So(fast.Size(), ShouldEqual, 399)
+			So(matrix.AllClose(fast, slow, 0, 1e-8, false), ShouldBeTrue)
+		})
+	})
+}
+
+func TestConvolve2D(t *testing.T) {
+	Convey("Given a small image and kernel", t, func() {
+		image := matrix.A2([]float64{1, 2}, []float64{3, 4}).M()
+		kernel := matrix.WithValue(1, 2, 2).M()
+
+		Convey("Convolve2D supports each mode", func() {
+			So(Convolve2D(image, kernel, ModeFull, BoundaryFill, 0).Equal(matrix.A2(
+				[]float64{1, 3, 2}, []float64{4, 10, 6}, []float64{3, 7, 4})), ShouldBeTrue)
+			So(Convolve2D(image, kernel, ModeSame, BoundaryFill, 0).Equal(matrix.A2(
+				[]float64{1, 3}, []float64{4, 10})), ShouldBeTrue)
+			So(Convolve2D(image, kernel, ModeValid, BoundaryFill, 0).Equal(matrix.A2(
+				[]float64{10})), ShouldBeTrue)
+		})
+
+		Convey("Valid mode needs the kernel to fit", func() {
+			So(func() { Convolve2D(kernel, matrix.Dense(3, 3).M(), ModeValid, BoundaryFill, 0) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a row image", t, func() {
+		image := matrix.A2([]float64{1, 2, 3}).M()
+		kernel := matrix.A2([]float64{1, 1, 1}).M()
+
+		Convey("Boundaries supply the outside pixels", func() {
+			So(Convolve2D(image, kernel, ModeSame, BoundaryFill, 10).Equal(matrix.A2(
+				[]float64{13, 6, 15})), ShouldBeTrue)
+			So(Convolve2D(image, kernel, ModeSame, BoundaryWrap, 0).Equal(matrix.A2(
+				[]float64{6, 6, 6})), ShouldBeTrue)
+			So(Convolve2D(image, kernel, ModeSame, BoundarySymmetric, 0).Equal(matrix.A2(
+				[]float64{4, 6, 8})), ShouldBeTrue)
+		})
+
+		Convey("Correlate2D flips the kernel", func() {
+			k := matrix.A2([]float64{1, 0, 0}).M()
+			So(Convolve2D(image, k, ModeSame, BoundaryFill, 0).Equal(matrix.A2(
+				[]float64{2, 3, 0})), ShouldBeTrue)
+			So(Correlate2D(image, k, ModeSame, BoundaryFill, 0).Equal(matrix.A2(
+				[]float64{0, 1, 2})), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a large kernel", t, func() {
+		image := matrix.RandN(20, 15).M()
+		kernel := matrix.RandN(9, 9).M()
+
+		Convey("The FFT path matches direct convolution", func() {
+			for _, boundary := range []Boundary{BoundaryFill, BoundaryWrap, BoundarySymmetric} {
+				fast := Convolve2D(image, kernel, ModeSame, boundary, 0.5)
+				var slow matrix.Matrix
+				direct(func() { slow = Convolve2D(image, kernel, ModeSame, boundary, 0.5) })
+				So(matrix.AllClose(fast, slow, 0, 1e-8, false), ShouldBeTrue)
+			}
+		})
+	})
+}
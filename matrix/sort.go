@@ -0,0 +1,337 @@
+package matrix
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Which index SearchSorted returns when a value equals elements of the
+// sorted array
+type SearchSide int
+
+const (
+	// Return the index of the first equal element
+	SearchLeft SearchSide = iota
+
+	// Return the index just past the last equal element
+	SearchRight
+)
+
+// Orders values ascending, with NaN after all other values
+func nanLess(a, b float64) bool {
+	return a < b || (!math.IsNaN(a) && math.IsNaN(b))
+}
+
+// Get a dense copy of the array, sorted along an axis. NaN values are sorted
+// to the end.
+func Sort(array NDArray, axis int) NDArray {
+	return mapLanes(array, axis, array.Shape()[axis], func(in, out []float64) {
+		copy(out, in)
+		sort.Slice(out, func(i, j int) bool { return nanLess(out[i], out[j]) })
+	})
+}
+
+// Get the indices which would sort the array along an axis, as a dense
+// array of the same shape. If stable is true, equal elements keep their
+// original order.
+func ArgSort(array NDArray, axis int, stable bool) NDArray {
+	idx := make([]int, array.Shape()[axis])
+	return mapLanes(array, axis, len(idx), func(in, out []float64) {
+		for i := range idx {
+			idx[i] = i
+		}
+		less := func(i, j int) bool { return nanLess(in[idx[i]], in[idx[j]]) }
+		if stable {
+			sort.SliceStable(idx, less)
+		} else {
+			sort.Slice(idx, less)
+		}
+		for i, v := range idx {
+			out[i] = float64(v)
+		}
+	})
+}
+
+// Reorder idx so that the element at position kth is the one which would be
+// there if idx were sorted by value, with no larger values before it and no
+// smaller values after it. Runs in expected linear time.
+func selectKth(values []float64, idx []int, kth int) {
+	less := func(i, j int) bool { return nanLess(values[idx[i]], values[idx[j]]) }
+	lo, hi := 0, len(idx)-1
+	for lo < hi {
+		// Median of three pivot, moved to hi
+		mid := lo + (hi-lo)/2
+		if less(mid, lo) {
+			idx[mid], idx[lo] = idx[lo], idx[mid]
+		}
+		if less(hi, lo) {
+			idx[hi], idx[lo] = idx[lo], idx[hi]
+		}
+		if less(mid, hi) {
+			idx[mid], idx[hi] = idx[hi], idx[mid]
+		}
+		store := lo
+		for i := lo; i < hi; i++ {
+			if less(i, hi) {
+				idx[i], idx[store] = idx[store], idx[i]
+				store++
+			}
+		}
+		idx[store], idx[hi] = idx[hi], idx[store]
+		switch {
+		case kth < store:
+			hi = store - 1
+		case kth > store:
+			lo = store + 1
+		default:
+			return
+		}
+	}
+}
+
+// Check a partition index against an axis, converting negative indices
+func kthIndex(shape []int, axis, kth int) int {
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	n := shape[axis]
+	if kth < -n || kth >= n {
+		panic(fmt.Sprintf("Partition index %d is invalid for an axis of length %d", kth, n))
+	}
+	if kth < 0 {
+		kth += n
+	}
+	return kth
+}
+
+// Get the indices which would partition the array along an axis: in each
+// lane, the element at position kth is where it would be if the lane were
+// sorted, smaller elements come before it and larger ones after, in no
+// particular order. Negative kth counts from the end of the axis.
+func ArgPartition(array NDArray, kth, axis int) NDArray {
+	kth = kthIndex(array.Shape(), axis, kth)
+	idx := make([]int, array.Shape()[axis])
+	return mapLanes(array, axis, len(idx), func(in, out []float64) {
+		for i := range idx {
+			idx[i] = i
+		}
+		selectKth(in, idx, kth)
+		for i, v := range idx {
+			out[i] = float64(v)
+		}
+	})
+}
+
+// Get a dense copy of the array partitioned along an axis; see ArgPartition
+func Partition(array NDArray, kth, axis int) NDArray {
+	kth = kthIndex(array.Shape(), axis, kth)
+	idx := make([]int, array.Shape()[axis])
+	return mapLanes(array, axis, len(idx), func(in, out []float64) {
+		for i := range idx {
+			idx[i] = i
+		}
+		selectKth(in, idx, kth)
+		for i, v := range idx {
+			out[i] = in[v]
+		}
+	})
+}
+
+// Get the sorted distinct values of an array, how many times each occurs,
+// and for each element of the flattened array, the index of its value in the
+// result. NaN values are all considered equal and sort last. Zeros implied by
+// sparse arrays are counted without visiting them.
+func Unique(array NDArray) (unique NDArray, counts []int, inverse []int) {
+	count := make(map[float64]int)
+	nans, nonzero := 0, 0
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		// Some sparse arrays visit stored zeros, which are counted below
+		if value == 0 {
+			return true
+		}
+		nonzero++
+		if math.IsNaN(value) {
+			nans++
+		} else {
+			count[value]++
+		}
+		return true
+	})
+	if zeros := array.Size() - nonzero; zeros > 0 {
+		count[0] = zeros
+	}
+
+	values := make([]float64, 0, len(count)+1)
+	for v := range count {
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+	counts = make([]int, len(values))
+	for i, v := range values {
+		counts[i] = count[v]
+	}
+	// Search only the sorted values, since the NaN slot at the end breaks
+	// the ordering
+	sorted := values
+	if nans > 0 {
+		values = append(values, math.NaN())
+		counts = append(counts, nans)
+	}
+
+	inverse = make([]int, array.Size())
+	zero := sort.SearchFloat64s(sorted, 0)
+	for i := range inverse {
+		inverse[i] = zero
+	}
+	shape := array.Shape()
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		i := len(values) - 1
+		if !math.IsNaN(value) {
+			i = sort.SearchFloat64s(sorted, value)
+		}
+		inverse[ndToFlat(shape, pos)] = i
+		return true
+	})
+	return A1(values...), counts, inverse
+}
+
+// Find the indices at which values should be inserted into a sorted 1D
+// array to keep it sorted. See SearchSide for the handling of equal values.
+func SearchSorted(sorted, values NDArray, side SearchSide) []int {
+	if sorted.NDim() != 1 {
+		panic(fmt.Sprintf("SearchSorted needs a 1D sorted array, but got shape %v", sorted.Shape()))
+	}
+	n := sorted.Size()
+	result := make([]int, values.Size())
+	for i := range result {
+		v := values.FlatItem(i)
+		if side == SearchLeft {
+			result[i] = sort.Search(n, func(j int) bool { return !nanLess(sorted.FlatItem(j), v) })
+		} else {
+			result[i] = sort.Search(n, func(j int) bool { return nanLess(v, sorted.FlatItem(j)) })
+		}
+	}
+	return result
+}
+
+// Get the order of the rows of a matrix when sorted by several columns.
+// keys[0] is the primary sort column, keys[1] breaks ties in keys[0], and so
+// on. Rows which are equal in every key keep their original order. Note that
+// NumPy's lexsort instead treats the last key as primary.
+func Lexsort(m Matrix, keys []int) []int {
+	rows, cols := m.Rows(), m.Cols()
+	for _, k := range keys {
+		if k < 0 || k >= cols {
+			panic(fmt.Sprintf("Lexsort key %d is invalid for a matrix with %d columns", k, cols))
+		}
+	}
+	columns := make([][]float64, len(keys))
+	for i, k := range keys {
+		columns[i] = make([]float64, rows)
+		for r := 0; r < rows; r++ {
+			columns[i][r] = m.Item(r, k)
+		}
+	}
+	order := make([]int, rows)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		for _, col := range columns {
+			a, b := col[order[i]], col[order[j]]
+			if nanLess(a, b) {
+				return true
+			} else if nanLess(b, a) {
+				return false
+			}
+		}
+		return false
+	})
+	return order
+}
+
+// A candidate for TopK
+type topKItem struct {
+	col   int
+	value float64
+}
+
+// Returns true if a ranks below b: it has a smaller value, or an equal value
+// and a larger column index
+func (a topKItem) below(b topKItem) bool {
+	return a.value < b.value || (a.value == b.value && a.col > b.col)
+}
+
+// A min-heap holding the best k items seen so far
+type topKHeap []topKItem
+
+func (h topKHeap) Len() int            { return len(h) }
+func (h topKHeap) Less(i, j int) bool  { return h[i].below(h[j]) }
+func (h topKHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *topKHeap) Push(x interface{}) { *h = append(*h, x.(topKItem)) }
+func (h *topKHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// Offer an item to a heap holding at most k items
+func (h *topKHeap) offer(item topKItem, k int) {
+	if h.Len() < k {
+		heap.Push(h, item)
+	} else if k > 0 && (*h)[0].below(item) {
+		(*h)[0] = item
+		heap.Fix(h, 0)
+	}
+}
+
+// Find the k largest values in each row of a matrix, ignoring NaN. Returns
+// the column indices and values for each row, in descending order of value;
+// ties are broken by the smaller column index. Rows with fewer than k
+// non-NaN values return all of them. Each row is scanned once with a heap of
+// size k, and sparse rows only visit their nonzero values.
+func TopK(m Matrix, k int) (indices [][]int, values [][]float64) {
+	if k < 0 {
+		panic(fmt.Sprintf("TopK: k=%d must be nonnegative", k))
+	}
+	rows, cols := m.Rows(), m.Cols()
+	heaps := make([]topKHeap, rows)
+	nonzero := make([]map[int]bool, rows)
+	for r := range nonzero {
+		nonzero[r] = make(map[int]bool)
+	}
+	m.VisitNonzero(func(pos []int, value float64) bool {
+		r := pos[0]
+		nonzero[r][pos[1]] = true
+		if !math.IsNaN(value) {
+			heaps[r].offer(topKItem{pos[1], value}, k)
+		}
+		return true
+	})
+
+	indices = make([][]int, rows)
+	values = make([][]float64, rows)
+	for r := 0; r < rows; r++ {
+		h := &heaps[r]
+		// Only the first k zero columns can rank among the top k
+		added := 0
+		for c := 0; c < cols && added < k && len(nonzero[r]) < cols; c++ {
+			if !nonzero[r][c] {
+				h.offer(topKItem{c, 0}, k)
+				added++
+			}
+		}
+		n := h.Len()
+		indices[r] = make([]int, n)
+		values[r] = make([]float64, n)
+		for i := n - 1; i >= 0; i-- {
+			item := heap.Pop(h).(topKItem)
+			indices[r][i] = item.col
+			values[r][i] = item.value
+		}
+	}
+	return indices, values
+}
@@ -0,0 +1,225 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSort(t *testing.T) {
+	Convey("Given a matrix", t, func() {
+		m := M(2, 3,
+			3, 1, 2,
+			0, 5, -4)
+
+		Convey("Sort works along both axes", func() {
+			So(Sort(m, 1).Equal(M(2, 3, 1, 2, 3, -4, 0, 5)), ShouldBeTrue)
+			So(Sort(m, 0).Equal(M(2, 3, 0, 1, -4, 3, 5, 2)), ShouldBeTrue)
+			So(Sort(m.T(), 0).Equal(M(3, 2, 1, -4, 2, 0, 3, 5)), ShouldBeTrue)
+		})
+
+		Convey("ArgSort works along both axes", func() {
+			So(ArgSort(m, 1, false).Equal(M(2, 3, 1, 2, 0, 2, 0, 1)), ShouldBeTrue)
+			So(ArgSort(m, 0, true).Equal(M(2, 3, 1, 0, 1, 0, 1, 0)), ShouldBeTrue)
+		})
+
+		Convey("Sparse matrices produce dense results", func() {
+			s := Sort(Diag(3, -1, 2), 1)
+			So(s.Sparsity(), ShouldEqual, DenseArray)
+			So(s.Equal(M(3, 3, 0, 0, 3, -1, 0, 0, 0, 0, 2)), ShouldBeTrue)
+		})
+	})
+
+	Convey("NaN values sort last", t, func() {
+		a := Sort(A1(2, math.NaN(), -1), 0)
+		So(a.FlatItem(0), ShouldEqual, -1)
+		So(a.FlatItem(1), ShouldEqual, 2)
+		So(math.IsNaN(a.FlatItem(2)), ShouldBeTrue)
+	})
+
+	Convey("Stable ArgSort keeps equal elements in order", t, func() {
+		a := Dense(100)
+		for i := 0; i < 100; i++ {
+			a.FlatItemSet(float64(i%3), i)
+		}
+		idx := ArgSort(a, 0, true)
+		for i := 1; i < 100; i++ {
+			prev, cur := idx.FlatItem(i-1), idx.FlatItem(i)
+			if a.FlatItem(int(prev)) == a.FlatItem(int(cur)) {
+				So(prev, ShouldBeLessThan, cur)
+			}
+		}
+	})
+}
+
+func TestPartition(t *testing.T) {
+	Convey("Given random arrays", t, func() {
+		rng := rand.New(rand.NewSource(1))
+		for trial := 0; trial < 20; trial++ {
+			a := Dense(31)
+			for i := 0; i < 31; i++ {
+				a.FlatItemSet(float64(rng.Intn(10)), i)
+			}
+			kth := rng.Intn(31)
+			sorted := Sort(a, 0)
+
+			p := Partition(a, kth, 0)
+			So(p.FlatItem(kth), ShouldEqual, sorted.FlatItem(kth))
+			for i := 0; i < 31; i++ {
+				if i < kth {
+					So(p.FlatItem(i), ShouldBeLessThanOrEqualTo, p.FlatItem(kth))
+				} else {
+					So(p.FlatItem(i), ShouldBeGreaterThanOrEqualTo, p.FlatItem(kth))
+				}
+			}
+
+			idx := ArgPartition(a, kth, 0)
+			So(a.FlatItem(int(idx.FlatItem(kth))), ShouldEqual, sorted.FlatItem(kth))
+			seen := make(map[float64]bool)
+			for i := 0; i < 31; i++ {
+				seen[idx.FlatItem(i)] = true
+			}
+			So(len(seen), ShouldEqual, 31)
+		}
+	})
+
+	Convey("Partition works along axes with negative kth", t, func() {
+		m := M(2, 4,
+			4, 1, 3, 2,
+			8, 5, 7, 6)
+		p := Partition(m, -1, 1)
+		So(p.Item(0, 3), ShouldEqual, 4)
+		So(p.Item(1, 3), ShouldEqual, 8)
+		So(Partition(m, 0, 0).Equal(m), ShouldBeTrue)
+		So(func() { Partition(m, 4, 1) }, ShouldPanic)
+		So(func() { ArgPartition(m, 0, 2) }, ShouldPanic)
+	})
+}
+
+func TestUnique(t *testing.T) {
+	Convey("Given a dense array", t, func() {
+		a := A1(3, 1, 3, 0, 1, 3)
+		unique, counts, inverse := Unique(a)
+		So(unique.Equal(A1(0, 1, 3)), ShouldBeTrue)
+		So(counts, ShouldResemble, []int{1, 2, 3})
+		So(inverse, ShouldResemble, []int{2, 1, 2, 0, 1, 2})
+	})
+
+	Convey("Given a sparse matrix with negative values and NaN", t, func() {
+		m := SparseCoo(2, 3,
+			-2, 0, 5,
+			0, math.NaN(), -2)
+		unique, counts, inverse := Unique(m)
+		So(unique.Size(), ShouldEqual, 4)
+		So(unique.Slice([]int{0}, []int{3}).Equal(A1(-2, 0, 5)), ShouldBeTrue)
+		So(math.IsNaN(unique.FlatItem(3)), ShouldBeTrue)
+		So(counts, ShouldResemble, []int{2, 2, 1, 1})
+		So(inverse, ShouldResemble, []int{0, 1, 2, 1, 3, 0})
+	})
+
+	Convey("Given a dense array ending in NaN", t, func() {
+		unique, counts, inverse := Unique(A1(1, 2, 3, 4, math.NaN(), math.NaN()))
+		So(unique.Size(), ShouldEqual, 5)
+		So(counts, ShouldResemble, []int{1, 1, 1, 1, 2})
+		So(inverse, ShouldResemble, []int{0, 1, 2, 3, 4, 4})
+	})
+
+	Convey("Given a diagonal matrix with a zero on its diagonal", t, func() {
+		unique, counts, inverse := Unique(Diag(0, 1))
+		So(unique.Equal(A1(0, 1)), ShouldBeTrue)
+		So(counts, ShouldResemble, []int{3, 1})
+		So(inverse, ShouldResemble, []int{0, 0, 0, 1})
+	})
+}
+
+func TestSearchSorted(t *testing.T) {
+	Convey("Given a sorted array", t, func() {
+		sorted := A1(1, 2, 2, 3, 5)
+		values := A1(0, 2, 4, 6)
+
+		Convey("Left search finds the first equal element", func() {
+			So(SearchSorted(sorted, values, SearchLeft), ShouldResemble, []int{0, 1, 4, 5})
+		})
+
+		Convey("Right search finds the position after equal elements", func() {
+			So(SearchSorted(sorted, values, SearchRight), ShouldResemble, []int{0, 3, 4, 5})
+		})
+
+		Convey("Multidimensional sorted arrays panic", func() {
+			So(func() { SearchSorted(Dense(2, 2), values, SearchLeft) }, ShouldPanic)
+		})
+	})
+}
+
+func TestLexsort(t *testing.T) {
+	Convey("Given a matrix with tied keys", t, func() {
+		m := M(5, 3,
+			2, 1, 0,
+			1, 9, 1,
+			2, 0, 2,
+			1, 9, 3,
+			1, 3, 4)
+		So(Lexsort(m, []int{0, 1}), ShouldResemble, []int{4, 1, 3, 2, 0})
+		So(Lexsort(m, []int{1}), ShouldResemble, []int{2, 0, 4, 1, 3})
+		So(Lexsort(m, nil), ShouldResemble, []int{0, 1, 2, 3, 4})
+		So(Lexsort(m.SparseCoo(), []int{0, 2}), ShouldResemble, []int{1, 3, 4, 0, 2})
+		So(func() { Lexsort(m, []int{3}) }, ShouldPanic)
+	})
+}
+
+func TestTopK(t *testing.T) {
+	Convey("Given a dense matrix", t, func() {
+		m := M(2, 4,
+			1, 5, 3, 5,
+			-1, -2, -3, -4)
+		indices, values := TopK(m, 2)
+		So(indices, ShouldResemble, [][]int{{1, 3}, {0, 1}})
+		So(values, ShouldResemble, [][]float64{{5, 5}, {-1, -2}})
+	})
+
+	Convey("Given a sparse matrix", t, func() {
+		m := SparseCoo(3, 6,
+			0, 0, 4, 0, 9, 0,
+			0, -1, 0, 0, -5, 0,
+			0, 0, 0, 0, 0, 0)
+
+		Convey("Zeros are chosen by the smallest column", func() {
+			indices, values := TopK(m, 3)
+			So(indices, ShouldResemble, [][]int{{4, 2, 0}, {0, 2, 3}, {0, 1, 2}})
+			So(values, ShouldResemble, [][]float64{{9, 4, 0}, {0, 0, 0}, {0, 0, 0}})
+		})
+
+		Convey("Results match sorting each row", func() {
+			r := SparseRandN(20, 50, 0.1)
+			indices, values := TopK(r, 5)
+			for i := 0; i < 20; i++ {
+				row := make([]float64, 50)
+				for j := range row {
+					row[j] = r.Item(i, j)
+				}
+				sort.Sort(sort.Reverse(sort.Float64Slice(row)))
+				So(values[i], ShouldResemble, row[:5])
+				for j, c := range indices[i] {
+					So(r.Item(i, c), ShouldEqual, values[i][j])
+				}
+			}
+		})
+	})
+
+	Convey("Rows with fewer than k values return them all, ignoring NaN", t, func() {
+		indices, values := TopK(M(1, 3, 2, math.NaN(), 1), 5)
+		So(indices, ShouldResemble, [][]int{{0, 2}})
+		So(values, ShouldResemble, [][]float64{{2, 1}})
+		So(func() { TopK(Eye(2), -1) }, ShouldPanic)
+	})
+
+	Convey("TopK with k=0 returns empty rows", t, func() {
+		for _, m := range []Matrix{M(2, 2, 1, 0, 0, 2), SparseCoo(2, 2, 1, 0, 0, 2), Eye(2)} {
+			indices, values := TopK(m, 0)
+			So(indices, ShouldResemble, [][]int{{}, {}})
+			So(values, ShouldResemble, [][]float64{{}, {}})
+		}
+	})
+}
@@ -199,6 +199,12 @@ func (array *sparseCooF64Matrix) FlatItemSet(value float64, index int) {
 	array.ItemSet(value, nd[0], nd[1])
 }
 
+// Format the array for display with fmt. The verbs %v and %s use NumPy's
+// formatting rules, while %e, %f and %g format values as for float64.
+func (array sparseCooF64Matrix) Format(f fmt.State, verb rune) {
+	formatState(&array, f, verb)
+}
+
 // Get the matrix inverse
 func (array sparseCooF64Matrix) Inverse() (Matrix, error) {
 	return Inverse(&array)
@@ -233,8 +239,10 @@ func (array *sparseCooF64Matrix) ItemAdd(value float64) NDArray {
 // Divide each array element by a scalar value
 func (array *sparseCooF64Matrix) ItemDiv(value float64) NDArray {
 	result := Dense(array.shape...)
+	errs := floatErrors{op: "ItemDiv"}
 	for row, val := range array.values {
 		for col, v := range val {
+			errs.checkDiv(v, value, v/value)
 			if array.transpose {
 				result.ItemSet(v/value, col, row)
 			} else {
@@ -242,6 +250,7 @@ func (array *sparseCooF64Matrix) ItemDiv(value float64) NDArray {
 			}
 		}
 	}
+	errs.report()
 	return result
 }
 
@@ -408,6 +417,11 @@ func (array sparseCooF64Matrix) Sparsity() ArraySparsity {
 	return SparseCooMatrix
 }
 
+// Get a string representation of the array, formatted as for %v
+func (array sparseCooF64Matrix) String() string {
+	return formatArray(&array, 'v', 0, false)
+}
+
 // Return the element-wise difference of this array and one or more others
 func (array sparseCooF64Matrix) Sub(other ...NDArray) NDArray {
 	return Sub(&array, other...)
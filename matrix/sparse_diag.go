@@ -189,6 +189,12 @@ func (array sparseDiagF64Matrix) FlatItemSet(value float64, index int) {
 	array.diag[coord[0]] = value
 }
 
+// Format the array for display with fmt. The verbs %v and %s use NumPy's
+// formatting rules, while %e, %f and %g format values as for float64.
+func (array sparseDiagF64Matrix) Format(f fmt.State, verb rune) {
+	formatState(&array, f, verb)
+}
+
 // Get the matrix inverse
 func (array sparseDiagF64Matrix) Inverse() (Matrix, error) {
 	return Inverse(&array)
@@ -228,9 +234,12 @@ func (array *sparseDiagF64Matrix) ItemAdd(value float64) NDArray {
 // Divide each array element by a scalar value
 func (array *sparseDiagF64Matrix) ItemDiv(value float64) NDArray {
 	result := array.copy()
-	for i := 0; i < len(result.diag); i++ {
-		result.diag[i] /= value
+	errs := floatErrors{op: "ItemDiv"}
+	for i, v := range result.diag {
+		result.diag[i] = v / value
+		errs.checkDiv(v, value, result.diag[i])
 	}
+	errs.report()
 	return result
 }
 
@@ -396,6 +405,11 @@ func (array sparseDiagF64Matrix) Sparsity() ArraySparsity {
 	return SparseDiagMatrix
 }
 
+// Get a string representation of the array, formatted as for %v
+func (array sparseDiagF64Matrix) String() string {
+	return formatArray(&array, 'v', 0, false)
+}
+
 // Return the element-wise difference of this array and one or more others
 func (array sparseDiagF64Matrix) Sub(other ...NDArray) NDArray {
 	return Sub(&array, other...)
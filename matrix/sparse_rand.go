@@ -0,0 +1,201 @@
+package matrix
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// The structure of the cells which SparseRandOpts may fill
+type SparsePattern int
+
+const (
+	// Any cell may be filled
+	SparsePatternRandom SparsePattern = iota
+
+	// Only cells within SparseRandOptions.Bandwidth of the main diagonal may
+	// be filled
+	SparsePatternBanded
+
+	// The matrix is divided into blocks of SparseRandOptions.BlockRows x
+	// SparseRandOptions.BlockCols cells, and randomly chosen blocks are filled
+	// completely
+	SparsePatternBlock
+)
+
+// Options which control the matrices created by SparseRandOpts. The zero
+// value fills randomly chosen cells with values uniformly distributed in
+// [0, 1), drawn from the global source in math/rand.
+type SparseRandOptions struct {
+
+	// Draws the value of each filled cell. Defaults to uniform values in
+	// [0, 1). Zero values are drawn again, so Values must not always return
+	// zero.
+	Values func() float64
+
+	// The source of randomness used to choose cells and to draw default
+	// values. Defaults to the global source in math/rand.
+	Rng *rand.Rand
+
+	// Whether the matrix should equal its transpose. Only square matrices can
+	// be symmetric. The density then gives the fraction of cells filled on and
+	// below the main diagonal, which are mirrored above it.
+	Symmetric bool
+
+	// If positive, exactly this many cells are filled in each row and the
+	// density is ignored. Can't be used with Symmetric or SparsePatternBlock.
+	RowNonzeros int
+
+	// Which cells may be filled
+	Pattern SparsePattern
+
+	// For SparsePatternBanded, the number of diagonals above and below the
+	// main diagonal which may be filled
+	Bandwidth int
+
+	// For SparsePatternBlock, the size of each block. Blocks at the bottom and
+	// right edges are truncated to fit the matrix.
+	BlockRows, BlockCols int
+}
+
+// Create a sparse coo matrix with int(density * n) cells filled, where n is
+// the number of cells the pattern in opts allows to be filled. Cells are
+// chosen without replacement, so this is fast for any density in [0, 1].
+// For block patterns, the density gives the fraction of blocks filled.
+func SparseRandOpts(rows, cols int, density float64, opts SparseRandOptions) Matrix {
+	if density < 0 || density > 1 {
+		panic(fmt.Sprintf("Can't create a sparse random matrix: density %f should be in [0, 1]", density))
+	}
+	if opts.Symmetric && rows != cols {
+		panic(fmt.Sprintf("Can't create a symmetric %dx%d matrix", rows, cols))
+	}
+	if opts.RowNonzeros > 0 && (opts.Symmetric || opts.Pattern == SparsePatternBlock) {
+		panic("Can't fix the nonzeros per row of a symmetric or block random matrix")
+	}
+
+	intn, value := rand.Intn, rand.Float64
+	if opts.Rng != nil {
+		intn, value = opts.Rng.Intn, opts.Rng.Float64
+	}
+	if opts.Values != nil {
+		value = opts.Values
+	}
+	result := SparseCoo(rows, cols)
+	fill := func(i, j int) {
+		v := value()
+		for v == 0 {
+			v = value()
+		}
+		result.ItemSet(v, i, j)
+		if opts.Symmetric {
+			result.ItemSet(v, j, i)
+		}
+	}
+
+	switch opts.Pattern {
+	case SparsePatternRandom:
+		span := func(i int) (int, int) {
+			if opts.Symmetric {
+				return 0, i + 1
+			}
+			return 0, cols
+		}
+		sampleCells(rows, span, density, opts.RowNonzeros, intn, fill)
+
+	case SparsePatternBanded:
+		if opts.Bandwidth < 0 {
+			panic(fmt.Sprintf("Can't create a banded random matrix with bandwidth %d", opts.Bandwidth))
+		}
+		span := func(i int) (int, int) {
+			lo, hi := i-opts.Bandwidth, i+opts.Bandwidth+1
+			if opts.Symmetric {
+				hi = i + 1
+			}
+			if lo < 0 {
+				lo = 0
+			}
+			if hi > cols {
+				hi = cols
+			}
+			if hi < lo {
+				hi = lo
+			}
+			return lo, hi
+		}
+		sampleCells(rows, span, density, opts.RowNonzeros, intn, fill)
+
+	case SparsePatternBlock:
+		br, bc := opts.BlockRows, opts.BlockCols
+		if br <= 0 || bc <= 0 || (opts.Symmetric && br != bc) {
+			panic(fmt.Sprintf("Can't create a block random matrix with %dx%d blocks", br, bc))
+		}
+		gridRows, gridCols := (rows+br-1)/br, (cols+bc-1)/bc
+		span := func(i int) (int, int) {
+			if opts.Symmetric {
+				return 0, i + 1
+			}
+			return 0, gridCols
+		}
+		sampleCells(gridRows, span, density, 0, intn, func(bi, bj int) {
+			for i := bi * br; i < (bi+1)*br && i < rows; i++ {
+				for j := bj * bc; j < (bj+1)*bc && j < cols; j++ {
+					if !opts.Symmetric || bi != bj || j <= i {
+						fill(i, j)
+					}
+				}
+			}
+		})
+
+	default:
+		panic(fmt.Sprintf("Unknown sparse pattern %d", opts.Pattern))
+	}
+	return result
+}
+
+// Visit distinct randomly chosen cells. Row i may use columns [lo, hi) as
+// given by span(i). If perRow is positive, exactly perRow cells are chosen
+// from each row; otherwise int(density * n) cells are chosen from all n
+// candidate cells.
+func sampleCells(rows int, span func(i int) (int, int), density float64, perRow int,
+	intn func(int) int, visit func(i, j int)) {
+
+	if perRow > 0 {
+		for i := 0; i < rows; i++ {
+			lo, hi := span(i)
+			if perRow > hi-lo {
+				panic(fmt.Sprintf("Can't fill %d cells in row %d, which has %d candidates", perRow, i, hi-lo))
+			}
+			sampleWithoutReplacement(hi-lo, perRow, intn, func(k int) {
+				visit(i, lo+k)
+			})
+		}
+		return
+	}
+
+	// Number the candidate cells row by row
+	offsets := make([]int, rows+1)
+	for i := 0; i < rows; i++ {
+		lo, hi := span(i)
+		offsets[i+1] = offsets[i] + hi - lo
+	}
+	total := offsets[rows]
+	sampleWithoutReplacement(total, int(float64(total)*density), intn, func(k int) {
+		i := sort.Search(rows, func(r int) bool { return offsets[r+1] > k })
+		lo, _ := span(i)
+		visit(i, lo+k-offsets[i])
+	})
+}
+
+// Visit k distinct integers chosen uniformly from [0, n), using Floyd's
+// algorithm in O(k) time and space
+func sampleWithoutReplacement(n, k int, intn func(int) int, visit func(int)) {
+	chosen := make(map[int]bool, k)
+	for j := n - k; j < n; j++ {
+		t := intn(j + 1)
+		if chosen[t] {
+			t = j
+		}
+		chosen[t] = true
+		visit(t)
+	}
+}
@@ -0,0 +1,181 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math/rand"
+	"testing"
+)
+
+func TestSparseRandOpts(t *testing.T) {
+	Convey("Given the default options", t, func() {
+		opts := SparseRandOptions{}
+
+		Convey("Density 1 fills every cell", func() {
+			m := SparseRandOpts(20, 30, 1, opts)
+			So(m.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(m.CountNonzero(), ShouldEqual, 600)
+		})
+
+		Convey("Density 0 fills no cells", func() {
+			So(SparseRandOpts(20, 30, 0, opts).CountNonzero(), ShouldEqual, 0)
+		})
+
+		Convey("Large sparse matrices are fast to create", func() {
+			m := SparseRandOpts(100000, 100000, 1e-7, opts)
+			So(m.CountNonzero(), ShouldEqual, 1000)
+		})
+
+		Convey("Invalid densities panic", func() {
+			So(func() { SparseRandOpts(2, 2, -0.5, opts) }, ShouldPanic)
+			So(func() { SparseRandOpts(2, 2, 1.5, opts) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a value callback and a source", t, func() {
+		opts := SparseRandOptions{
+			Values: func() float64 { return 7 },
+			Rng:    rand.New(rand.NewSource(1)),
+		}
+
+		Convey("All filled cells use the callback's values", func() {
+			m := SparseRandOpts(10, 10, 0.3, opts)
+			So(m.CountNonzero(), ShouldEqual, 30)
+			So(m.Sum(), ShouldEqual, 210)
+		})
+
+		Convey("The same seed chooses the same cells", func() {
+			m1 := SparseRandOpts(10, 10, 0.3, opts)
+			opts.Rng = rand.New(rand.NewSource(1))
+			m2 := SparseRandOpts(10, 10, 0.3, opts)
+			So(m1.Equal(m2), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given symmetric output", t, func() {
+		opts := SparseRandOptions{Symmetric: true}
+
+		Convey("The matrix equals its transpose", func() {
+			m := SparseRandOpts(15, 15, 0.4, opts)
+			So(m.Equal(m.T()), ShouldBeTrue)
+			So(m.CountNonzero(), ShouldBeGreaterThan, 0)
+		})
+
+		Convey("Density 1 fills every cell", func() {
+			So(SparseRandOpts(6, 6, 1, opts).CountNonzero(), ShouldEqual, 36)
+		})
+
+		Convey("Non-square matrices panic", func() {
+			So(func() { SparseRandOpts(2, 3, 0.5, opts) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a fixed number of nonzeros per row", t, func() {
+		opts := SparseRandOptions{RowNonzeros: 3}
+
+		Convey("Each row has that many nonzeros", func() {
+			m := SparseRandOpts(12, 8, 0, opts)
+			for i := 0; i < 12; i++ {
+				count := 0
+				for j := 0; j < 8; j++ {
+					if m.Item(i, j) != 0 {
+						count++
+					}
+				}
+				So(count, ShouldEqual, 3)
+			}
+		})
+
+		Convey("Rows with too few columns panic", func() {
+			So(func() { SparseRandOpts(3, 2, 0, opts) }, ShouldPanic)
+		})
+
+		Convey("Symmetric and block matrices panic", func() {
+			opts.Symmetric = true
+			So(func() { SparseRandOpts(5, 5, 0, opts) }, ShouldPanic)
+			opts.Symmetric = false
+			opts.Pattern = SparsePatternBlock
+			So(func() { SparseRandOpts(5, 5, 0, opts) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a banded pattern", t, func() {
+		opts := SparseRandOptions{Pattern: SparsePatternBanded, Bandwidth: 1}
+
+		Convey("Density 1 fills the band", func() {
+			m := SparseRandOpts(5, 6, 1, opts)
+			So(m.CountNonzero(), ShouldEqual, 14)
+			m.VisitNonzero(func(pos []int, value float64) bool {
+				So(pos[1]-pos[0], ShouldBeBetweenOrEqual, -1, 1)
+				return true
+			})
+		})
+
+		Convey("Partial density fills part of the band", func() {
+			m := SparseRandOpts(50, 50, 0.5, opts)
+			So(m.CountNonzero(), ShouldEqual, 74)
+			m.VisitNonzero(func(pos []int, value float64) bool {
+				So(pos[1]-pos[0], ShouldBeBetweenOrEqual, -1, 1)
+				return true
+			})
+		})
+
+		Convey("Symmetric banded matrices equal their transposes", func() {
+			opts.Symmetric = true
+			m := SparseRandOpts(30, 30, 0.5, opts)
+			So(m.Equal(m.T()), ShouldBeTrue)
+		})
+
+		Convey("Rows can have a fixed number of nonzeros", func() {
+			opts.Bandwidth = 2
+			opts.RowNonzeros = 2
+			So(SparseRandOpts(10, 10, 0, opts).CountNonzero(), ShouldEqual, 20)
+		})
+
+		Convey("Negative bandwidths panic", func() {
+			opts.Bandwidth = -1
+			So(func() { SparseRandOpts(5, 5, 1, opts) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a block pattern", t, func() {
+		opts := SparseRandOptions{Pattern: SparsePatternBlock, BlockRows: 2, BlockCols: 3}
+
+		Convey("Chosen blocks are filled completely", func() {
+			m := SparseRandOpts(8, 9, 0.5, opts)
+			So(m.CountNonzero(), ShouldEqual, 36)
+			for bi := 0; bi < 4; bi++ {
+				for bj := 0; bj < 3; bj++ {
+					count := 0
+					for i := 2 * bi; i < 2*bi+2; i++ {
+						for j := 3 * bj; j < 3*bj+3; j++ {
+							if m.Item(i, j) != 0 {
+								count++
+							}
+						}
+					}
+					So(count == 0 || count == 6, ShouldBeTrue)
+				}
+			}
+		})
+
+		Convey("Edge blocks are truncated", func() {
+			So(SparseRandOpts(5, 4, 1, opts).CountNonzero(), ShouldEqual, 20)
+		})
+
+		Convey("Symmetric block matrices equal their transposes", func() {
+			opts.BlockCols = 2
+			opts.Symmetric = true
+			m := SparseRandOpts(9, 9, 0.5, opts)
+			So(m.Equal(m.T()), ShouldBeTrue)
+			So(SparseRandOpts(9, 9, 1, opts).CountNonzero(), ShouldEqual, 81)
+		})
+
+		Convey("Invalid block sizes panic", func() {
+			opts.BlockRows = 0
+			So(func() { SparseRandOpts(5, 5, 1, opts) }, ShouldPanic)
+			opts.BlockRows = 2
+			opts.Symmetric = true
+			So(func() { SparseRandOpts(6, 6, 1, opts) }, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,383 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// How Quantile and Percentile choose a value when the requested quantile
+// falls between two array elements. These match the methods of the same
+// names in NumPy.
+type QuantileMethod int
+
+const (
+	// Interpolate linearly between the two elements
+	QuantileLinear QuantileMethod = iota
+
+	// Use the smaller element
+	QuantileLower
+
+	// Use the larger element
+	QuantileHigher
+
+	// Use the nearer element, rounding ties to the even index
+	QuantileNearest
+
+	// Use the average of the two elements
+	QuantileMidpoint
+)
+
+// Get the shape of an array reduced along an axis. Reducing a 1D array
+// produces an array of shape [1].
+func reducedShape(shape []int, axis int) []int {
+	if axis < 0 || axis >= len(shape) {
+		panic(fmt.Sprintf("Axis %d is invalid for array shape %v", axis, shape))
+	}
+	if len(shape) == 1 {
+		return []int{1}
+	}
+	result := make([]int, 0, len(shape)-1)
+	result = append(result, shape[:axis]...)
+	return append(result, shape[axis+1:]...)
+}
+
+// Get the flat index, in an array reduced along axis, of the lane holding the
+// element at pos
+func laneIndex(shape []int, axis int, pos []int) int {
+	flat := 0
+	for i := range shape {
+		if i != axis {
+			flat = flat*shape[i] + pos[i]
+		}
+	}
+	return flat
+}
+
+// Collect the nonzero values of each lane along an axis. Zeros are implied by
+// the lane length, so sparse arrays aren't densified.
+func nonzeroLanes(array NDArray, axis int) (lanes [][]float64, shape []int) {
+	shape = reducedShape(array.Shape(), axis)
+	size := 1
+	for _, sz := range shape {
+		size *= sz
+	}
+	lanes = make([][]float64, size)
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		i := laneIndex(array.Shape(), axis, pos)
+		lanes[i] = append(lanes[i], value)
+		return true
+	})
+	return lanes, shape
+}
+
+// Get the arithmetic mean of all array elements
+func Mean(array NDArray) float64 {
+	return array.Sum() / float64(array.Size())
+}
+
+// Get the arithmetic mean along an axis
+func MeanAxis(array NDArray, axis int) NDArray {
+	n := float64(array.Shape()[axis])
+	return SumAxis(array, axis).ItemDiv(n)
+}
+
+// Get the sum along an axis
+func SumAxis(array NDArray, axis int) NDArray {
+	lanes, shape := nonzeroLanes(array, axis)
+	sums := make([]float64, len(lanes))
+	for i, lane := range lanes {
+		for _, v := range lane {
+			sums[i] += v
+		}
+	}
+	return A(shape, sums...)
+}
+
+// Get the variance of n values whose nonzero elements are given, dividing by
+// n - ddof. The mean is subtracted before squaring for numerical stability.
+func variance(nonzero []float64, n, ddof int) float64 {
+	if n-ddof <= 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, v := range nonzero {
+		sum += v
+	}
+	mean := sum / float64(n)
+	var ss float64
+	for _, v := range nonzero {
+		ss += (v - mean) * (v - mean)
+	}
+	ss += float64(n-len(nonzero)) * mean * mean
+	return ss / float64(n-ddof)
+}
+
+// Get the variance of all array elements. The sum of squared deviations is
+// divided by Size() - ddof, so ddof=0 gives the population variance and
+// ddof=1 gives the unbiased sample variance.
+func Var(array NDArray, ddof int) float64 {
+	var nonzero []float64
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		nonzero = append(nonzero, value)
+		return true
+	})
+	return variance(nonzero, array.Size(), ddof)
+}
+
+// Get the variance along an axis; see Var
+func VarAxis(array NDArray, axis, ddof int) NDArray {
+	lanes, shape := nonzeroLanes(array, axis)
+	n := array.Shape()[axis]
+	result := Dense(shape...)
+	for i, lane := range lanes {
+		result.FlatItemSet(variance(lane, n, ddof), i)
+	}
+	return result
+}
+
+// Get the standard deviation of all array elements; see Var
+func Std(array NDArray, ddof int) float64 {
+	return math.Sqrt(Var(array, ddof))
+}
+
+// Get the standard deviation along an axis; see Var
+func StdAxis(array NDArray, axis, ddof int) NDArray {
+	return VarAxis(array, axis, ddof).Apply(math.Sqrt)
+}
+
+// Get the q-th quantile, for q in [0, 1], of n values whose nonzero elements
+// are given. The implied zeros are placed between the negative and positive
+// values rather than materialized.
+func quantile(nonzero []float64, n int, q float64, method QuantileMethod) float64 {
+	if q < 0 || q > 1 {
+		panic(fmt.Sprintf("Quantile %f should be in [0, 1]", q))
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64{}, nonzero...)
+	sort.Float64s(sorted)
+	neg := sort.SearchFloat64s(sorted, 0)
+	zeros := n - len(sorted)
+	get := func(k int) float64 {
+		switch {
+		case k < neg:
+			return sorted[k]
+		case k < neg+zeros:
+			return 0
+		default:
+			return sorted[k-zeros]
+		}
+	}
+
+	h := q * float64(n-1)
+	lo := int(math.Floor(h))
+	hi := int(math.Ceil(h))
+	switch method {
+	case QuantileLinear:
+		vlo := get(lo)
+		return vlo + (h-float64(lo))*(get(hi)-vlo)
+	case QuantileLower:
+		return get(lo)
+	case QuantileHigher:
+		return get(hi)
+	case QuantileNearest:
+		return get(int(math.RoundToEven(h)))
+	case QuantileMidpoint:
+		return (get(lo) + get(hi)) / 2
+	default:
+		panic(fmt.Sprintf("Unknown quantile method %d", method))
+	}
+}
+
+// Get the q-th quantile of all array elements, for q in [0, 1]
+func Quantile(array NDArray, q float64, method QuantileMethod) float64 {
+	var nonzero []float64
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		nonzero = append(nonzero, value)
+		return true
+	})
+	return quantile(nonzero, array.Size(), q, method)
+}
+
+// Get the q-th quantile along an axis, for q in [0, 1]
+func QuantileAxis(array NDArray, q float64, method QuantileMethod, axis int) NDArray {
+	lanes, shape := nonzeroLanes(array, axis)
+	n := array.Shape()[axis]
+	result := Dense(shape...)
+	for i, lane := range lanes {
+		result.FlatItemSet(quantile(lane, n, q, method), i)
+	}
+	return result
+}
+
+// Get the q-th percentile of all array elements, for q in [0, 100]
+func Percentile(array NDArray, q float64, method QuantileMethod) float64 {
+	return Quantile(array, q/100, method)
+}
+
+// Get the q-th percentile along an axis, for q in [0, 100]
+func PercentileAxis(array NDArray, q float64, method QuantileMethod, axis int) NDArray {
+	return QuantileAxis(array, q/100, method, axis)
+}
+
+// Get the median of all array elements
+func Median(array NDArray) float64 {
+	return Quantile(array, 0.5, QuantileLinear)
+}
+
+// Get the median along an axis
+func MedianAxis(array NDArray, axis int) NDArray {
+	return QuantileAxis(array, 0.5, QuantileLinear, axis)
+}
+
+// Get the weighted average of all array elements. The weights must have the
+// same shape as the array, and must not sum to zero.
+func Average(array, weights NDArray) float64 {
+	if !sameShape(array.Shape(), weights.Shape()) {
+		panic(fmt.Sprintf("Can't average array of shape %v with weights of shape %v", array.Shape(), weights.Shape()))
+	}
+	total := weights.Sum()
+	if total == 0 {
+		panic("Can't average with weights which sum to zero")
+	}
+	var sum float64
+	array.VisitNonzero(func(pos []int, value float64) bool {
+		sum += value * weights.Item(pos...)
+		return true
+	})
+	return sum / total
+}
+
+// Get the weighted average along an axis. The weights must either have the
+// same shape as the array or be a 1D array with one weight per position along
+// the axis. Panics if the weights of any lane sum to zero.
+func AverageAxis(array, weights NDArray, axis int) NDArray {
+	shape := array.Shape()
+	sums := SumAxis(array.Prod(broadcastWeights(shape, weights, axis)), axis)
+	var totals NDArray
+	if sameShape(shape, weights.Shape()) {
+		totals = SumAxis(weights, axis)
+	} else {
+		totals = WithValue(weights.Sum(), reducedShape(shape, axis)...)
+	}
+	if !totals.All() {
+		panic("Can't average with weights which sum to zero")
+	}
+	return sums.Div(totals)
+}
+
+// Expand a 1D array of weights along an axis to the given shape
+func broadcastWeights(shape []int, weights NDArray, axis int) NDArray {
+	if sameShape(shape, weights.Shape()) {
+		return weights
+	}
+	if weights.NDim() != 1 || axis < 0 || axis >= len(shape) || weights.Size() != shape[axis] {
+		panic(fmt.Sprintf("Can't average array of shape %v with weights of shape %v along axis %d", shape, weights.Shape(), axis))
+	}
+	result := Dense(shape...)
+	max := result.Size()
+	for i := 0; i < max; i++ {
+		result.FlatItemSet(weights.FlatItem(flatToNd(shape, i)[axis]), i)
+	}
+	return result
+}
+
+// Returns true if two shapes are equal
+func sameShape(sh1, sh2 []int) bool {
+	if len(sh1) != len(sh2) {
+		return false
+	}
+	for i := range sh1 {
+		if sh1[i] != sh2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the covariance matrix of a set of variables. If rowVar is true, each
+// row of m is a variable and each column an observation, as in NumPy;
+// otherwise each column is a variable. Sums of squared deviations are divided
+// by the number of observations minus ddof. Sparse matrices are not
+// densified: only products of nonzero values are accumulated.
+func Cov(m Matrix, rowVar bool, ddof int) Matrix {
+	if rowVar {
+		m = m.T()
+	}
+	nobs, nvars := m.Rows(), m.Cols()
+	means := MeanAxis(m, 0).Array()
+	result := Dense(nvars, nvars).M()
+	denom := float64(nobs - ddof)
+	if nobs-ddof <= 0 {
+		result.Fill(math.NaN())
+		return result
+	}
+
+	cross := make([]float64, nvars*nvars)
+	if m.Sparsity() == DenseArray {
+		centered := make([]float64, nvars)
+		for k := 0; k < nobs; k++ {
+			for i := range centered {
+				centered[i] = m.FlatItem(k*nvars+i) - means[i]
+			}
+			for i := range centered {
+				for j := 0; j <= i; j++ {
+					cross[i*nvars+j] += centered[i] * centered[j]
+				}
+			}
+		}
+	} else {
+		// sum_k (x_ki - mu_i)(x_kj - mu_j) = sum_k x_ki x_kj - n mu_i mu_j
+		obs := make(map[int][][2]float64)
+		m.VisitNonzero(func(pos []int, value float64) bool {
+			obs[pos[0]] = append(obs[pos[0]], [2]float64{float64(pos[1]), value})
+			return true
+		})
+		for _, values := range obs {
+			for _, a := range values {
+				for _, b := range values {
+					if i, j := int(a[0]), int(b[0]); j <= i {
+						cross[i*nvars+j] += a[1] * b[1]
+					}
+				}
+			}
+		}
+		for i := 0; i < nvars; i++ {
+			for j := 0; j <= i; j++ {
+				cross[i*nvars+j] -= float64(nobs) * means[i] * means[j]
+			}
+		}
+	}
+	for i := 0; i < nvars; i++ {
+		for j := 0; j <= i; j++ {
+			result.ItemSet(cross[i*nvars+j]/denom, i, j)
+			result.ItemSet(cross[i*nvars+j]/denom, j, i)
+		}
+	}
+	return result
+}
+
+// Get the matrix of Pearson correlation coefficients of a set of variables.
+// See Cov for the meaning of rowVar.
+func Corrcoef(m Matrix, rowVar bool) Matrix {
+	c := Cov(m, rowVar, 1)
+	n := c.Rows()
+	std := make([]float64, n)
+	for i := range std {
+		std[i] = math.Sqrt(c.Item(i, i))
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			r := c.Item(i, j) / (std[i] * std[j])
+			if r > 1 {
+				r = 1
+			} else if r < -1 {
+				r = -1
+			}
+			c.ItemSet(r, i, j)
+		}
+	}
+	return c
+}
@@ -0,0 +1,203 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestMeanVarStd(t *testing.T) {
+	Convey("Given a 1D array", t, func() {
+		a := A1(1, 2, 3, 4)
+
+		Convey("Mean, Var and Std are correct", func() {
+			So(Mean(a), ShouldEqual, 2.5)
+			So(Var(a, 0), ShouldEqual, 1.25)
+			So(Var(a, 1), ShouldAlmostEqual, 5.0/3, Eps)
+			So(Std(a, 0), ShouldAlmostEqual, math.Sqrt(1.25), Eps)
+		})
+
+		Convey("Reducing along axis 0 gives shape [1]", func() {
+			So(MeanAxis(a, 0).Equal(A1(2.5)), ShouldBeTrue)
+		})
+
+		Convey("Too large a ddof gives NaN", func() {
+			So(math.IsNaN(Var(a, 4)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a dense matrix", t, func() {
+		m := M(3, 2,
+			1, 2,
+			3, 5,
+			5, 11)
+
+		Convey("Axis statistics are correct", func() {
+			So(SumAxis(m, 0).Equal(A1(9, 18)), ShouldBeTrue)
+			So(MeanAxis(m, 0).Equal(A1(3, 6)), ShouldBeTrue)
+			So(MeanAxis(m, 1).Equal(A1(1.5, 4, 8)), ShouldBeTrue)
+			v := VarAxis(m, 0, 0)
+			So(v.FlatItem(0), ShouldAlmostEqual, 8.0/3, Eps)
+			So(v.FlatItem(1), ShouldAlmostEqual, 14, Eps)
+			So(StdAxis(m, 1, 0).Equal(A1(0.5, 1, 3)), ShouldBeTrue)
+		})
+
+		Convey("Transposed matrices reduce along logical axes", func() {
+			So(MeanAxis(m.T(), 1).Equal(A1(3, 6)), ShouldBeTrue)
+		})
+
+		Convey("Sparse matrices give the same results", func() {
+			for _, sp := range []Matrix{m.SparseCoo(), m.SparseCoo().T().T()} {
+				for axis := 0; axis < 2; axis++ {
+					So(SumAxis(sp, axis).Equal(SumAxis(m, axis)), ShouldBeTrue)
+					So(VarAxis(sp, axis, 1).AllF2(func(v1, v2 float64) bool {
+						return math.Abs(v1-v2) < Eps
+					}, VarAxis(m, axis, 1)), ShouldBeTrue)
+				}
+				So(Var(sp, 1), ShouldAlmostEqual, Var(m, 1), Eps)
+			}
+		})
+
+		Convey("Invalid axes panic", func() {
+			So(func() { MeanAxis(m, 2) }, ShouldPanic)
+			So(func() { VarAxis(m, -1, 0) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a 3D array", t, func() {
+		a := A([]int{2, 3, 2}, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)
+
+		Convey("Reducing an axis removes it from the shape", func() {
+			s := SumAxis(a, 1)
+			So(s.Shape(), ShouldResemble, []int{2, 2})
+			So(s.Equal(A([]int{2, 2}, 6, 9, 24, 27)), ShouldBeTrue)
+			So(SumAxis(a, 2).Equal(A([]int{2, 3}, 1, 5, 9, 13, 17, 21)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a sparse diagonal matrix", t, func() {
+		d := Diag(2, 4, 6)
+		So(MeanAxis(d, 0).Equal(A1(2.0/3, 4.0/3, 2)), ShouldBeTrue)
+		So(Var(d, 0), ShouldAlmostEqual, Var(d.Dense(), 0), Eps)
+	})
+}
+
+func TestQuantile(t *testing.T) {
+	Convey("Given a 1D array", t, func() {
+		a := A1(4, 1, 3, 2)
+
+		Convey("Median is correct", func() {
+			So(Median(a), ShouldEqual, 2.5)
+			So(Median(A1(3, 1, 2)), ShouldEqual, 2)
+		})
+
+		Convey("Each quantile method is correct", func() {
+			So(Percentile(a, 40, QuantileLinear), ShouldAlmostEqual, 2.2, Eps)
+			So(Percentile(a, 40, QuantileLower), ShouldEqual, 2)
+			So(Percentile(a, 40, QuantileHigher), ShouldEqual, 3)
+			So(Percentile(a, 40, QuantileNearest), ShouldEqual, 2)
+			So(Percentile(a, 50, QuantileNearest), ShouldEqual, 3)
+			So(Percentile(a, 40, QuantileMidpoint), ShouldEqual, 2.5)
+			So(Quantile(a, 0, QuantileLinear), ShouldEqual, 1)
+			So(Quantile(a, 1, QuantileLinear), ShouldEqual, 4)
+		})
+
+		Convey("Invalid quantiles panic", func() {
+			So(func() { Quantile(a, 1.5, QuantileLinear) }, ShouldPanic)
+			So(func() { Percentile(a, -1, QuantileLinear) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given a sparse matrix with negative values", t, func() {
+		m := SparseCoo(2, 5,
+			-3, 0, 0, 2, 0,
+			1, 2, 3, 4, 5)
+
+		Convey("Implied zeros are ordered correctly", func() {
+			So(MedianAxis(m, 1).Equal(A1(0, 3)), ShouldBeTrue)
+			So(QuantileAxis(m, 0, QuantileLinear, 1).Equal(A1(-3, 1)), ShouldBeTrue)
+			So(QuantileAxis(m, 1, QuantileLinear, 1).Equal(A1(2, 5)), ShouldBeTrue)
+			So(PercentileAxis(m, 50, QuantileLinear, 0).Equal(A1(-1, 1, 1.5, 3, 2.5)), ShouldBeTrue)
+		})
+
+		Convey("Results match the dense matrix", func() {
+			So(Median(m), ShouldEqual, Median(m.Dense()))
+			So(Percentile(m, 30, QuantileLinear), ShouldEqual, Percentile(m.Dense(), 30, QuantileLinear))
+		})
+	})
+}
+
+func TestAverage(t *testing.T) {
+	Convey("Given a 1D array and weights", t, func() {
+		a := A1(1, 2, 3, 4)
+		So(Average(a, A1(4, 3, 2, 1)), ShouldEqual, 2)
+		So(Average(a, Ones(4)), ShouldEqual, Mean(a))
+		So(func() { Average(a, A1(1, -1, 0, 0)) }, ShouldPanic)
+		So(func() { Average(a, Ones(3)) }, ShouldPanic)
+	})
+
+	Convey("Given a matrix", t, func() {
+		m := M(3, 2,
+			1, 2,
+			3, 5,
+			5, 11)
+
+		Convey("1D weights apply along the axis", func() {
+			So(AverageAxis(m, A1(1, 3), 1).Equal(A1(1.75, 4.5, 9.5)), ShouldBeTrue)
+			So(AverageAxis(m.SparseCoo(), A1(1, 0, 1), 0).Equal(A1(3, 6.5)), ShouldBeTrue)
+		})
+
+		Convey("Full weights apply elementwise", func() {
+			w := M(3, 2,
+				1, 0,
+				1, 1,
+				0, 3)
+			So(AverageAxis(m, w, 0).Equal(A1(2, 9.5)), ShouldBeTrue)
+		})
+
+		Convey("Mismatched weights panic", func() {
+			So(func() { AverageAxis(m, A1(1, 2), 0) }, ShouldPanic)
+			So(func() { AverageAxis(m, A1(1, -1), 1) }, ShouldPanic)
+		})
+	})
+}
+
+func TestCov(t *testing.T) {
+	Convey("Given observations of two variables", t, func() {
+		m := M(3, 2,
+			1, 2,
+			3, 5,
+			5, 11)
+		expected := M(2, 2,
+			4, 9,
+			9, 21)
+		close := func(v1, v2 float64) bool { return math.Abs(v1-v2) < Eps }
+
+		Convey("Cov is correct with variables in columns", func() {
+			So(Cov(m, false, 1).AllF2(close, expected), ShouldBeTrue)
+		})
+
+		Convey("Cov is correct with variables in rows", func() {
+			So(Cov(m.T(), true, 1).AllF2(close, expected), ShouldBeTrue)
+			So(Cov(m.T(), true, 0).AllF2(close, expected.ItemProd(2.0/3)), ShouldBeTrue)
+		})
+
+		Convey("Cov is correct for sparse matrices", func() {
+			So(Cov(m.SparseCoo(), false, 1).AllF2(close, expected), ShouldBeTrue)
+			So(Cov(Diag(1, 2), false, 0).AllF2(close, M(2, 2, 0.25, -0.5, -0.5, 1)), ShouldBeTrue)
+		})
+
+		Convey("Corrcoef is correct", func() {
+			r := Corrcoef(m, false)
+			So(r.Item(0, 0), ShouldAlmostEqual, 1, Eps)
+			So(r.Item(1, 1), ShouldAlmostEqual, 1, Eps)
+			So(r.Item(0, 1), ShouldAlmostEqual, 9/math.Sqrt(84), Eps)
+			So(r.Item(1, 0), ShouldAlmostEqual, 9/math.Sqrt(84), Eps)
+			So(Corrcoef(M(2, 2, 1, -1, 2, -2), false).Item(0, 1), ShouldAlmostEqual, -1, Eps)
+		})
+
+		Convey("Too few observations give NaN", func() {
+			So(math.IsNaN(Cov(M(1, 2, 1, 2), false, 1).Item(0, 0)), ShouldBeTrue)
+		})
+	})
+}
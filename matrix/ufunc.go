@@ -0,0 +1,222 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+)
+
+// A named elementwise function. Functions with f(0) == 0 preserve sparsity:
+// applying them to a sparse matrix only visits its nonzero elements and
+// produces a sparse matrix of the same type.
+type UFunc struct {
+
+	// The name used when reporting floating-point errors
+	Name string
+
+	// The function to apply to each element
+	F func(float64) float64
+
+	// Whether F(0) == 0
+	PreservesZero bool
+}
+
+// Create a UFunc, recording whether f(0) == 0
+func NewUFunc(name string, f func(float64) float64) UFunc {
+	return UFunc{
+		Name:          name,
+		F:             f,
+		PreservesZero: f(0) == 0,
+	}
+}
+
+// Apply the function to each array element. Floating-point errors are
+// reported under the function's name; see SetErr.
+func (u UFunc) Apply(array NDArray) NDArray {
+	errs := floatErrors{op: u.Name}
+	var result NDArray
+	if u.PreservesZero && array.Sparsity() != DenseArray {
+		result = array.Copy()
+		result.VisitNonzero(func(pos []int, value float64) bool {
+			v := u.F(value)
+			errs.check(value, v)
+			result.ItemSet(v, pos[0], pos[1])
+			return true
+		})
+	} else {
+		result = array.Dense()
+		size := result.Size()
+		for i := 0; i < size; i++ {
+			in := result.FlatItem(i)
+			v := u.F(in)
+			errs.check(in, v)
+			result.FlatItemSet(v, i)
+		}
+	}
+	errs.report()
+	return result
+}
+
+var (
+	absFunc     = NewUFunc("Abs", math.Abs)
+	cosFunc     = NewUFunc("Cos", math.Cos)
+	expFunc     = NewUFunc("Exp", math.Exp)
+	floorFunc   = NewUFunc("Floor", math.Floor)
+	logFunc     = NewUFunc("Log", math.Log)
+	log1pFunc   = NewUFunc("Log1p", math.Log1p)
+	roundFunc   = NewUFunc("Round", math.RoundToEven)
+	sigmoidFunc = NewUFunc("Sigmoid", sigmoid)
+	signFunc    = NewUFunc("Sign", sign)
+	sinFunc     = NewUFunc("Sin", math.Sin)
+	sqrtFunc    = NewUFunc("Sqrt", math.Sqrt)
+	tanhFunc    = NewUFunc("Tanh", math.Tanh)
+)
+
+// The logistic function
+func sigmoid(v float64) float64 {
+	if v >= 0 {
+		return 1 / (1 + math.Exp(-v))
+	}
+	e := math.Exp(v)
+	return e / (1 + e)
+}
+
+// The sign of a value: -1, 0 or 1, or NaN for NaN
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return v
+	}
+}
+
+// Get the absolute value of each element
+func Abs(array NDArray) NDArray {
+	return absFunc.Apply(array)
+}
+
+// Get the cosine of each element
+func Cos(array NDArray) NDArray {
+	return cosFunc.Apply(array)
+}
+
+// Get e raised to the power of each element
+func Exp(array NDArray) NDArray {
+	return expFunc.Apply(array)
+}
+
+// Round each element down to an integer
+func Floor(array NDArray) NDArray {
+	return floorFunc.Apply(array)
+}
+
+// Get the natural logarithm of each element
+func Log(array NDArray) NDArray {
+	return logFunc.Apply(array)
+}
+
+// Get the natural logarithm of one plus each element, accurately for
+// elements near zero
+func Log1p(array NDArray) NDArray {
+	return log1pFunc.Apply(array)
+}
+
+// Round each element to the nearest integer, rounding halves to even as
+// NumPy does
+func Round(array NDArray) NDArray {
+	return roundFunc.Apply(array)
+}
+
+// Get the logistic sigmoid 1 / (1 + e^-x) of each element
+func Sigmoid(array NDArray) NDArray {
+	return sigmoidFunc.Apply(array)
+}
+
+// Get the sign of each element: -1, 0 or 1
+func Sign(array NDArray) NDArray {
+	return signFunc.Apply(array)
+}
+
+// Get the sine of each element
+func Sin(array NDArray) NDArray {
+	return sinFunc.Apply(array)
+}
+
+// Get the square root of each element
+func Sqrt(array NDArray) NDArray {
+	return sqrtFunc.Apply(array)
+}
+
+// Get the hyperbolic tangent of each element
+func Tanh(array NDArray) NDArray {
+	return tanhFunc.Apply(array)
+}
+
+// Raise each element to a power. Positive powers preserve sparsity.
+func Pow(array NDArray, power float64) NDArray {
+	return NewUFunc("Pow", func(v float64) float64 {
+		return math.Pow(v, power)
+	}).Apply(array)
+}
+
+// Limit each element to the range [min, max]. Ranges containing zero
+// preserve sparsity.
+func Clip(array NDArray, min, max float64) NDArray {
+	if min > max {
+		panic(fmt.Sprintf("Clip range [%f, %f] is empty", min, max))
+	}
+	return NewUFunc("Clip", func(v float64) float64 {
+		return math.Max(min, math.Min(max, v))
+	}).Apply(array)
+}
+
+// Apply a binary function with f(0, 0) == 0 to pairs of elements in the same
+// position. If both arrays are sparse, only the union of their nonzero
+// positions is visited and the result is sparse coo; otherwise it's dense.
+func applyPairs(name string, a, b NDArray, f func(v1, v2 float64) float64) NDArray {
+	if !sameShape(a.Shape(), b.Shape()) {
+		panic(fmt.Sprintf("Can't apply %s to arrays with shapes %v and %v", name, a.Shape(), b.Shape()))
+	}
+	if a.Sparsity() == DenseArray || b.Sparsity() == DenseArray {
+		result := a.Dense()
+		size := result.Size()
+		for i := 0; i < size; i++ {
+			result.FlatItemSet(f(result.FlatItem(i), b.FlatItem(i)), i)
+		}
+		return result
+	}
+	result := SparseCoo(a.Shape()[0], a.Shape()[1])
+	a.VisitNonzero(func(pos []int, value float64) bool {
+		result.ItemSet(f(value, b.Item(pos[0], pos[1])), pos[0], pos[1])
+		return true
+	})
+	b.VisitNonzero(func(pos []int, value float64) bool {
+		if a.Item(pos[0], pos[1]) == 0 {
+			result.ItemSet(f(0, value), pos[0], pos[1])
+		}
+		return true
+	})
+	return result
+}
+
+// Get the element-wise maximum of two arrays. NaN values propagate.
+func Maximum(a, b NDArray) NDArray {
+	return applyPairs("Maximum", a, b, func(v1, v2 float64) float64 {
+		if math.IsNaN(v1) || math.IsNaN(v2) {
+			return math.NaN()
+		}
+		return math.Max(v1, v2)
+	})
+}
+
+// Get the element-wise minimum of two arrays. NaN values propagate.
+func Minimum(a, b NDArray) NDArray {
+	return applyPairs("Minimum", a, b, func(v1, v2 float64) float64 {
+		if math.IsNaN(v1) || math.IsNaN(v2) {
+			return math.NaN()
+		}
+		return math.Min(v1, v2)
+	})
+}
@@ -0,0 +1,123 @@
+package matrix
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"testing"
+)
+
+func TestUFunc(t *testing.T) {
+	close := func(v1, v2 float64) bool { return math.Abs(v1-v2) < Eps }
+
+	Convey("NewUFunc records whether f(0) == 0", t, func() {
+		So(NewUFunc("Sin", math.Sin).PreservesZero, ShouldBeTrue)
+		So(NewUFunc("Cos", math.Cos).PreservesZero, ShouldBeFalse)
+	})
+
+	Convey("Given a dense array", t, func() {
+		a := A1(-2.5, -1, 0, 0.5, 1.5, 4)
+
+		Convey("Library functions are applied elementwise", func() {
+			So(Abs(a).Equal(A1(2.5, 1, 0, 0.5, 1.5, 4)), ShouldBeTrue)
+			So(Sign(a).Equal(A1(-1, -1, 0, 1, 1, 1)), ShouldBeTrue)
+			So(Floor(a).Equal(A1(-3, -1, 0, 0, 1, 4)), ShouldBeTrue)
+			So(Round(a).Equal(A1(-2, -1, 0, 0, 2, 4)), ShouldBeTrue)
+			So(Clip(a, -1, 1).Equal(A1(-1, -1, 0, 0.5, 1, 1)), ShouldBeTrue)
+			So(Pow(a, 2).Equal(A1(6.25, 1, 0, 0.25, 2.25, 16)), ShouldBeTrue)
+			So(Exp(A1(0, 1)).AllF2(close, A1(1, math.E)), ShouldBeTrue)
+			So(Log(A1(1, math.E)).AllF2(close, A1(0, 1)), ShouldBeTrue)
+			So(Log1p(A1(0, 1e-20)).Equal(A1(0, 1e-20)), ShouldBeTrue)
+			So(Sqrt(A1(4, 9)).Equal(A1(2, 3)), ShouldBeTrue)
+			So(Sin(A1(0, math.Pi/2)).AllF2(close, A1(0, 1)), ShouldBeTrue)
+			So(Cos(A1(0, math.Pi)).AllF2(close, A1(1, -1)), ShouldBeTrue)
+			So(Tanh(A1(0, 100)).Equal(A1(0, 1)), ShouldBeTrue)
+			So(Sigmoid(A1(0, 1000, -1000)).Equal(A1(0.5, 1, 0)), ShouldBeTrue)
+		})
+
+		Convey("The input is unchanged", func() {
+			Abs(a)
+			So(a.FlatItem(0), ShouldEqual, -2.5)
+		})
+
+		Convey("Invalid clip ranges panic", func() {
+			So(func() { Clip(a, 1, -1) }, ShouldPanic)
+		})
+	})
+
+	Convey("Given sparse matrices", t, func() {
+		coo := SparseCoo(2, 3,
+			0, -4, 0,
+			9, 0, 0)
+		diag := Diag(4, -9)
+
+		Convey("Zero-preserving functions keep sparse coo matrices sparse", func() {
+			r := Abs(coo)
+			So(r.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(r.Equal(M(2, 3, 0, 4, 0, 9, 0, 0)), ShouldBeTrue)
+			So(Sqrt(Abs(coo.T())).Equal(M(3, 2, 0, 3, 2, 0, 0, 0)), ShouldBeTrue)
+		})
+
+		Convey("Zero-preserving functions keep sparse diagonal matrices sparse", func() {
+			r := Sign(diag)
+			So(r.Sparsity(), ShouldEqual, SparseDiagMatrix)
+			So(r.Equal(Diag(1, -1)), ShouldBeTrue)
+			So(diag.Equal(Diag(4, -9)), ShouldBeTrue)
+		})
+
+		Convey("Values mapped to zero are dropped", func() {
+			So(Clip(coo, 0, 10).CountNonzero(), ShouldEqual, 1)
+		})
+
+		Convey("Other functions produce dense results", func() {
+			r := Exp(coo)
+			So(r.Sparsity(), ShouldEqual, DenseArray)
+			So(r.Item(0, 0), ShouldEqual, 1)
+			So(Pow(diag, 0).Equal(Ones(2, 2)), ShouldBeTrue)
+		})
+	})
+
+	Convey("Floating-point errors are reported under the function's name", t, func() {
+		old := SetErr(ErrState{Invalid: ErrRaise})
+		Reset(func() {
+			SetErr(old)
+		})
+		err := CatchFloatError(func() { Sqrt(SparseCoo(1, 2, -1, 4)) })
+		So(err, ShouldResemble, &FloatError{Invalid, "Sqrt", 1})
+	})
+}
+
+func TestMaximumMinimum(t *testing.T) {
+	Convey("Given dense arrays", t, func() {
+		a := A1(1, 5, -2, math.NaN())
+		b := A1(3, 4, -1, 0)
+		mx := Maximum(a, b)
+		mn := Minimum(a, b)
+		So(mx.Slice([]int{0}, []int{3}).Equal(A1(3, 5, -1)), ShouldBeTrue)
+		So(mn.Slice([]int{0}, []int{3}).Equal(A1(1, 4, -2)), ShouldBeTrue)
+		So(math.IsNaN(mx.FlatItem(3)), ShouldBeTrue)
+		So(math.IsNaN(mn.FlatItem(3)), ShouldBeTrue)
+	})
+
+	Convey("Given sparse matrices", t, func() {
+		a := SparseCoo(2, 2, 0, 3, -1, 0)
+		b := Diag(2, -5)
+
+		Convey("The result is sparse", func() {
+			mx := Maximum(a, b)
+			So(mx.Sparsity(), ShouldEqual, SparseCooMatrix)
+			So(mx.Equal(M(2, 2, 2, 3, 0, 0)), ShouldBeTrue)
+			So(Minimum(a, b).Equal(M(2, 2, 0, 0, -1, -5)), ShouldBeTrue)
+			So(Maximum(a.T(), b).Equal(M(2, 2, 2, 0, 3, 0)), ShouldBeTrue)
+		})
+
+		Convey("Mixing with dense arrays produces a dense result", func() {
+			r := Maximum(a, Ones(2, 2))
+			So(r.Sparsity(), ShouldEqual, DenseArray)
+			So(r.Equal(M(2, 2, 1, 3, 1, 1)), ShouldBeTrue)
+		})
+
+		Convey("Mismatched shapes panic", func() {
+			So(func() { Maximum(a, Eye(3)) }, ShouldPanic)
+		})
+	})
+}